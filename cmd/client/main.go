@@ -35,6 +35,6 @@ func main() {
 
 	log.Info("Client server is started at %v", cfg.Client.Port)
 	if err := http.ListenAndServe(":"+cfg.Client.Port, nil); err != nil {
-		log.Error("client server: error shile starting server: %e", err.Error())
+		log.Error("client server: error shile starting server: %s", err.Error())
 	}
 }
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"real-time-forum/database/migrations"
+	"real-time-forum/internal/config"
+	"real-time-forum/pkg/migrate"
+	"real-time-forum/pkg/sqlite"
+)
+
+const usage = "usage: migrate [-config-path path] up|down|status"
+
+func main() {
+	configPath := flag.String("config-path", "./configs/config.json", "Path to the config file")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := run(*configPath, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath string, command string) error {
+	cfg, err := config.NewConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	db, err := sqlite.ConnectDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("rolled back %04d_%s\n", m.Version, m.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+
+			fmt.Printf("%04d_%-28s %s\n", s.Version, s.Name, appliedAt)
+		}
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+
+	return nil
+}
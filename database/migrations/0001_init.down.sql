@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS session_token;
+
+DROP TABLE IF EXISTS post_image;
+
+DROP TABLE IF EXISTS vote_comment;
+
+DROP TABLE IF EXISTS vote_post;
+
+DROP TABLE IF EXISTS post_category;
+
+DROP TABLE IF EXISTS category;
+
+DROP TABLE IF EXISTS comment;
+
+DROP TABLE IF EXISTS post;
+
+DROP TABLE IF EXISTS user;
@@ -75,7 +75,7 @@ CREATE TABLE IF NOT EXISTS session_token (
     FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
 );
 
-INSERT INTO
+INSERT OR IGNORE INTO
     category (name)
 VALUES
     ("All"),
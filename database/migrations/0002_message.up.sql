@@ -0,0 +1,14 @@
+CREATE TABLE message (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    sender_id INTEGER NOT NULL,
+    recipient_id INTEGER NOT NULL,
+    message TEXT NOT NULL,
+    creation_time DATETIME NOT NULL,
+    readed BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (sender_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (recipient_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX message_sender_recipient_idx ON message (sender_id, recipient_id);
+
+CREATE INDEX message_recipient_sender_idx ON message (recipient_id, sender_id);
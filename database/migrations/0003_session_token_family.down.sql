@@ -0,0 +1,9 @@
+DROP TABLE session_token;
+
+CREATE TABLE session_token (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token TEXT DEFAULT NULL,
+    token_expiration_time DATETIME DEFAULT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
@@ -0,0 +1,14 @@
+-- sessions are replaced by refresh tokens, the old tokens can't be converted and are dropped
+DROP TABLE session_token;
+
+CREATE TABLE session_token (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token TEXT UNIQUE NOT NULL,
+    family TEXT NOT NULL,
+    used BOOLEAN NOT NULL DEFAULT FALSE,
+    token_expiration_time DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX session_token_family_idx ON session_token (family);
@@ -0,0 +1,2 @@
+-- the previous foreign keys referenced tables that never existed, they are not restored
+SELECT 1;
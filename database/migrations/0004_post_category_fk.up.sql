@@ -0,0 +1,21 @@
+-- foreign keys referenced the non-existent posts and categories tables
+CREATE TABLE post_category_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL,
+    category_id INTEGER NOT NULL,
+    FOREIGN KEY(post_id) REFERENCES post(id) ON DELETE CASCADE,
+    FOREIGN KEY(category_id) REFERENCES category(id) ON DELETE CASCADE
+);
+
+INSERT INTO
+    post_category_new (id, post_id, category_id)
+SELECT
+    id, post_id, category_id
+FROM
+    post_category
+WHERE
+    post_id IN (SELECT id FROM post) AND category_id IN (SELECT id FROM category);
+
+DROP TABLE post_category;
+
+ALTER TABLE post_category_new RENAME TO post_category;
@@ -0,0 +1,20 @@
+CREATE TABLE comment_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL,
+    author TEXT NOT NULL,
+    content TEXT NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES post(id) ON DELETE CASCADE
+);
+
+INSERT INTO
+    comment_old (id, post_id, author, content)
+SELECT
+    comment.id, comment.post_id, user.username, comment.content
+FROM
+    comment
+INNER JOIN user
+ON user.id = comment.user_id;
+
+DROP TABLE comment;
+
+ALTER TABLE comment_old RENAME TO comment;
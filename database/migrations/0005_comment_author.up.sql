@@ -0,0 +1,28 @@
+-- author was stored as username text, comments of unknown authors are dropped
+CREATE TABLE comment_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    image TEXT,
+    creation_time DATETIME NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES post(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+INSERT INTO
+    comment_new (id, post_id, user_id, content, creation_time)
+SELECT
+    comment.id, comment.post_id, user.id, comment.content, CURRENT_TIMESTAMP
+FROM
+    comment
+INNER JOIN user
+ON user.username = comment.author;
+
+DROP TABLE comment;
+
+ALTER TABLE comment_new RENAME TO comment;
+
+DELETE FROM vote_comment WHERE comment_id NOT IN (SELECT id FROM comment);
+
+CREATE INDEX comment_post_idx ON comment (post_id);
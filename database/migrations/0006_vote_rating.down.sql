@@ -0,0 +1,45 @@
+DROP TRIGGER vote_post_insert;
+
+DROP TRIGGER vote_post_update;
+
+DROP TRIGGER vote_post_delete;
+
+DROP TRIGGER vote_comment_insert;
+
+DROP TRIGGER vote_comment_update;
+
+DROP TRIGGER vote_comment_delete;
+
+ALTER TABLE post DROP COLUMN rating;
+
+ALTER TABLE comment DROP COLUMN rating;
+
+CREATE TABLE vote_post_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER DEFAULT NULL,
+    post_id INTEGER DEFAULT NULL,
+    vote INTEGER DEFAULT 0,
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES post(id) ON DELETE CASCADE
+);
+
+INSERT INTO vote_post_old (id, user_id, post_id, vote) SELECT id, user_id, post_id, vote FROM vote_post;
+
+DROP TABLE vote_post;
+
+ALTER TABLE vote_post_old RENAME TO vote_post;
+
+CREATE TABLE vote_comment_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER DEFAULT NULL,
+    comment_id INTEGER DEFAULT NULL,
+    vote INTEGER DEFAULT 0,
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comment(id) ON DELETE CASCADE
+);
+
+INSERT INTO vote_comment_old (id, user_id, comment_id, vote) SELECT id, user_id, comment_id, vote FROM vote_comment;
+
+DROP TABLE vote_comment;
+
+ALTER TABLE vote_comment_old RENAME TO vote_comment;
@@ -0,0 +1,87 @@
+-- a user has a single vote per target, stored as 1 or -1; the last vote wins
+CREATE TABLE vote_post_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    post_id INTEGER NOT NULL,
+    vote INTEGER NOT NULL CHECK (vote IN (-1, 1)),
+    UNIQUE (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES post(id) ON DELETE CASCADE
+);
+
+INSERT INTO
+    vote_post_new (id, user_id, post_id, vote)
+SELECT
+    id, user_id, post_id, CASE WHEN vote > 0 THEN 1 ELSE -1 END
+FROM
+    vote_post
+WHERE
+    vote != 0 AND id IN (SELECT MAX(id) FROM vote_post GROUP BY user_id, post_id)
+    AND user_id IN (SELECT id FROM user) AND post_id IN (SELECT id FROM post);
+
+DROP TABLE vote_post;
+
+ALTER TABLE vote_post_new RENAME TO vote_post;
+
+CREATE TABLE vote_comment_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    comment_id INTEGER NOT NULL,
+    vote INTEGER NOT NULL CHECK (vote IN (-1, 1)),
+    UNIQUE (user_id, comment_id),
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comment(id) ON DELETE CASCADE
+);
+
+INSERT INTO
+    vote_comment_new (id, user_id, comment_id, vote)
+SELECT
+    id, user_id, comment_id, CASE WHEN vote > 0 THEN 1 ELSE -1 END
+FROM
+    vote_comment
+WHERE
+    vote != 0 AND id IN (SELECT MAX(id) FROM vote_comment GROUP BY user_id, comment_id)
+    AND user_id IN (SELECT id FROM user) AND comment_id IN (SELECT id FROM comment);
+
+DROP TABLE vote_comment;
+
+ALTER TABLE vote_comment_new RENAME TO vote_comment;
+
+-- post.rating and comment.rating are the sums of the votes, kept in sync by triggers
+ALTER TABLE post ADD COLUMN rating INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE comment ADD COLUMN rating INTEGER NOT NULL DEFAULT 0;
+
+UPDATE post SET rating = IFNULL((SELECT SUM(vote) FROM vote_post WHERE post_id = post.id), 0);
+
+UPDATE comment SET rating = IFNULL((SELECT SUM(vote) FROM vote_comment WHERE comment_id = comment.id), 0);
+
+CREATE TRIGGER vote_post_insert AFTER INSERT ON vote_post
+BEGIN
+    UPDATE post SET rating = rating + NEW.vote WHERE id = NEW.post_id;
+END;
+
+CREATE TRIGGER vote_post_update AFTER UPDATE OF vote ON vote_post
+BEGIN
+    UPDATE post SET rating = rating - OLD.vote + NEW.vote WHERE id = NEW.post_id;
+END;
+
+CREATE TRIGGER vote_post_delete AFTER DELETE ON vote_post
+BEGIN
+    UPDATE post SET rating = rating - OLD.vote WHERE id = OLD.post_id;
+END;
+
+CREATE TRIGGER vote_comment_insert AFTER INSERT ON vote_comment
+BEGIN
+    UPDATE comment SET rating = rating + NEW.vote WHERE id = NEW.comment_id;
+END;
+
+CREATE TRIGGER vote_comment_update AFTER UPDATE OF vote ON vote_comment
+BEGIN
+    UPDATE comment SET rating = rating - OLD.vote + NEW.vote WHERE id = NEW.comment_id;
+END;
+
+CREATE TRIGGER vote_comment_delete AFTER DELETE ON vote_comment
+BEGIN
+    UPDATE comment SET rating = rating - OLD.vote WHERE id = OLD.comment_id;
+END;
@@ -0,0 +1,5 @@
+ALTER TABLE category DROP COLUMN archived;
+
+ALTER TABLE category DROP COLUMN position;
+
+ALTER TABLE user DROP COLUMN role;
@@ -0,0 +1,10 @@
+-- admins manage categories, there is no API to grant the role
+ALTER TABLE user ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+ALTER TABLE category ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+-- archived categories are hidden from new posts but still resolve for old ones
+ALTER TABLE category ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- "All" keeps position 0 and stays first
+UPDATE category SET position = id - 1;
@@ -0,0 +1,7 @@
+DROP TRIGGER message_unread_insert;
+
+DROP TRIGGER message_unread_read;
+
+DROP TRIGGER message_unread_delete;
+
+DROP TABLE message_unread;
@@ -0,0 +1,40 @@
+-- unread counts per conversation, kept in sync by triggers so the chat list doesn't count messages
+CREATE TABLE message_unread (
+    recipient_id INTEGER NOT NULL,
+    sender_id INTEGER NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (recipient_id, sender_id),
+    FOREIGN KEY (recipient_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (sender_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+INSERT INTO
+    message_unread (recipient_id, sender_id, count)
+SELECT
+    recipient_id, sender_id, COUNT(*)
+FROM
+    message
+WHERE
+    readed = FALSE
+GROUP BY
+    recipient_id, sender_id;
+
+CREATE TRIGGER message_unread_insert AFTER INSERT ON message WHEN NOT NEW.readed
+BEGIN
+    INSERT INTO
+        message_unread (recipient_id, sender_id, count)
+    VALUES
+        (NEW.recipient_id, NEW.sender_id, 1)
+    ON CONFLICT (recipient_id, sender_id) DO UPDATE SET
+        count = count + 1;
+END;
+
+CREATE TRIGGER message_unread_read AFTER UPDATE OF readed ON message WHEN NOT OLD.readed AND NEW.readed
+BEGIN
+    UPDATE message_unread SET count = count - 1 WHERE recipient_id = NEW.recipient_id AND sender_id = NEW.sender_id;
+END;
+
+CREATE TRIGGER message_unread_delete AFTER DELETE ON message WHEN NOT OLD.readed
+BEGIN
+    UPDATE message_unread SET count = count - 1 WHERE recipient_id = OLD.recipient_id AND sender_id = OLD.sender_id;
+END;
@@ -0,0 +1,5 @@
+DROP TABLE conversation_message;
+
+DROP TABLE conversation_member;
+
+DROP TABLE conversation;
@@ -0,0 +1,32 @@
+-- group conversations, direct messages stay in the message table
+CREATE TABLE conversation (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    owner_id INTEGER,
+    creation_time DATETIME NOT NULL,
+    FOREIGN KEY (owner_id) REFERENCES user(id) ON DELETE SET NULL
+);
+
+CREATE TABLE conversation_member (
+    conversation_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    joined_at DATETIME NOT NULL,
+    last_read_message_id INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (conversation_id, user_id),
+    FOREIGN KEY (conversation_id) REFERENCES conversation(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX conversation_member_user_idx ON conversation_member (user_id);
+
+CREATE TABLE conversation_message (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    conversation_id INTEGER NOT NULL,
+    sender_id INTEGER NOT NULL,
+    message TEXT NOT NULL,
+    creation_time DATETIME NOT NULL,
+    FOREIGN KEY (conversation_id) REFERENCES conversation(id) ON DELETE CASCADE,
+    FOREIGN KEY (sender_id) REFERENCES user(id) ON DELETE CASCADE
+);
+
+CREATE INDEX conversation_message_conversation_idx ON conversation_message (conversation_id, id);
@@ -0,0 +1,5 @@
+DROP TABLE message_edit;
+
+ALTER TABLE message DROP COLUMN deleted_at;
+
+ALTER TABLE message DROP COLUMN edited_at;
@@ -0,0 +1,15 @@
+-- a deleted message stays as a tombstone so both sides keep the place in the history
+ALTER TABLE message ADD COLUMN edited_at DATETIME;
+
+ALTER TABLE message ADD COLUMN deleted_at DATETIME;
+
+-- previous versions of edited messages
+CREATE TABLE message_edit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id INTEGER NOT NULL,
+    message TEXT NOT NULL,
+    edited_at DATETIME NOT NULL,
+    FOREIGN KEY (message_id) REFERENCES message(id) ON DELETE CASCADE
+);
+
+CREATE INDEX message_edit_message_idx ON message_edit (message_id);
@@ -0,0 +1,5 @@
+DROP TABLE reaction_message;
+
+DROP TABLE reaction_comment;
+
+DROP TABLE reaction_post;
@@ -0,0 +1,30 @@
+-- a user can react to a target with several emoji, each one once
+CREATE TABLE reaction_post (
+    user_id INTEGER NOT NULL,
+    post_id INTEGER NOT NULL,
+    emoji TEXT NOT NULL,
+    creation_time DATETIME NOT NULL,
+    PRIMARY KEY (post_id, user_id, emoji),
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES post(id) ON DELETE CASCADE
+);
+
+CREATE TABLE reaction_comment (
+    user_id INTEGER NOT NULL,
+    comment_id INTEGER NOT NULL,
+    emoji TEXT NOT NULL,
+    creation_time DATETIME NOT NULL,
+    PRIMARY KEY (comment_id, user_id, emoji),
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (comment_id) REFERENCES comment(id) ON DELETE CASCADE
+);
+
+CREATE TABLE reaction_message (
+    user_id INTEGER NOT NULL,
+    message_id INTEGER NOT NULL,
+    emoji TEXT NOT NULL,
+    creation_time DATETIME NOT NULL,
+    PRIMARY KEY (message_id, user_id, emoji),
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES message(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- avatars keep the plain file names, the images directory is a setting of the server
+DROP INDEX post_image_image_idx;
+
+DROP INDEX post_image_post_idx;
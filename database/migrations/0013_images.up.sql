@@ -0,0 +1,17 @@
+-- images are served by file name, avatars were stored with the images directory in front
+UPDATE
+    user
+SET
+    avatar = SUBSTR(avatar, LENGTH(RTRIM(avatar, REPLACE(avatar, '/', ''))) + 1)
+WHERE
+    avatar LIKE '%/%';
+
+-- the same image is attached to a post once
+DELETE FROM
+    post_image
+WHERE
+    id NOT IN (SELECT MIN(id) FROM post_image GROUP BY post_id, image);
+
+CREATE UNIQUE INDEX post_image_post_idx ON post_image (post_id, image);
+
+CREATE INDEX post_image_image_idx ON post_image (image);
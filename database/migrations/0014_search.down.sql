@@ -0,0 +1,19 @@
+DROP INDEX user_name_nocase_idx;
+
+DROP INDEX user_username_nocase_idx;
+
+DROP TRIGGER comment_fts_delete;
+
+DROP TRIGGER comment_fts_update;
+
+DROP TRIGGER comment_fts_insert;
+
+DROP TRIGGER post_fts_delete;
+
+DROP TRIGGER post_fts_update;
+
+DROP TRIGGER post_fts_insert;
+
+DROP TABLE comment_fts;
+
+DROP TABLE post_fts;
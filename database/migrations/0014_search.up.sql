@@ -0,0 +1,56 @@
+-- full-text indexes read the text from post and comment, triggers keep them in sync
+CREATE VIRTUAL TABLE post_fts USING fts5 (
+    title,
+    content,
+    content = 'post',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE VIRTUAL TABLE comment_fts USING fts5 (
+    content,
+    content = 'comment',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO post_fts (post_fts) VALUES ('rebuild');
+
+INSERT INTO comment_fts (comment_fts) VALUES ('rebuild');
+
+CREATE TRIGGER post_fts_insert AFTER INSERT ON post
+BEGIN
+    INSERT INTO post_fts (rowid, title, content) VALUES (NEW.id, NEW.title, NEW.content);
+END;
+
+CREATE TRIGGER post_fts_update AFTER UPDATE OF title, content ON post
+BEGIN
+    INSERT INTO post_fts (post_fts, rowid, title, content) VALUES ('delete', OLD.id, OLD.title, OLD.content);
+    INSERT INTO post_fts (rowid, title, content) VALUES (NEW.id, NEW.title, NEW.content);
+END;
+
+CREATE TRIGGER post_fts_delete AFTER DELETE ON post
+BEGIN
+    INSERT INTO post_fts (post_fts, rowid, title, content) VALUES ('delete', OLD.id, OLD.title, OLD.content);
+END;
+
+CREATE TRIGGER comment_fts_insert AFTER INSERT ON comment
+BEGIN
+    INSERT INTO comment_fts (rowid, content) VALUES (NEW.id, NEW.content);
+END;
+
+CREATE TRIGGER comment_fts_update AFTER UPDATE OF content ON comment
+BEGIN
+    INSERT INTO comment_fts (comment_fts, rowid, content) VALUES ('delete', OLD.id, OLD.content);
+    INSERT INTO comment_fts (rowid, content) VALUES (NEW.id, NEW.content);
+END;
+
+CREATE TRIGGER comment_fts_delete AFTER DELETE ON comment
+BEGIN
+    INSERT INTO comment_fts (comment_fts, rowid, content) VALUES ('delete', OLD.id, OLD.content);
+END;
+
+-- prefix search of users for the conversation picker
+CREATE INDEX user_username_nocase_idx ON user (username COLLATE NOCASE);
+
+CREATE INDEX user_name_nocase_idx ON user (first_name COLLATE NOCASE, last_name COLLATE NOCASE);
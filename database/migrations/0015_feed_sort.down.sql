@@ -0,0 +1,13 @@
+DROP INDEX post_comments_count_idx;
+
+DROP INDEX post_rating_idx;
+
+DROP INDEX post_creation_time_idx;
+
+DROP INDEX post_category_category_idx;
+
+DROP TRIGGER comment_count_delete;
+
+DROP TRIGGER comment_count_insert;
+
+ALTER TABLE post DROP COLUMN comments_count;
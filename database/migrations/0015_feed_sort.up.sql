@@ -0,0 +1,23 @@
+-- post.comments_count is the number of comments, kept in sync by triggers like post.rating
+ALTER TABLE post ADD COLUMN comments_count INTEGER NOT NULL DEFAULT 0;
+
+UPDATE post SET comments_count = (SELECT COUNT(*) FROM comment WHERE post_id = post.id);
+
+CREATE TRIGGER comment_count_insert AFTER INSERT ON comment
+BEGIN
+    UPDATE post SET comments_count = comments_count + 1 WHERE id = NEW.post_id;
+END;
+
+CREATE TRIGGER comment_count_delete AFTER DELETE ON comment
+BEGIN
+    UPDATE post SET comments_count = comments_count - 1 WHERE id = OLD.post_id;
+END;
+
+-- feeds of a category, and the orders of the feed: time windows, top and most discussed
+CREATE INDEX post_category_category_idx ON post_category (category_id, post_id);
+
+CREATE INDEX post_creation_time_idx ON post (creation_time);
+
+CREATE INDEX post_rating_idx ON post (rating, id);
+
+CREATE INDEX post_comments_count_idx ON post (comments_count, id);
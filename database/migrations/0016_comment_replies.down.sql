@@ -0,0 +1,17 @@
+DROP INDEX comment_post_parent_idx;
+
+DROP INDEX comment_path_idx;
+
+DROP INDEX comment_parent_idx;
+
+DROP TRIGGER comment_subtree_delete;
+
+DROP TRIGGER comment_path_insert;
+
+ALTER TABLE comment DROP COLUMN replies_count;
+
+ALTER TABLE comment DROP COLUMN depth;
+
+ALTER TABLE comment DROP COLUMN path;
+
+ALTER TABLE comment DROP COLUMN parent_id;
@@ -0,0 +1,34 @@
+-- replies: parent_id is the comment replied to, NULL for a top-level comment. path is the ids from
+-- the top-level comment down to the comment, zero-padded and ended with '/', so the comments of a
+-- thread sorted by path are in reading order and a subtree is a range of paths.
+ALTER TABLE comment ADD COLUMN parent_id INTEGER DEFAULT NULL;
+ALTER TABLE comment ADD COLUMN path TEXT NOT NULL DEFAULT '';
+ALTER TABLE comment ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comment ADD COLUMN replies_count INTEGER NOT NULL DEFAULT 0;
+
+UPDATE comment SET path = printf('%010d/', id);
+
+CREATE TRIGGER comment_path_insert AFTER INSERT ON comment
+BEGIN
+    UPDATE comment
+    SET
+        path = IFNULL((SELECT path FROM comment WHERE id = NEW.parent_id), '') || printf('%010d/', NEW.id),
+        depth = IFNULL((SELECT depth + 1 FROM comment WHERE id = NEW.parent_id), 0)
+    WHERE id = NEW.id;
+
+    UPDATE comment SET replies_count = replies_count + 1 WHERE id = NEW.parent_id;
+END;
+
+-- the replies go with the comment, the triggers on comment still fire for each of them
+CREATE TRIGGER comment_subtree_delete AFTER DELETE ON comment
+BEGIN
+    DELETE FROM comment WHERE post_id = OLD.post_id AND path > OLD.path AND path < OLD.path || '~';
+
+    UPDATE comment SET replies_count = replies_count - 1 WHERE id = OLD.parent_id;
+END;
+
+CREATE INDEX comment_parent_idx ON comment (parent_id, id);
+
+CREATE INDEX comment_path_idx ON comment (post_id, path);
+
+CREATE INDEX comment_post_parent_idx ON comment (post_id, parent_id);
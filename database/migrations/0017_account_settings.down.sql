@@ -0,0 +1,3 @@
+DROP TABLE email_verification;
+
+ALTER TABLE user DROP COLUMN username_changed_at;
@@ -0,0 +1,12 @@
+-- username_changed_at limits how often a username can be changed, NULL until the first change
+ALTER TABLE user ADD COLUMN username_changed_at DATETIME DEFAULT NULL;
+
+-- a new email replaces the old one only once the link sent to it is opened,
+-- a user has one pending change at most
+CREATE TABLE IF NOT EXISTS email_verification (
+    user_id INTEGER PRIMARY KEY,
+    email VARCHAR(50) NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    expiration_time DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES user(id) ON DELETE CASCADE
+);
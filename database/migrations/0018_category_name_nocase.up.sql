@@ -0,0 +1,18 @@
+-- category names are unique regardless of case, names that only differed in case
+-- before get the id appended so the index can be built
+UPDATE
+    category
+SET
+    name = name || ' (' || id || ')'
+WHERE
+    EXISTS (
+        SELECT
+            1
+        FROM
+            category AS other
+        WHERE
+            other.name = category.name COLLATE NOCASE
+            AND other.id < category.id
+    );
+
+CREATE UNIQUE INDEX category_name_nocase_idx ON category (name COLLATE NOCASE);
@@ -0,0 +1,2 @@
+-- the stripped characters can't be restored and aren't needed back
+SELECT 1;
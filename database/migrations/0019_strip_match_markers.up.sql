@@ -0,0 +1,15 @@
+-- the search marks matches with char(2) and char(3), they are stripped from posts and comments
+-- when written since then, older ones are cleaned up here; the triggers update the search index
+UPDATE post
+SET
+    title = replace(replace(title, char(2), ''), char(3), ''),
+    content = replace(replace(content, char(2), ''), char(3), '')
+WHERE
+    instr(title, char(2)) > 0 OR instr(title, char(3)) > 0
+    OR instr(content, char(2)) > 0 OR instr(content, char(3)) > 0;
+
+UPDATE comment
+SET
+    content = replace(replace(content, char(2), ''), char(3), '')
+WHERE
+    instr(content, char(2)) > 0 OR instr(content, char(3)) > 0;
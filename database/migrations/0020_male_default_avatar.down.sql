@@ -0,0 +1,2 @@
+-- the default avatar of men is kept, it was wrong before
+SELECT 1;
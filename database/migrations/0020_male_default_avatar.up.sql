@@ -0,0 +1,6 @@
+-- men were given the default avatar of women on sign up, the ones who kept it get their own
+UPDATE user
+SET
+    avatar = 'male_default.jpg'
+WHERE
+    gender = 'Male' AND avatar = 'female_default.jpg';
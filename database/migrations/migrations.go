@@ -0,0 +1,9 @@
+// Package migrations embeds the versioned schema migrations of the forum database.
+package migrations
+
+import "embed"
+
+// FS holds the migrations named NNNN_name.up.sql and NNNN_name.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -1,17 +0,0 @@
-DROP TABLE post_categories;
-
-DROP TABLE session_tokens;
-
-DROP TABLE post_images;
-
-DROP TABLE vote_comment;
-
-DROP TABLE vote_post;
-
-DROP TABLE categories;
-
-DROP TABLE comment;
-
-DROP TABLE post;
-
-DROP TABLE user;
\ No newline at end of file
@@ -3,9 +3,12 @@ module real-time-forum
 go 1.19
 
 require (
-	github.com/gofrs/uuid v4.3.1+incompatible // indirect
-	github.com/gorilla/websocket v1.5.0 // indirect
-	github.com/mattn/go-sqlite3 v1.14.16 // indirect
-	github.com/rshezarr/gorr v0.0.0-20230111104522-669c9045c9ec // indirect
-	golang.org/x/crypto v0.5.0 // indirect
+	github.com/gofrs/uuid v4.3.1+incompatible
+	github.com/gorilla/websocket v1.5.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/rshezarr/gorr v0.0.0-20230111104522-669c9045c9ec
+	golang.org/x/crypto v0.5.0
+	golang.org/x/image v0.18.0
 )
+
+require golang.org/x/sys v0.4.0 // indirect
@@ -1,17 +1,24 @@
 package app
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"real-time-forum/database/migrations"
 	"real-time-forum/internal/config"
 	handler "real-time-forum/internal/handler/http"
+	"real-time-forum/internal/handler/ws"
 	"real-time-forum/internal/repository"
 	"real-time-forum/internal/server"
 	"real-time-forum/internal/service"
+	"real-time-forum/pkg/auth"
 	"real-time-forum/pkg/hasher"
+	"real-time-forum/pkg/images"
 	"real-time-forum/pkg/logger"
+	"real-time-forum/pkg/mailer"
+	"real-time-forum/pkg/migrate"
 	"real-time-forum/pkg/sqlite"
 )
 
@@ -40,14 +47,57 @@ func (a *App) Start(configPath *string) {
 
 	a.log.Info("Database connected")
 
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		a.log.Error("error while reading migrations: %s", err.Error())
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		a.log.Error("error while applying migrations: %s", err.Error())
+	}
+
+	for _, m := range applied {
+		a.log.Info("Migration %04d_%s applied", m.Version, m.Name)
+	}
+
+	// the salt is kept only to verify passwords hashed before the switch to argon2id
 	h, err := hasher.NewHasher("aboba")
 	if err != nil {
-		a.log.Error("error while connecting database: %s", err.Error())
+		a.log.Error("error while creating hasher: %s", err.Error())
+	}
+
+	tokenManager, err := auth.NewManager(cfg.Auth.SigningKey)
+	if err != nil {
+		a.log.Error("error while creating token manager: %s, set it in %s", err.Error(), config.SigningKeyEnv)
+	}
+
+	imageStore, err := images.NewStore(cfg.Sqlite.ImagesPath, cfg.Images.MaxSize, cfg.Images.ThumbnailSize)
+	if err != nil {
+		a.log.Error("error while creating image store: %s", err.Error())
+	}
+
+	var mail mailer.Mailer = mailer.NewLog(a.log)
+	if cfg.Mail.Host != "" {
+		mail = mailer.NewSMTP(cfg.Mail.Host, cfg.Mail.Port, cfg.Mail.Username, cfg.Mail.Password, cfg.Mail.From)
 	}
 
 	repository := repository.NewRepository(db)
-	service := service.NewService(repository, h, cfg)
-	handler := handler.NewHandler(service)
+	// the hub publishes feed events of the services to websocket subscribers
+	// and closes the connections of sessions the user was signed out of
+	hub := ws.NewHub()
+	service := service.NewService(repository, h, tokenManager, hub, hub, imageStore, mail, cfg)
+
+	removed, err := service.Image.RemoveOrphans(context.Background())
+	if err != nil {
+		a.log.Error("error while removing unused images: %s", err.Error())
+	}
+
+	if removed > 0 {
+		a.log.Info("Removed %d unused images", removed)
+	}
+	wsHandler := ws.NewHandler(service, hub, cfg.WS)
+	handler := handler.NewHandler(service, wsHandler)
 
 	server := server.NewServer(cfg, handler.InitRoutes())
 
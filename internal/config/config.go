@@ -8,9 +8,15 @@ import (
 
 type (
 	Config struct {
-		API    API    `json:"api"`
-		Client Client `json:"client"`
-		Sqlite Sqlite `json:"sqlite"`
+		API      API      `json:"api"`
+		Client   Client   `json:"client"`
+		Sqlite   Sqlite   `json:"sqlite"`
+		Auth     Auth     `json:"auth"`
+		WS       WS       `json:"ws"`
+		Images   Images   `json:"images"`
+		Comments Comments `json:"comments"`
+		Account  Account  `json:"account"`
+		Mail     Mail     `json:"mail"`
 	}
 
 	API struct {
@@ -29,11 +35,52 @@ type (
 	Sqlite struct {
 		Driver           string `json:"driver"`
 		DatabaseFileName string `json:"databaseFileName"`
-		SchemePath       string `json:"schemePath"`
 		ImagesPath       string `json:"imagesPath"`
 	}
+
+	// Auth.SigningKey is read from the SigningKeyEnv environment variable, it's never kept in the config file
+	Auth struct {
+		SigningKey      string `json:"-"`
+		AccessTokenTTL  int    `json:"accessTokenTTL"`  // minutes
+		RefreshTokenTTL int    `json:"refreshTokenTTL"` // hours
+	}
+
+	WS struct {
+		PingPeriod     int   `json:"pingPeriod"` // seconds
+		MaxMissedPongs int   `json:"maxMissedPongs"`
+		WriteTimeout   int   `json:"writeTimeout"`   // seconds
+		MaxMessageSize int64 `json:"maxMessageSize"` // bytes
+	}
+
+	// Images are uploaded to Sqlite.ImagesPath
+	Images struct {
+		MaxSize       int64 `json:"maxSize"`       // bytes
+		ThumbnailSize int   `json:"thumbnailSize"` // pixels, the longer side
+	}
+
+	Comments struct {
+		MaxDepth int `json:"maxDepth"` // levels of replies under a top-level comment
+	}
+
+	Account struct {
+		UsernameCooldown     int    `json:"usernameCooldown"`     // days between username changes
+		EmailVerificationTTL int    `json:"emailVerificationTTL"` // hours
+		VerifyEmailURL       string `json:"verifyEmailURL"`       // the token is appended to it
+	}
+
+	// Mail is sent through the SMTP server, without a host mails are only logged
+	Mail struct {
+		Host     string `json:"host"`
+		Port     string `json:"port"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		From     string `json:"from"`
+	}
 )
 
+// SigningKeyEnv is the environment variable holding the key access tokens are signed with.
+const SigningKeyEnv = "AUTH_SIGNING_KEY"
+
 func NewConfig(configPath string) (*Config, error) {
 	var config Config
 
@@ -46,6 +93,8 @@ func NewConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("decode config file: %w", err)
 	}
 
+	config.Auth.SigningKey = os.Getenv(SigningKeyEnv)
+
 	return &config, nil
 }
 
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewConfigReadsSigningKeyFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"auth": {"signingKey": "from the file", "accessTokenTTL": 15}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(SigningKeyEnv, "from the environment")
+
+	cfg, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Auth.SigningKey != "from the environment" {
+		t.Errorf("signing key = %q, want the one from the environment", cfg.Auth.SigningKey)
+	}
+	if cfg.Auth.AccessTokenTTL != 15 {
+		t.Errorf("access token TTL = %d, want 15", cfg.Auth.AccessTokenTTL)
+	}
+}
+
+func TestNewConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	malformed := filepath.Join(dir, "malformed.json")
+	if err := os.WriteFile(malformed, []byte(`{"api": `), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "missing.json"), malformed} {
+		if _, err := NewConfig(path); err == nil {
+			t.Errorf("NewConfig(%q) error = nil, want an error", filepath.Base(path))
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
+
+	"github.com/rshezarr/gorr"
+)
+
+type profileInput struct {
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Age       int    `json:"age"`
+	Gender    string `json:"gender"`
+}
+
+type usernameInput struct {
+	Username string `json:"username"`
+}
+
+type emailInput struct {
+	Email           string `json:"email"`
+	CurrentPassword string `json:"currentPassword"`
+}
+
+type verifyEmailInput struct {
+	Token string `json:"token"`
+}
+
+type emailResponse struct {
+	Email string `json:"email"`
+}
+
+type passwordInput struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+const (
+	maxNameLength     = 50 // user columns are VARCHAR(50)
+	minUsernameLength = 3
+	minPasswordLength = 8
+	maxPasswordLength = 128
+	minAge            = 13
+	maxAge            = 120
+)
+
+func (i profileInput) validate(v *validator.Validator) {
+	checkProfile(v, i.FirstName, i.LastName, i.Age, i.Gender)
+}
+
+func (i usernameInput) validate(v *validator.Validator) {
+	checkUsername(v, i.Username)
+}
+
+func (i emailInput) validate(v *validator.Validator) {
+	checkEmail(v, i.Email)
+	v.Check(i.CurrentPassword != "", "currentPassword", "current password is required")
+}
+
+func (i verifyEmailInput) validate(v *validator.Validator) {
+	v.Check(validator.NotBlank(i.Token), "token", "token is required")
+}
+
+func (i passwordInput) validate(v *validator.Validator) {
+	v.Check(i.CurrentPassword != "", "currentPassword", "current password is required")
+	checkPassword(v, "newPassword", i.NewPassword)
+}
+
+// checkProfile checks the fields of the profile, they are set at sign up and in the settings.
+func checkProfile(v *validator.Validator, firstName string, lastName string, age int, gender string) {
+	v.Check(validator.Length(strings.TrimSpace(firstName), 1, maxNameLength),
+		"firstName", "first name must be from 1 to 50 characters")
+	v.Check(validator.Length(strings.TrimSpace(lastName), 1, maxNameLength),
+		"lastName", "last name must be from 1 to 50 characters")
+	v.Check(validator.Between(age, minAge, maxAge), "age", "age must be from 13 to 120")
+	v.Check(validator.OneOf(gender, "Male", "Female"), "gender", service.ErrUnknownGender.Error())
+}
+
+// checkUsername keeps usernames apart from emails, both are accepted at sign in.
+func checkUsername(v *validator.Validator, username string) {
+	username = strings.TrimSpace(username)
+
+	v.Check(validator.Length(username, minUsernameLength, maxNameLength),
+		"username", "username must be from 3 to 50 characters")
+	v.Check(validator.Chars(username, usernameChar),
+		"username", "username may only have letters, digits, '.', '-' and '_'")
+}
+
+func checkEmail(v *validator.Validator, email string) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	v.Check(email != "", "email", "email is required")
+	v.Check(len(email) <= maxNameLength, "email", "email must be at most 50 characters")
+	v.Check(validator.Email(email), "email", "email must be an address like name@example.com")
+}
+
+func checkPassword(v *validator.Validator, field string, password string) {
+	v.Check(validator.Length(password, minPasswordLength, maxPasswordLength),
+		field, "password must be from 8 to 128 characters")
+}
+
+func usernameChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_'
+}
+
+// GetAccount returns the signed in user with the settings only they see.
+func (h *Handler) GetAccount(c *gorr.Context) {
+	user, err := h.service.User.GetAccount(c.Context(), getUserID(c))
+	if err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, user)
+}
+
+func (h *Handler) UpdateProfile(c *gorr.Context) {
+	var input profileInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	user, err := h.service.User.UpdateProfile(c.Context(), getUserID(c), service.ProfileInput{
+		FirstName: input.FirstName,
+		LastName:  input.LastName,
+		Age:       input.Age,
+		Gender:    input.Gender,
+	})
+	if err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, user)
+}
+
+func (h *Handler) ChangeUsername(c *gorr.Context) {
+	var input usernameInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	user, err := h.service.User.ChangeUsername(c.Context(), getUserID(c), input.Username)
+	if err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, user)
+}
+
+// ChangeEmail mails a verification link to the new email, it replaces the current one once opened.
+func (h *Handler) ChangeEmail(c *gorr.Context) {
+	var input emailInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	user, err := h.service.User.ChangeEmail(c.Context(), getUserID(c), service.EmailInput{
+		Email:           input.Email,
+		CurrentPassword: input.CurrentPassword,
+	})
+	if err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusAccepted, user)
+}
+
+// VerifyEmail takes the token of the mailed link, it doesn't need the user to be signed in.
+func (h *Handler) VerifyEmail(c *gorr.Context) {
+	var input verifyEmailInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	email, err := h.service.User.VerifyEmail(c.Context(), input.Token)
+	if err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, emailResponse{Email: email})
+}
+
+// ChangePassword signs the user out of every session and returns the tokens of a new one.
+func (h *Handler) ChangePassword(c *gorr.Context) {
+	var input passwordInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	tokens, err := h.service.User.ChangePassword(c.Context(), getUserID(c), getSessionID(c), service.PasswordInput{
+		CurrentPassword: input.CurrentPassword,
+		NewPassword:     input.NewPassword,
+	})
+	if err != nil {
+		writeAccountError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	})
+}
+
+func writeAccountError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserDoesNotExists):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrUsernameTaken),
+		errors.Is(err, service.ErrUsernameCooldown):
+		writeFieldError(c, http.StatusConflict, "username", err)
+	case errors.Is(err, service.ErrEmailTaken):
+		writeFieldError(c, http.StatusConflict, "email", err)
+	case errors.Is(err, service.ErrSameEmail):
+		writeFieldError(c, http.StatusUnprocessableEntity, "email", err)
+	case errors.Is(err, service.ErrInvalidEmailLink):
+		writeFieldError(c, http.StatusUnprocessableEntity, "token", err)
+	case errors.Is(err, service.ErrWrongPassword):
+		writeFieldError(c, http.StatusUnprocessableEntity, "currentPassword", err)
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
+
+	"github.com/rshezarr/gorr"
+)
+
+type categoryInput struct {
+	Name string `json:"name"`
+}
+
+type categoriesOrderInput struct {
+	Categories []int `json:"categories"`
+}
+
+func (i categoryInput) validate(v *validator.Validator) {
+	v.Check(validator.NotBlank(i.Name), "name", "name is required")
+}
+
+func (i categoriesOrderInput) validate(v *validator.Validator) {
+	v.Check(len(i.Categories) > 0, "categories", "categories are required")
+	v.Check(validator.Positive(i.Categories...), "categories", "categories must be ids of categories")
+}
+
+// GetCategories lists active categories, archived ones are included with ?archived=true.
+func (h *Handler) GetCategories(c *gorr.Context) {
+	withArchived := c.URL.Query().Get("archived") == "true"
+
+	categories, err := h.service.Category.GetAll(c.Context(), withArchived)
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, categories)
+}
+
+func (h *Handler) CreateCategory(c *gorr.Context) {
+	var input categoryInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	category, err := h.service.Category.Create(c.Context(), getUserID(c), input.Name)
+	if err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusCreated, category)
+}
+
+func (h *Handler) RenameCategory(c *gorr.Context) {
+	categoryID, err := c.GetIntParam("category_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input categoryInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	if err := h.service.Category.Rename(c.Context(), getUserID(c), categoryID, input.Name); err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+
+	c.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ReorderCategories(c *gorr.Context) {
+	var input categoriesOrderInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	if err := h.service.Category.Reorder(c.Context(), getUserID(c), input.Categories); err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+
+	c.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ArchiveCategory(c *gorr.Context) {
+	h.setCategoryArchived(c, true)
+}
+
+func (h *Handler) RestoreCategory(c *gorr.Context) {
+	h.setCategoryArchived(c, false)
+}
+
+func (h *Handler) setCategoryArchived(c *gorr.Context, archived bool) {
+	categoryID, err := c.GetIntParam("category_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Category.SetArchived(c.Context(), getUserID(c), categoryID, archived); err != nil {
+		writeCategoryError(c, err)
+		return
+	}
+
+	c.WriteHeader(http.StatusNoContent)
+}
+
+func writeCategoryError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotAdmin),
+		errors.Is(err, service.ErrAllCategory):
+		c.WriteError(http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrUnknownCategory):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrCategoryExists):
+		writeFieldError(c, http.StatusConflict, "name", err)
+	case errors.Is(err, service.ErrInvalidCategoryName):
+		writeFieldError(c, http.StatusUnprocessableEntity, "name", err)
+	case errors.Is(err, service.ErrInvalidOrder):
+		writeFieldError(c, http.StatusUnprocessableEntity, "categories", err)
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
+
+	"github.com/rshezarr/gorr"
+)
+
+type commentInput struct {
+	Content  string `json:"data"`
+	ParentID int    `json:"parentID"`
+}
+
+const maxCommentLength = 2000
+
+func (i commentInput) validate(v *validator.Validator) {
+	v.Check(validator.NotBlank(i.Content), "data", service.ErrEmptyComment.Error())
+	v.Check(validator.Length(i.Content, 0, maxCommentLength), "data", "comment must be at most 2000 characters")
+	v.Check(i.ParentID >= 0, "parentID", "parentID must be the id of a comment")
+}
+
+type commentsResponse struct {
+	Comments []model.Comment `json:"comments"`
+	service.Cursors
+}
+
+func (h *Handler) CreateComment(c *gorr.Context) {
+	postID, err := c.GetIntParam("post_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input commentInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	comment, err := h.service.Comment.Create(c.Context(), service.CommentInput{
+		UserID:   getUserID(c),
+		PostID:   postID,
+		ParentID: input.ParentID,
+		Content:  input.Content,
+	})
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusCreated, comment)
+}
+
+// GetComments returns the comments of the post newest first, the next pages are read with ?cursor=.
+func (h *Handler) GetComments(c *gorr.Context) {
+	postID, err := c.GetIntParam("post_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comments, cursors, err := h.service.Comment.GetByPostID(c.Context(), postID, getUserID(c), c.URL.Query().Get("cursor"))
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, commentsResponse{Comments: comments, Cursors: cursors})
+}
+
+// GetReplies returns the replies to the comment oldest first, the next pages are read with ?cursor=.
+func (h *Handler) GetReplies(c *gorr.Context) {
+	commentID, err := c.GetIntParam("comment_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	replies, cursors, err := h.service.Comment.GetReplies(c.Context(), commentID, getUserID(c), c.URL.Query().Get("cursor"))
+	if err != nil {
+		writeCommentError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, commentsResponse{Comments: replies, Cursors: cursors})
+}
+
+func writeCommentError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound),
+		errors.Is(err, service.ErrCommentNotFound):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrEmptyComment):
+		writeFieldError(c, http.StatusUnprocessableEntity, "data", err)
+	case errors.Is(err, service.ErrReplyTooDeep):
+		writeFieldError(c, http.StatusUnprocessableEntity, "parentID", err)
+	case errors.Is(err, service.ErrInvalidCursor):
+		c.WriteError(http.StatusBadRequest, err.Error())
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
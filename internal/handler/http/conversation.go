@@ -0,0 +1,166 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
+
+	"github.com/rshezarr/gorr"
+)
+
+type conversationInput struct {
+	Title   string `json:"title"`
+	Members []int  `json:"members"`
+}
+
+type conversationTitleInput struct {
+	Title string `json:"title"`
+}
+
+type memberInput struct {
+	UserID int `json:"userID"`
+}
+
+func (i conversationInput) validate(v *validator.Validator) {
+	v.Check(validator.NotBlank(i.Title), "title", "title is required")
+	v.Check(len(i.Members) > 0, "members", service.ErrNoMembers.Error())
+	v.Check(validator.Positive(i.Members...), "members", "members must be ids of users")
+}
+
+func (i conversationTitleInput) validate(v *validator.Validator) {
+	v.Check(validator.NotBlank(i.Title), "title", "title is required")
+}
+
+func (i memberInput) validate(v *validator.Validator) {
+	v.Check(validator.Positive(i.UserID), "userID", "userID must be the id of a user")
+}
+
+func (h *Handler) CreateConversation(c *gorr.Context) {
+	var input conversationInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	conversation, err := h.service.Conversation.Create(c.Context(), getUserID(c), input.Title, input.Members)
+	if err != nil {
+		writeConversationError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusCreated, conversation)
+}
+
+func (h *Handler) GetConversations(c *gorr.Context) {
+	conversations, err := h.service.Conversation.GetAll(c.Context(), getUserID(c))
+	if err != nil {
+		writeConversationError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, conversations)
+}
+
+func (h *Handler) GetConversation(c *gorr.Context) {
+	conversationID, err := c.GetIntParam("conversation_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conversation, err := h.service.Conversation.GetByID(c.Context(), getUserID(c), conversationID)
+	if err != nil {
+		writeConversationError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, conversation)
+}
+
+func (h *Handler) RenameConversation(c *gorr.Context) {
+	conversationID, err := c.GetIntParam("conversation_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input conversationTitleInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	conversation, err := h.service.Conversation.Rename(c.Context(), getUserID(c), conversationID, input.Title)
+	if err != nil {
+		writeConversationError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, conversation)
+}
+
+func (h *Handler) AddConversationMember(c *gorr.Context) {
+	conversationID, err := c.GetIntParam("conversation_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input memberInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	conversation, err := h.service.Conversation.AddMember(c.Context(), getUserID(c), conversationID, input.UserID)
+	if err != nil {
+		writeConversationError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, conversation)
+}
+
+// RemoveConversationMember removes the member, a member removing themselves leaves the conversation.
+func (h *Handler) RemoveConversationMember(c *gorr.Context) {
+	conversationID, err := c.GetIntParam("conversation_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	memberID, err := c.GetIntParam("user_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Conversation.RemoveMember(c.Context(), getUserID(c), conversationID, memberID); err != nil {
+		writeConversationError(c, err)
+		return
+	}
+
+	c.WriteHeader(http.StatusNoContent)
+}
+
+func writeConversationError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrConversationNotFound),
+		errors.Is(err, service.ErrNotConversationMember):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrNotConversationOwner):
+		c.WriteError(http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrAlreadyMember):
+		writeFieldError(c, http.StatusConflict, "userID", err)
+	case errors.Is(err, service.ErrInvalidTitle):
+		writeFieldError(c, http.StatusUnprocessableEntity, "title", err)
+	case errors.Is(err, service.ErrNoMembers):
+		writeFieldError(c, http.StatusUnprocessableEntity, "members", err)
+	case errors.Is(err, service.ErrUserDoesNotExists):
+		c.WriteError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"real-time-forum/internal/handler/ws"
 	"real-time-forum/internal/service"
 
 	"github.com/rshezarr/gorr"
@@ -8,11 +9,13 @@ import (
 
 type Handler struct {
 	service *service.Service
+	ws      *ws.Handler
 }
 
-func NewHandler(service *service.Service) *Handler {
+func NewHandler(service *service.Service, ws *ws.Handler) *Handler {
 	return &Handler{
 		service: service,
+		ws:      ws,
 	}
 }
 
@@ -21,19 +24,72 @@ func (h *Handler) InitRoutes() *gorr.Router {
 	// user handlers
 	router.POST("/api/user/sign-up", h.SignUp)
 	router.POST("/api/user/sign-in", h.SignIn)
+	router.POST("/api/user/sign-out", h.authenticated(h.SignOut))
+	router.POST("/api/auth/refresh", h.Refresh)
+	router.GET("/api/user/online", h.authenticated(h.GetOnlineUsers))
+	router.GET("/api/user/me", h.authenticated(h.GetAccount))
+	router.PUT("/api/user/profile", h.authenticated(h.UpdateProfile))
+	router.PUT("/api/user/username", h.authenticated(h.ChangeUsername))
+	router.PUT("/api/user/email", h.authenticated(h.ChangeEmail))
+	router.POST("/api/user/email/verify", h.VerifyEmail)
+	router.PUT("/api/user/password", h.authenticated(h.ChangePassword))
+	router.PUT("/api/user/avatar", h.authenticated(h.UploadAvatar))
 	router.GET("/api/user/:user_id", h.GetUser)
 	router.GET("/api/user/:user_id/posts", h.GetUserPosts)
 	router.GET("/api/user/:user_id/liked-posts", h.GetUserVotedPosts)
 
 	//post handlers
+	router.POST("/api/posts", h.authenticated(h.CreatePost))
+	router.GET("/api/posts/:post_id", h.optionalAuth(h.GetPost))
+	router.PUT("/api/posts/:post_id", h.authenticated(h.UpdatePost))
+	router.DELETE("/api/posts/:post_id", h.authenticated(h.DeletePost))
+	router.POST("/api/posts/:post_id/likes", h.authenticated(h.VotePost))
+	router.DELETE("/api/posts/:post_id/likes", h.authenticated(h.RetractPostVote))
+	router.GET("/api/posts/:post_id/reactions", h.optionalAuth(h.GetPostReactions))
+	router.POST("/api/posts/:post_id/reactions", h.authenticated(h.ReactPost))
+	router.DELETE("/api/posts/:post_id/reactions", h.authenticated(h.UnreactPost))
+	router.GET("/api/posts/:post_id/reactions/users", h.GetPostReactionUsers)
+	router.POST("/api/posts/:post_id/images", h.authenticated(h.UploadPostImage))
 
 	//categories handlers
+	router.GET("/api/categories", h.GetCategories)
+	router.POST("/api/categories", h.authenticated(h.CreateCategory))
+	// registered before the :category_id routes, routes are matched in order
+	router.PUT("/api/categories/order", h.authenticated(h.ReorderCategories))
+	router.PUT("/api/categories/:category_id", h.authenticated(h.RenameCategory))
+	router.POST("/api/categories/:category_id/archive", h.authenticated(h.ArchiveCategory))
+	router.DELETE("/api/categories/:category_id/archive", h.authenticated(h.RestoreCategory))
+	router.GET("/api/categories/:category_id/posts", h.GetPostsByCategory)
 
 	//comments handlers
+	router.POST("/api/posts/:post_id/comments", h.authenticated(h.CreateComment))
+	router.GET("/api/posts/:post_id/comments", h.optionalAuth(h.GetComments))
+	router.GET("/api/comments/:comment_id/replies", h.optionalAuth(h.GetReplies))
+	router.POST("/api/comments/:comment_id/likes", h.authenticated(h.VoteComment))
+	router.DELETE("/api/comments/:comment_id/likes", h.authenticated(h.RetractCommentVote))
+	router.GET("/api/comments/:comment_id/reactions", h.optionalAuth(h.GetCommentReactions))
+	router.POST("/api/comments/:comment_id/reactions", h.authenticated(h.ReactComment))
+	router.DELETE("/api/comments/:comment_id/reactions", h.authenticated(h.UnreactComment))
+	router.GET("/api/comments/:comment_id/reactions/users", h.GetCommentReactionUsers)
+	router.PUT("/api/comments/:comment_id/image", h.authenticated(h.UploadCommentImage))
+
+	//conversations handlers
+	router.POST("/api/conversations", h.authenticated(h.CreateConversation))
+	router.GET("/api/conversations", h.authenticated(h.GetConversations))
+	router.GET("/api/conversations/:conversation_id", h.authenticated(h.GetConversation))
+	router.PUT("/api/conversations/:conversation_id", h.authenticated(h.RenameConversation))
+	router.POST("/api/conversations/:conversation_id/members", h.authenticated(h.AddConversationMember))
+	router.DELETE("/api/conversations/:conversation_id/members/:user_id", h.authenticated(h.RemoveConversationMember))
+
+	//search handlers
+	router.GET("/api/search", h.Search)
+	router.GET("/api/search/users", h.authenticated(h.SearchUsers))
 
 	//chat handlers
+	router.GET("/ws", h.ws.ServeWS)
 
 	//images fileserver
+	router.GET("/images/:image", h.ServeImage)
 
 	return router
 }
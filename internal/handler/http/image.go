@@ -0,0 +1,161 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/images"
+
+	"github.com/rshezarr/gorr"
+)
+
+var errNoImage = errors.New("multipart form with an image field expected")
+
+func (h *Handler) UploadPostImage(c *gorr.Context) {
+	postID, err := c.GetIntParam("post_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	image, err := imagePart(c)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer image.Close()
+
+	img, err := h.service.Image.UploadPostImage(c.Context(), getUserID(c), postID, image)
+	if err != nil {
+		writeImageError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusCreated, img)
+}
+
+func (h *Handler) UploadCommentImage(c *gorr.Context) {
+	commentID, err := c.GetIntParam("comment_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	image, err := imagePart(c)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer image.Close()
+
+	img, err := h.service.Image.UploadCommentImage(c.Context(), getUserID(c), commentID, image)
+	if err != nil {
+		writeImageError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, img)
+}
+
+func (h *Handler) UploadAvatar(c *gorr.Context) {
+	image, err := imagePart(c)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	defer image.Close()
+
+	img, err := h.service.Image.UploadAvatar(c.Context(), getUserID(c), image)
+	if err != nil {
+		writeImageError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, img)
+}
+
+// ServeImage serves uploaded images, their names are content hashes so they are cached for good.
+func (h *Handler) ServeImage(c *gorr.Context) {
+	name, err := c.GetStringParam("image")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	path, err := h.service.Image.FilePath(name)
+	if err != nil {
+		c.WriteError(http.StatusNotFound, err.Error())
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		c.WriteError(http.StatusNotFound, "image not found")
+		return
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		c.WriteError(http.StatusNotFound, "image not found")
+		return
+	}
+
+	header := c.ResponseWriter.Header()
+	if images.IsStored(name) {
+		header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		// default avatars may be replaced
+		header.Set("Cache-Control", "public, max-age=3600")
+	}
+	header.Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(c.ResponseWriter, c.Request, name, info.ModTime(), file)
+}
+
+// imagePart streams the image from the "image" field of a multipart form without buffering the whole form.
+func imagePart(c *gorr.Context) (io.ReadCloser, error) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, errNoImage
+	}
+
+	part, err := reader.NextPart()
+	if err != nil {
+		return nil, errNoImage
+	}
+
+	if part.FormName() != "image" {
+		part.Close()
+		return nil, errNoImage
+	}
+
+	return part, nil
+}
+
+func writeImageError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, images.ErrTooLarge):
+		c.WriteError(http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, images.ErrUnsupportedFormat):
+		c.WriteError(http.StatusUnsupportedMediaType, err.Error())
+	case errors.Is(err, images.ErrInvalidImage):
+		writeFieldError(c, http.StatusUnprocessableEntity, "image", err)
+	case errors.Is(err, service.ErrPostNotFound),
+		errors.Is(err, service.ErrCommentNotFound),
+		errors.Is(err, service.ErrUserDoesNotExists):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrNotPostAuthor),
+		errors.Is(err, service.ErrNotCommentAuthor):
+		c.WriteError(http.StatusForbidden, err.Error())
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
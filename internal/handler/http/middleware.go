@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"real-time-forum/internal/service"
+
+	"github.com/rshezarr/gorr"
+)
+
+type ctxKey string
+
+const identityCtx ctxKey = "identity"
+
+var (
+	errEmptyAuthHeader   = errors.New("empty auth header")
+	errInvalidAuthHeader = errors.New("invalid auth header")
+)
+
+// authenticated rejects requests without a valid bearer token.
+func (h *Handler) authenticated(next gorr.Handler) gorr.Handler {
+	return func(c *gorr.Context) {
+		identity, err := h.identify(c)
+		if err != nil {
+			h.writeAuthError(c, err)
+			return
+		}
+
+		setIdentity(c, identity)
+		next(c)
+	}
+}
+
+// optionalAuth lets anonymous requests through, but a token that was sent must be valid.
+func (h *Handler) optionalAuth(next gorr.Handler) gorr.Handler {
+	return func(c *gorr.Context) {
+		identity, err := h.identify(c)
+		if err != nil && !errors.Is(err, errEmptyAuthHeader) {
+			h.writeAuthError(c, err)
+			return
+		}
+
+		setIdentity(c, identity)
+		next(c)
+	}
+}
+
+func (h *Handler) identify(c *gorr.Context) (service.Identity, error) {
+	header := c.Request.Header.Get("Authorization")
+	if header == "" {
+		return service.Identity{}, errEmptyAuthHeader
+	}
+
+	headerParts := strings.Split(header, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" || headerParts[1] == "" {
+		return service.Identity{}, errInvalidAuthHeader
+	}
+
+	return h.service.User.Identify(c.Context(), headerParts[1])
+}
+
+func (h *Handler) writeAuthError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, errEmptyAuthHeader),
+		errors.Is(err, errInvalidAuthHeader),
+		errors.Is(err, service.ErrInvalidToken),
+		errors.Is(err, service.ErrTokenExpired):
+		c.WriteError(http.StatusUnauthorized, err.Error())
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
+
+func setIdentity(c *gorr.Context, identity service.Identity) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Context(), identityCtx, identity))
+}
+
+// getUserID returns the id of the authenticated user, 0 for anonymous requests.
+func getUserID(c *gorr.Context) int {
+	identity, _ := c.Context().Value(identityCtx).(service.Identity)
+	return identity.UserID
+}
+
+// getSessionID returns the session the token of the request was issued in.
+func getSessionID(c *gorr.Context) string {
+	identity, _ := c.Context().Value(identityCtx).(service.Identity)
+	return identity.SessionID
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rshezarr/gorr"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	h := newTestHandler(fakeUsers{tokens: map[string]int{"alice-token": 1}})
+
+	tests := []struct {
+		name         string
+		header       string
+		wantCode     int // of the authenticated route
+		wantUserID   int
+		wantOptional int // code of the route with optional auth
+	}{
+		{"valid token", "Bearer alice-token", http.StatusOK, 1, http.StatusOK},
+		{"no header", "", http.StatusUnauthorized, 0, http.StatusOK},
+		{"unknown token", "Bearer forged", http.StatusUnauthorized, 0, http.StatusUnauthorized},
+		{"expired token", "Bearer expired", http.StatusUnauthorized, 0, http.StatusUnauthorized},
+		{"other scheme", "Basic alice-token", http.StatusUnauthorized, 0, http.StatusUnauthorized},
+		{"lower case scheme", "bearer alice-token", http.StatusUnauthorized, 0, http.StatusUnauthorized},
+		{"no token", "Bearer ", http.StatusUnauthorized, 0, http.StatusUnauthorized},
+		{"extra part", "Bearer alice-token more", http.StatusUnauthorized, 0, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, route := range []struct {
+				name       string
+				middleware func(gorr.Handler) gorr.Handler
+				wantCode   int
+			}{
+				{"authenticated", h.authenticated, tt.wantCode},
+				{"optionalAuth", h.optionalAuth, tt.wantOptional},
+			} {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				if tt.header != "" {
+					req.Header.Set("Authorization", tt.header)
+				}
+				rec := httptest.NewRecorder()
+
+				userID := -1
+				route.middleware(func(c *gorr.Context) {
+					userID = getUserID(c)
+					c.WriteHeader(http.StatusOK)
+				})(&gorr.Context{ResponseWriter: rec, Request: req})
+
+				if rec.Code != route.wantCode {
+					t.Errorf("%s: code = %d, want %d", route.name, rec.Code, route.wantCode)
+				}
+				if route.wantCode == http.StatusOK && userID != tt.wantUserID {
+					t.Errorf("%s: user id = %d, want %d", route.name, userID, tt.wantUserID)
+				}
+				if route.wantCode != http.StatusOK && userID != -1 {
+					t.Errorf("%s: the handler was called for a rejected request", route.name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
+
+	"github.com/rshezarr/gorr"
+)
+
+type postInput struct {
+	Title      string `json:"title"`
+	Content    string `json:"data"`
+	Categories []int  `json:"categories"`
+}
+
+const (
+	maxPostTitleLength   = 150
+	maxPostContentLength = 10000
+)
+
+func (i postInput) validate(v *validator.Validator) {
+	v.Check(validator.NotBlank(i.Title), "title", "title is required")
+	v.Check(validator.Length(i.Title, 0, maxPostTitleLength), "title", "title must be at most 150 characters")
+	v.Check(validator.NotBlank(i.Content), "data", "content is required")
+	v.Check(validator.Length(i.Content, 0, maxPostContentLength), "data", "content must be at most 10000 characters")
+	v.Check(len(i.Categories) > 0, "categories", service.ErrNoCategories.Error())
+	v.Check(validator.Positive(i.Categories...), "categories", "categories must be ids of categories")
+}
+
+type postIDResponse struct {
+	PostID int `json:"postID"`
+}
+
+type postsResponse struct {
+	Posts []model.Post `json:"posts"`
+	service.Cursors
+}
+
+func (h *Handler) CreatePost(c *gorr.Context) {
+	var input postInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	postID, err := h.service.Post.Create(c.Context(), service.PostInput{
+		UserID:      getUserID(c),
+		Title:       input.Title,
+		Content:     input.Content,
+		CategoryIDs: input.Categories,
+	})
+	if err != nil {
+		writePostError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusCreated, postIDResponse{PostID: postID})
+}
+
+func (h *Handler) GetPost(c *gorr.Context) {
+	postID, err := c.GetIntParam("post_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	post, err := h.service.Post.GetByID(c.Context(), postID, getUserID(c))
+	if err != nil {
+		writePostError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, post)
+}
+
+func (h *Handler) UpdatePost(c *gorr.Context) {
+	postID, err := c.GetIntParam("post_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input postInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	if err := h.service.Post.Update(c.Context(), postID, service.PostInput{
+		UserID:      getUserID(c),
+		Title:       input.Title,
+		Content:     input.Content,
+		CategoryIDs: input.Categories,
+	}); err != nil {
+		writePostError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, postIDResponse{PostID: postID})
+}
+
+func (h *Handler) DeletePost(c *gorr.Context) {
+	postID, err := c.GetIntParam("post_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Post.Delete(c.Context(), getUserID(c), postID); err != nil {
+		writePostError(c, err)
+		return
+	}
+
+	c.WriteHeader(http.StatusNoContent)
+}
+
+// GetPostsByCategory is the feed of a category, the home feed is the one of "All". The first page is
+// ordered with ?sort=new|top|hot|discussed and ?period=day|week|month|all, the next ones with ?cursor=.
+func (h *Handler) GetPostsByCategory(c *gorr.Context) {
+	categoryID, err := c.GetIntParam("category_id")
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := c.URL.Query()
+
+	feed := service.Feed{
+		Sort:   query.Get("sort"),
+		Period: query.Get("period"),
+	}
+
+	posts, cursors, err := h.service.Post.GetPostsByCategoryID(c.Context(), categoryID, feed, query.Get("cursor"))
+	if err != nil {
+		writePostError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, postsResponse{Posts: posts, Cursors: cursors})
+}
+
+func writePostError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrNotPostAuthor):
+		c.WriteError(http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrEmptyPost):
+		writeFieldError(c, http.StatusUnprocessableEntity, "data", err)
+	case errors.Is(err, service.ErrNoCategories),
+		errors.Is(err, service.ErrUnknownCategory),
+		errors.Is(err, service.ErrArchivedCategory):
+		writeFieldError(c, http.StatusUnprocessableEntity, "categories", err)
+	case errors.Is(err, service.ErrUnknownSort),
+		errors.Is(err, service.ErrUnknownPeriod),
+		errors.Is(err, service.ErrInvalidCursor):
+		c.WriteError(http.StatusBadRequest, err.Error())
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
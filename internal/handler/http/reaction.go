@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
+
+	"github.com/rshezarr/gorr"
+)
+
+type reactionInput struct {
+	Emoji string `json:"emoji"`
+}
+
+func (i reactionInput) validate(v *validator.Validator) {
+	v.Check(i.Emoji != "", "emoji", "emoji is required")
+}
+
+func (h *Handler) GetPostReactions(c *gorr.Context) {
+	h.getReactions(c, "post_id", service.PostTarget)
+}
+
+func (h *Handler) ReactPost(c *gorr.Context) {
+	h.react(c, "post_id", service.PostTarget)
+}
+
+func (h *Handler) UnreactPost(c *gorr.Context) {
+	h.unreact(c, "post_id", service.PostTarget)
+}
+
+func (h *Handler) GetPostReactionUsers(c *gorr.Context) {
+	h.getReactionUsers(c, "post_id", service.PostTarget)
+}
+
+func (h *Handler) GetCommentReactions(c *gorr.Context) {
+	h.getReactions(c, "comment_id", service.CommentTarget)
+}
+
+func (h *Handler) ReactComment(c *gorr.Context) {
+	h.react(c, "comment_id", service.CommentTarget)
+}
+
+func (h *Handler) UnreactComment(c *gorr.Context) {
+	h.unreact(c, "comment_id", service.CommentTarget)
+}
+
+func (h *Handler) GetCommentReactionUsers(c *gorr.Context) {
+	h.getReactionUsers(c, "comment_id", service.CommentTarget)
+}
+
+func (h *Handler) getReactions(c *gorr.Context, param string, target string) {
+	targetID, err := c.GetIntParam(param)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reactions, err := h.service.Reaction.GetReactions(c.Context(), getUserID(c), target, targetID)
+	if err != nil {
+		writeReactionError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, reactions)
+}
+
+func (h *Handler) react(c *gorr.Context, param string, target string) {
+	targetID, err := c.GetIntParam(param)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input reactionInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	reactions, err := h.service.Reaction.React(c.Context(), getUserID(c), target, targetID, input.Emoji)
+	if err != nil {
+		writeReactionError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, reactions)
+}
+
+// unreact removes the reaction given with ?emoji=, DELETE requests carry no body.
+func (h *Handler) unreact(c *gorr.Context, param string, target string) {
+	targetID, err := c.GetIntParam(param)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	emoji := c.URL.Query().Get("emoji")
+
+	reactions, err := h.service.Reaction.Unreact(c.Context(), getUserID(c), target, targetID, emoji)
+	if err != nil {
+		writeReactionError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, reactions)
+}
+
+// getReactionUsers lists who reacted with ?emoji=.
+func (h *Handler) getReactionUsers(c *gorr.Context, param string, target string) {
+	targetID, err := c.GetIntParam(param)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	emoji := c.URL.Query().Get("emoji")
+
+	users, err := h.service.Reaction.GetReactionUsers(c.Context(), getUserID(c), target, targetID, emoji)
+	if err != nil {
+		writeReactionError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, users)
+}
+
+func writeReactionError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound),
+		errors.Is(err, service.ErrCommentNotFound):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidEmoji):
+		writeFieldError(c, http.StatusUnprocessableEntity, "emoji", err)
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/service"
+
+	"github.com/rshezarr/gorr"
+)
+
+type searchResponse struct {
+	Results []model.SearchResult `json:"results"`
+}
+
+type usersResponse struct {
+	Users []model.User `json:"users"`
+}
+
+var errInvalidQueryParam = errors.New("category and page must be numbers")
+
+// Search handles /api/search?q=&category=&author=&page=, only q is required.
+func (h *Handler) Search(c *gorr.Context) {
+	query := c.URL.Query()
+
+	categoryID, err := intQueryParam(query.Get("category"), 0)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := intQueryParam(query.Get("page"), 1)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := h.service.Search.Search(c.Context(), service.SearchInput{
+		Query:      query.Get("q"),
+		CategoryID: categoryID,
+		Author:     query.Get("author"),
+		Page:       page,
+	})
+	if err != nil {
+		writeSearchError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, searchResponse{Results: results})
+}
+
+// SearchUsers handles /api/search/users?q= for picking a user to talk to.
+func (h *Handler) SearchUsers(c *gorr.Context) {
+	users, err := h.service.Search.SearchUsers(c.Context(), getUserID(c), c.URL.Query().Get("q"))
+	if err != nil {
+		writeSearchError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, usersResponse{Users: users})
+}
+
+func intQueryParam(value string, defaultValue int) (int, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errInvalidQueryParam
+	}
+
+	return n, nil
+}
+
+func writeSearchError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidSearch),
+		errors.Is(err, service.ErrInvalidPage):
+		c.WriteError(http.StatusBadRequest, err.Error())
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
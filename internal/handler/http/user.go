@@ -3,7 +3,9 @@ package http
 import (
 	"errors"
 	"net/http"
+
 	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
 
 	"github.com/rshezarr/gorr"
 )
@@ -18,11 +20,17 @@ type usersSignUpInput struct {
 	Gender    string `json:"gender"`
 }
 
+func (i usersSignUpInput) validate(v *validator.Validator) {
+	checkEmail(v, i.Email)
+	checkUsername(v, i.Username)
+	checkPassword(v, "password", i.Password)
+	checkProfile(v, i.FirstName, i.LastName, i.Age, i.Gender)
+}
+
 func (h *Handler) SignUp(c *gorr.Context) {
 	var input usersSignUpInput
 
-	if err := c.ReadBody(&input); err != nil {
-		c.WriteError(http.StatusBadRequest, err.Error())
+	if !readInput(c, &input) {
 		return
 	}
 
@@ -35,7 +43,7 @@ func (h *Handler) SignUp(c *gorr.Context) {
 		Email:     input.Email,
 		Password:  input.Password,
 	}); err != nil {
-		c.WriteError(http.StatusInternalServerError, err.Error())
+		writeSignUpError(c, err)
 		return
 	}
 
@@ -47,34 +55,105 @@ type usersSignInInput struct {
 	Password        string `json:"password"`
 }
 
+func (i usersSignInInput) validate(v *validator.Validator) {
+	v.Check(validator.NotBlank(i.UsernameOrEmail), "usernameOrEmail", "username or email is required")
+	v.Check(i.Password != "", "password", "password is required")
+}
+
 type tokenResponse struct {
-	Token string `json:"token"`
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
 }
 
 func (h *Handler) SignIn(c *gorr.Context) {
 	var input usersSignInInput
 
-	if err := c.ReadBody(&input); err != nil {
-		c.WriteError(http.StatusBadRequest, err.Error())
+	if !readInput(c, &input) {
 		return
 	}
 
-	token, err := h.service.User.SignIn(c.Context(), service.UserSignInInput{
+	tokens, err := h.service.User.SignIn(c.Context(), service.UserSignInInput{
 		UsernameOrEmail: input.UsernameOrEmail,
 		Password:        input.Password,
 	})
 	if err != nil {
-		c.WriteError(http.StatusBadRequest, err.Error())
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			c.WriteError(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.WriteError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := tokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+	}
+
+	c.WriteJSON(http.StatusOK, resp)
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (i refreshInput) validate(v *validator.Validator) {
+	v.Check(i.RefreshToken != "", "refreshToken", "refresh token is required")
+}
+
+func (h *Handler) Refresh(c *gorr.Context) {
+	var input refreshInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	tokens, err := h.service.User.RefreshTokens(c.Context(), input.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			c.WriteError(http.StatusUnauthorized, err.Error())
+			return
+		}
+		c.WriteError(http.StatusInternalServerError, err.Error())
 		return
 	}
 
 	resp := tokenResponse{
-		Token: token,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}
 
 	c.WriteJSON(http.StatusOK, resp)
 }
 
+type signOutInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+func (i signOutInput) validate(v *validator.Validator) {
+	v.Check(i.RefreshToken != "", "refreshToken", "refresh token is required")
+}
+
+// SignOut ends the session of the device the request comes from, the other ones stay signed in.
+func (h *Handler) SignOut(c *gorr.Context) {
+	var input signOutInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	if err := h.service.User.SignOut(c.Context(), getUserID(c), input.RefreshToken); err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			writeFieldError(c, http.StatusUnprocessableEntity, "refreshToken", err)
+			return
+		}
+		c.WriteError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.WriteHeader(http.StatusNoContent)
+}
+
 func (h *Handler) GetUser(c *gorr.Context) {
 	userID, err := c.GetIntParam("user_id")
 	if err != nil {
@@ -92,9 +171,22 @@ func (h *Handler) GetUser(c *gorr.Context) {
 		return
 	}
 
-	c.WriteJSON(http.StatusOK, user)
+	user.Online = h.service.Presence.IsOnline(user.ID)
+
+	c.WriteJSON(http.StatusOK, user.Public())
+}
+
+func (h *Handler) GetOnlineUsers(c *gorr.Context) {
+	users, err := h.service.Presence.GetOnlineUsers(c.Context())
+	if err != nil {
+		c.WriteError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, users)
 }
 
+// GetUserPosts returns the posts of the user newest first, the next pages are read with ?cursor=.
 func (h *Handler) GetUserPosts(c *gorr.Context) {
 	userID, err := c.GetIntParam("user_id")
 	if err != nil {
@@ -102,17 +194,13 @@ func (h *Handler) GetUserPosts(c *gorr.Context) {
 		return
 	}
 
-	posts, err := h.service.User.GetUsersPosts(c.Context(), userID)
+	posts, cursors, err := h.service.User.GetUsersPosts(c.Context(), userID, c.URL.Query().Get("cursor"))
 	if err != nil {
-		if errors.Is(err, service.ErrUserDoesNotExists) {
-			c.WriteError(http.StatusNotFound, err.Error())
-			return
-		}
-		c.WriteError(http.StatusInternalServerError, err.Error())
+		writeUserPostsError(c, err)
 		return
 	}
 
-	c.WriteJSON(http.StatusOK, posts)
+	c.WriteJSON(http.StatusOK, postsResponse{Posts: posts, Cursors: cursors})
 }
 
 func (h *Handler) GetUserVotedPosts(c *gorr.Context) {
@@ -122,15 +210,35 @@ func (h *Handler) GetUserVotedPosts(c *gorr.Context) {
 		return
 	}
 
-	likedPosts, err := h.service.User.GetUsersVotedPosts(c.Context(), userID)
+	posts, cursors, err := h.service.User.GetUsersVotedPosts(c.Context(), userID, c.URL.Query().Get("cursor"))
 	if err != nil {
-		if errors.Is(err, service.ErrUserDoesNotExists) {
-			c.WriteError(http.StatusNotFound, err.Error())
-			return
-		}
-		c.WriteError(http.StatusInternalServerError, err.Error())
+		writeUserPostsError(c, err)
 		return
 	}
 
-	c.WriteJSON(http.StatusOK, likedPosts)
+	c.WriteJSON(http.StatusOK, postsResponse{Posts: posts, Cursors: cursors})
+}
+
+func writeUserPostsError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserDoesNotExists):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidCursor):
+		c.WriteError(http.StatusBadRequest, err.Error())
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeSignUpError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUsernameTaken):
+		writeFieldError(c, http.StatusConflict, "username", err)
+	case errors.Is(err, service.ErrEmailTaken):
+		writeFieldError(c, http.StatusConflict, "email", err)
+	case errors.Is(err, service.ErrUnknownGender):
+		writeFieldError(c, http.StatusUnprocessableEntity, "gender", err)
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
 }
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/service"
+)
+
+// fakeUsers knows the users, the methods a test doesn't set panic on the nil service.User.
+type fakeUsers struct {
+	service.User
+	users  map[int]model.User
+	tokens map[string]int
+}
+
+func (f fakeUsers) GetByID(ctx context.Context, userID int) (model.User, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return model.User{}, service.ErrUserDoesNotExists
+	}
+	return user, nil
+}
+
+// Identify knows the tokens given to it, "expired" has expired. Every token has a session of its own.
+func (f fakeUsers) Identify(ctx context.Context, token string) (service.Identity, error) {
+	if token == "expired" {
+		return service.Identity{}, service.ErrTokenExpired
+	}
+
+	userID, ok := f.tokens[token]
+	if !ok {
+		return service.Identity{}, service.ErrInvalidToken
+	}
+	return service.Identity{UserID: userID, SessionID: token}, nil
+}
+
+var alice = model.User{
+	ID:        1,
+	Email:     "alice@example.com",
+	Username:  "alice",
+	Password:  "hash",
+	FirstName: "Alice",
+	LastName:  "Liddell",
+	Age:       20,
+	Gender:    "Female",
+	Avatar:    "default.jpg",
+}
+
+func newTestHandler(users service.User) *Handler {
+	return &Handler{service: &service.Service{User: users, Presence: service.NewPresence(nil)}}
+}
+
+// serve sends the request through the routes and decodes the JSON response into a map.
+func serve(t *testing.T, h *Handler, req *http.Request) (int, map[string]interface{}) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.InitRoutes().ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	if rec.Body.Len() > 0 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("response %q isn't a JSON object: %v", rec.Body.String(), err)
+		}
+	}
+
+	return rec.Code, body
+}
+
+func TestGetUser(t *testing.T) {
+	h := newTestHandler(fakeUsers{users: map[int]model.User{1: alice}})
+
+	tests := []struct {
+		name     string
+		path     string
+		wantCode int
+		wantKeys []string
+	}{
+		{"public profile", "/api/user/1", http.StatusOK, []string{"id", "username", "firstName", "lastName", "avatar", "lastSeen", "online"}},
+		{"unknown user", "/api/user/2", http.StatusNotFound, []string{"error"}},
+		{"online list without sign in", "/api/user/online", http.StatusUnauthorized, []string{"error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := serve(t, h, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", code, tt.wantCode)
+			}
+
+			if len(body) != len(tt.wantKeys) {
+				t.Errorf("body = %v, want only the keys %v", body, tt.wantKeys)
+			}
+			for _, key := range tt.wantKeys {
+				if _, ok := body[key]; !ok {
+					t.Errorf("body = %v, want the key %s", body, key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"real-time-forum/pkg/validator"
+
+	"github.com/rshezarr/gorr"
+)
+
+var (
+	errMalformedBody = errors.New("request body must be a JSON object")
+	errInvalidInput  = errors.New("invalid input")
+)
+
+// errorResponse is the body of every error response, Fields tells which fields of the input
+// caused the error and why, so a client can show it next to them.
+type errorResponse struct {
+	Error  string           `json:"error"`
+	Fields validator.Errors `json:"fields,omitempty"`
+}
+
+// input is a request body checking its own fields.
+type input interface {
+	validate(v *validator.Validator)
+}
+
+// readInput reads the body into in and validates it. A body that isn't the JSON of the input
+// is answered with 400, invalid fields with 422. It reports if the input can be used.
+func readInput(c *gorr.Context, in input) bool {
+	if err := c.ReadBody(in); err != nil {
+		writeBodyError(c, err)
+		return false
+	}
+
+	v := validator.New()
+	in.validate(v)
+
+	if err := v.Err(); err != nil {
+		writeValidationError(c, err)
+		return false
+	}
+
+	return true
+}
+
+func writeBodyError(c *gorr.Context, err error) {
+	resp := errorResponse{Error: errMalformedBody.Error()}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		resp.Fields = validator.Errors{{
+			Field:   typeErr.Field,
+			Message: typeErr.Field + " must be " + jsonType(typeErr.Type),
+		}}
+	}
+
+	c.WriteJSON(http.StatusBadRequest, resp)
+}
+
+func writeValidationError(c *gorr.Context, err error) {
+	var fields validator.Errors
+	if !errors.As(err, &fields) {
+		c.WriteError(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.WriteJSON(http.StatusUnprocessableEntity, errorResponse{Error: errInvalidInput.Error(), Fields: fields})
+}
+
+// writeFieldError answers with an error of the service caused by the value of one field.
+func writeFieldError(c *gorr.Context, code int, field string, err error) {
+	c.WriteJSON(code, errorResponse{
+		Error:  err.Error(),
+		Fields: validator.Errors{{Field: field, Message: err.Error()}},
+	})
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/service"
+)
+
+// fakeAccounts changes the account of alice, her password is "secret-password" and bob has bob@example.com.
+type fakeAccounts struct {
+	fakeUsers
+}
+
+func (f fakeAccounts) ChangePassword(ctx context.Context, userID int, sessionID string, input service.PasswordInput) (service.Tokens, error) {
+	if input.CurrentPassword != "secret-password" {
+		return service.Tokens{}, service.ErrWrongPassword
+	}
+	return service.Tokens{AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+func (f fakeAccounts) ChangeEmail(ctx context.Context, userID int, input service.EmailInput) (model.User, error) {
+	if input.CurrentPassword != "secret-password" {
+		return model.User{}, service.ErrWrongPassword
+	}
+	if input.Email == "bob@example.com" {
+		return model.User{}, service.ErrEmailTaken
+	}
+	return alice, nil
+}
+
+// fieldNames returns the fields of an error response in the order they're reported.
+func fieldNames(body map[string]interface{}) []string {
+	fields, _ := body["fields"].([]interface{})
+
+	var names []string
+	for _, field := range fields {
+		names = append(names, field.(map[string]interface{})["field"].(string))
+	}
+	return names
+}
+
+func TestValidation(t *testing.T) {
+	h := newTestHandler(fakeAccounts{fakeUsers{
+		users:  map[int]model.User{1: alice},
+		tokens: map[string]int{"alice-token": 1},
+	}})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantCode   int
+		wantFields []string
+	}{
+		{"not JSON", http.MethodPost, "/api/user/sign-up", `email=alice`, http.StatusBadRequest, nil},
+		{"wrong type", http.MethodPost, "/api/user/sign-up", `{"age": "20"}`, http.StatusBadRequest, []string{"age"}},
+		{
+			"every invalid field", http.MethodPost, "/api/user/sign-up",
+			`{"email": "alice", "username": "a@b", "password": "short", "firstName": " ", "lastName": "Liddell", "age": 12, "gender": "female"}`,
+			http.StatusUnprocessableEntity, []string{"email", "username", "password", "firstName", "age", "gender"},
+		},
+		{
+			"long email", http.MethodPut, "/api/user/email",
+			`{"email": "` + strings.Repeat("a", 40) + `@example.com"}`,
+			http.StatusUnprocessableEntity, []string{"email", "currentPassword"},
+		},
+		{
+			"email with a wrong password", http.MethodPut, "/api/user/email",
+			`{"email": "alice@example.org", "currentPassword": "guess"}`,
+			http.StatusUnprocessableEntity, []string{"currentPassword"},
+		},
+		{
+			"email taken", http.MethodPut, "/api/user/email",
+			`{"email": "bob@example.com", "currentPassword": "secret-password"}`,
+			http.StatusConflict, []string{"email"},
+		},
+		{
+			"email changed", http.MethodPut, "/api/user/email",
+			`{"email": "alice@example.org", "currentPassword": "secret-password"}`,
+			http.StatusAccepted, nil,
+		},
+		{
+			"weak new password", http.MethodPut, "/api/user/password",
+			`{"currentPassword": "", "newPassword": "short"}`,
+			http.StatusUnprocessableEntity, []string{"currentPassword", "newPassword"},
+		},
+		{
+			"wrong current password", http.MethodPut, "/api/user/password",
+			`{"currentPassword": "guess", "newPassword": "new-password"}`,
+			http.StatusUnprocessableEntity, []string{"currentPassword"},
+		},
+		{
+			"password changed", http.MethodPut, "/api/user/password",
+			`{"currentPassword": "secret-password", "newPassword": "new-password"}`,
+			http.StatusOK, nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer alice-token")
+
+			code, body := serve(t, h, req)
+			if code != tt.wantCode {
+				t.Fatalf("code = %d, want %d: %v", code, tt.wantCode, body)
+			}
+
+			if msg, _ := body["error"].(string); code >= http.StatusBadRequest && msg == "" {
+				t.Errorf("body = %v, want an error", body)
+			}
+			if got := fieldNames(body); !reflect.DeepEqual(got, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", got, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
+
+	"github.com/rshezarr/gorr"
+)
+
+type likeInput struct {
+	LikeType int `json:"likeType"`
+}
+
+func (i likeInput) validate(v *validator.Validator) {
+	v.Check(i.LikeType == service.Like || i.LikeType == service.Dislike, "likeType", "likeType must be 1 to like or 2 to dislike")
+}
+
+func (h *Handler) VotePost(c *gorr.Context) {
+	h.vote(c, "post_id", h.service.Vote.VotePost)
+}
+
+func (h *Handler) RetractPostVote(c *gorr.Context) {
+	h.retractVote(c, "post_id", h.service.Vote.VotePost)
+}
+
+func (h *Handler) VoteComment(c *gorr.Context) {
+	h.vote(c, "comment_id", h.service.Vote.VoteComment)
+}
+
+func (h *Handler) RetractCommentVote(c *gorr.Context) {
+	h.retractVote(c, "comment_id", h.service.Vote.VoteComment)
+}
+
+type voteFunc func(ctx context.Context, userID int, targetID int, likeType int) (model.VoteResult, error)
+
+func (h *Handler) vote(c *gorr.Context, param string, vote voteFunc) {
+	targetID, err := c.GetIntParam(param)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input likeInput
+
+	if !readInput(c, &input) {
+		return
+	}
+
+	result, err := vote(c.Context(), getUserID(c), targetID, input.LikeType)
+	if err != nil {
+		writeVoteError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, result)
+}
+
+func (h *Handler) retractVote(c *gorr.Context, param string, vote voteFunc) {
+	targetID, err := c.GetIntParam(param)
+	if err != nil {
+		c.WriteError(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := vote(c.Context(), getUserID(c), targetID, service.Retract)
+	if err != nil {
+		writeVoteError(c, err)
+		return
+	}
+
+	c.WriteJSON(http.StatusOK, result)
+}
+
+func writeVoteError(c *gorr.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrPostNotFound),
+		errors.Is(err, service.ErrCommentNotFound):
+		c.WriteError(http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidLikeType):
+		writeFieldError(c, http.StatusUnprocessableEntity, "likeType", err)
+	default:
+		c.WriteError(http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,188 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/service"
+)
+
+func (h *Handler) sendMessage(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messageInput
+	if err := readInput(body, &input); err != nil {
+		return err
+	}
+
+	message, err := h.service.Chat.SendMessage(ctx, model.Message{
+		SenderID:    c.userID,
+		RecipientID: input.RecipientID,
+		Message:     input.Message,
+	})
+	if err != nil {
+		return messageError(err)
+	}
+
+	// the message is what the partner was waiting for
+	h.typing.stop(message.SenderID, message.RecipientID)
+
+	event := Event{Type: messageEvent, Body: message}
+	h.hub.SendToUser(message.RecipientID, event)
+	h.hub.SendToUser(message.SenderID, event)
+
+	return nil
+}
+
+// messageError points at the field of the message input an error of the service is caused by.
+func messageError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrEmptyMessage):
+		return fieldError("message", err)
+	case errors.Is(err, service.ErrSelfRecipient), errors.Is(err, service.ErrUnknownRecipient):
+		return fieldError("recipientID", err)
+	default:
+		return err
+	}
+}
+
+func (h *Handler) getMessages(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messagesRequestInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	messages, cursors, err := h.service.Chat.GetMessages(ctx, c.userID, input.UserID, input.Cursor)
+	if err != nil {
+		return err
+	}
+
+	c.write(Event{Type: messagesResponseEvent, Body: messagesResponse{
+		UserID:   input.UserID,
+		Messages: messages,
+		Cursors:  cursors,
+	}})
+
+	return nil
+}
+
+func (h *Handler) getChats(ctx context.Context, c *Client, body json.RawMessage) error {
+	chats, err := h.service.Chat.GetChats(ctx, c.userID)
+	if err != nil {
+		return err
+	}
+
+	c.write(Event{Type: chatsResponseEvent, Body: chats})
+
+	return nil
+}
+
+func (h *Handler) readMessage(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messageIDInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	receipt, err := h.service.Chat.ReadMessages(ctx, c.userID, input.MessageID)
+	if err != nil {
+		return err
+	}
+
+	event := Event{Type: readMessageResponseEvent, Body: receipt}
+	// every tab of the reader updates its counter, the sender learns only about new reads
+	h.hub.SendToUser(receipt.RecipientID, event)
+	if receipt.ReadCount > 0 {
+		h.hub.SendToUser(receipt.SenderID, event)
+	}
+
+	return nil
+}
+
+func (h *Handler) editMessage(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input editMessageInput
+	if err := readInput(body, &input); err != nil {
+		return err
+	}
+
+	message, err := h.service.Chat.EditMessage(ctx, c.userID, input.MessageID, input.Message)
+	if err != nil {
+		if errors.Is(err, service.ErrMessageUnchanged) {
+			return nil
+		}
+		return messageError(err)
+	}
+
+	event := Event{Type: messageEditedEvent, Body: message}
+	h.hub.SendToUser(message.RecipientID, event)
+	h.hub.SendToUser(message.SenderID, event)
+
+	return nil
+}
+
+func (h *Handler) deleteMessage(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messageIDInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	message, err := h.service.Chat.DeleteMessage(ctx, c.userID, input.MessageID)
+	if err != nil {
+		return err
+	}
+
+	event := Event{Type: messageDeletedEvent, Body: message}
+	h.hub.SendToUser(message.RecipientID, event)
+	h.hub.SendToUser(message.SenderID, event)
+
+	return nil
+}
+
+func (h *Handler) getMessageEdits(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messageIDInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	edits, err := h.service.Chat.GetMessageEdits(ctx, c.userID, input.MessageID)
+	if err != nil {
+		return err
+	}
+
+	c.write(Event{Type: messageEditsResponseEvent, Body: edits})
+
+	return nil
+}
+
+func (h *Handler) getOnlineUsers(ctx context.Context, c *Client, body json.RawMessage) error {
+	users, err := h.service.Presence.GetOnlineUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.write(Event{Type: onlineUsersResponseEvent, Body: users})
+
+	return nil
+}
+
+func (h *Handler) typingIn(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input typingInInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	if input.RecipientID <= 0 || input.RecipientID == c.userID {
+		return ErrInvalidEventBody
+	}
+
+	// the typing state is kept per pair, ids of users that don't exist would fill the tracker
+	if _, err := h.service.User.GetByID(ctx, input.RecipientID); err != nil {
+		if errors.Is(err, service.ErrUserDoesNotExists) {
+			return service.ErrUnknownRecipient
+		}
+		return err
+	}
+
+	h.typing.start(c.userID, input.RecipientID)
+
+	return nil
+}
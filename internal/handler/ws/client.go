@@ -0,0 +1,149 @@
+package ws
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"real-time-forum/internal/config"
+
+	"github.com/gorilla/websocket"
+)
+
+const sendBufferSize = 256
+
+// used when the config leaves a setting empty
+const (
+	defaultPingPeriod     = 30 * time.Second
+	defaultMaxMissedPongs = 2
+	defaultWriteWait      = 10 * time.Second
+	defaultMaxMessageSize = 4096
+)
+
+// heartbeat holds the connection timings, the server pings every pingPeriod
+// and drops a connection that missed more than maxMissedPongs pongs in a row.
+type heartbeat struct {
+	pingPeriod     time.Duration
+	maxMissedPongs int32
+	writeWait      time.Duration
+	maxMessageSize int64
+}
+
+func newHeartbeat(cfg config.WS) heartbeat {
+	hb := heartbeat{
+		pingPeriod:     time.Duration(cfg.PingPeriod) * time.Second,
+		maxMissedPongs: int32(cfg.MaxMissedPongs),
+		writeWait:      time.Duration(cfg.WriteTimeout) * time.Second,
+		maxMessageSize: cfg.MaxMessageSize,
+	}
+
+	if hb.pingPeriod <= 0 {
+		hb.pingPeriod = defaultPingPeriod
+	}
+	if hb.maxMissedPongs <= 0 {
+		hb.maxMissedPongs = defaultMaxMissedPongs
+	}
+	if hb.writeWait <= 0 {
+		hb.writeWait = defaultWriteWait
+	}
+	if hb.maxMessageSize <= 0 {
+		hb.maxMessageSize = defaultMaxMessageSize
+	}
+
+	return hb
+}
+
+// pongWait is how long the connection may stay silent before the read fails.
+func (hb heartbeat) pongWait() time.Duration {
+	return hb.pingPeriod * time.Duration(hb.maxMissedPongs+1)
+}
+
+// Client is a single websocket connection. userID stays zero until the connection is authenticated with a token.
+type Client struct {
+	conn   *websocket.Conn
+	send   chan Event
+	done   chan struct{}
+	once   sync.Once
+	userID int
+
+	// session of the last token, guarded by the hub
+	sessionID string
+
+	heartbeat   heartbeat
+	missedPongs int32
+
+	// feed topics, guarded by the hub
+	topics map[string]struct{}
+}
+
+func newClient(conn *websocket.Conn, hb heartbeat) *Client {
+	return &Client{
+		conn:      conn,
+		send:      make(chan Event, sendBufferSize),
+		done:      make(chan struct{}),
+		heartbeat: hb,
+		topics:    make(map[string]struct{}),
+	}
+}
+
+// write queues the event, a client that can't keep up with its queue is disconnected.
+func (c *Client) write(event Event) {
+	select {
+	case <-c.done:
+	case c.send <- event:
+	default:
+		c.close()
+	}
+}
+
+func (c *Client) close() {
+	c.once.Do(func() {
+		close(c.done)
+	})
+}
+
+// pong is called from the read loop on both control and application pongs.
+func (c *Client) pong() {
+	atomic.StoreInt32(&c.missedPongs, 0)
+	c.conn.SetReadDeadline(time.Now().Add(c.heartbeat.pongWait()))
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(c.heartbeat.pingPeriod)
+
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case event := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(c.heartbeat.writeWait))
+			if err := c.conn.WriteJSON(event); err != nil {
+				c.close()
+				return
+			}
+		case <-ticker.C:
+			if atomic.AddInt32(&c.missedPongs, 1) > c.heartbeat.maxMissedPongs {
+				c.close()
+				return
+			}
+
+			// browsers answer control pings themselves, the web client answers pingMessage
+			c.conn.SetWriteDeadline(time.Now().Add(c.heartbeat.writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close()
+				return
+			}
+			if err := c.conn.WriteJSON(Event{Type: pingMessageEvent}); err != nil {
+				c.close()
+				return
+			}
+		case <-c.done:
+			c.conn.SetWriteDeadline(time.Now().Add(c.heartbeat.writeWait))
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return
+		}
+	}
+}
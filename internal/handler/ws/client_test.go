@@ -0,0 +1,187 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/config"
+
+	"github.com/gorilla/websocket"
+	"github.com/rshezarr/gorr"
+)
+
+func TestNewHeartbeat(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.WS
+		want heartbeat
+	}{
+		{
+			name: "empty config",
+			cfg:  config.WS{},
+			want: heartbeat{defaultPingPeriod, defaultMaxMissedPongs, defaultWriteWait, defaultMaxMessageSize},
+		},
+		{
+			name: "negative settings",
+			cfg:  config.WS{PingPeriod: -1, MaxMissedPongs: -1, WriteTimeout: -1, MaxMessageSize: -1},
+			want: heartbeat{defaultPingPeriod, defaultMaxMissedPongs, defaultWriteWait, defaultMaxMessageSize},
+		},
+		{
+			name: "configured",
+			cfg:  config.WS{PingPeriod: 5, MaxMissedPongs: 3, WriteTimeout: 2, MaxMessageSize: 1024},
+			want: heartbeat{5 * time.Second, 3, 2 * time.Second, 1024},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newHeartbeat(tt.cfg); got != tt.want {
+				t.Errorf("newHeartbeat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// dialTestServer serves a websocket handler with a fast heartbeat and connects to it.
+func dialTestServer(t *testing.T, hb heartbeat) *websocket.Conn {
+	t.Helper()
+
+	h := newTestHandler()
+	h.heartbeat = hb
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeWS(&gorr.Context{ResponseWriter: w, Request: r})
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestHeartbeat(t *testing.T) {
+	hb := heartbeat{
+		pingPeriod:     30 * time.Millisecond,
+		maxMissedPongs: 2,
+		writeWait:      time.Second,
+		maxMessageSize: defaultMaxMessageSize,
+	}
+	// long enough to miss every pong allowed and a few more
+	silence := hb.pingPeriod * time.Duration(hb.maxMissedPongs+4)
+
+	tests := []struct {
+		name string
+		// answer reads the connection until it fails, answering the pings its way
+		answer     func(conn *websocket.Conn) error
+		wantClosed bool
+	}{
+		{
+			name: "control pongs",
+			answer: func(conn *websocket.Conn) error {
+				// the default ping handler answers with a pong
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return err
+					}
+				}
+			},
+		},
+		{
+			name: "pongMessage events",
+			answer: func(conn *websocket.Conn) error {
+				conn.SetPingHandler(func(string) error { return nil })
+				for {
+					var e Event
+					if err := conn.ReadJSON(&e); err != nil {
+						return err
+					}
+					if e.Type == pingMessageEvent {
+						if err := conn.WriteJSON(Event{Type: pongMessageEvent}); err != nil {
+							return err
+						}
+					}
+				}
+			},
+		},
+		{
+			name: "missed pongs",
+			answer: func(conn *websocket.Conn) error {
+				conn.SetPingHandler(func(string) error { return nil })
+				for {
+					if _, _, err := conn.ReadMessage(); err != nil {
+						return err
+					}
+				}
+			},
+			wantClosed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			conn := dialTestServer(t, hb)
+
+			errs := make(chan error, 1)
+			go func() { errs <- tt.answer(conn) }()
+
+			select {
+			case err := <-errs:
+				if !tt.wantClosed {
+					t.Fatalf("connection closed: %v", err)
+				}
+
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					t.Fatalf("read timed out instead of the server closing the connection: %v", err)
+				}
+			case <-time.After(silence):
+				if tt.wantClosed {
+					t.Fatal("connection is still open")
+				}
+			}
+		})
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	conn := dialTestServer(t, heartbeat{
+		pingPeriod:     time.Second,
+		maxMissedPongs: 2,
+		writeWait:      time.Second,
+		maxMessageSize: 64,
+	})
+
+	body, err := json.Marshal(strings.Repeat("a", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteJSON(rawEvent{Type: tokenEvent, Body: body}); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+
+		if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+			t.Errorf("read error = %v, want the close for a message too big", err)
+		}
+		return
+	}
+}
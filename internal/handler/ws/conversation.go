@@ -0,0 +1,49 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// sendConversationMessage stores the message, the service delivers it to every member.
+func (h *Handler) sendConversationMessage(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input conversationMessageInput
+	if err := readInput(body, &input); err != nil {
+		return err
+	}
+
+	_, err := h.service.Conversation.SendMessage(ctx, c.userID, input.ConversationID, input.Message)
+
+	return messageError(err)
+}
+
+func (h *Handler) getConversationMessages(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input conversationMessagesRequestInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	messages, cursors, err := h.service.Conversation.GetMessages(ctx, c.userID, input.ConversationID, input.Cursor)
+	if err != nil {
+		return err
+	}
+
+	c.write(Event{Type: conversationMessagesResponseEvent, Body: conversationMessagesResponse{
+		ConversationID: input.ConversationID,
+		Messages:       messages,
+		Cursors:        cursors,
+	}})
+
+	return nil
+}
+
+func (h *Handler) readConversation(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input conversationReadInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	_, err := h.service.Conversation.ReadMessages(ctx, c.userID, input.ConversationID, input.MessageID)
+
+	return err
+}
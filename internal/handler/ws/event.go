@@ -0,0 +1,140 @@
+package ws
+
+import (
+	"encoding/json"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/service"
+)
+
+// incoming event types
+const (
+	tokenEvent                       = "token"
+	messageEvent                     = "message"
+	messagesRequestEvent             = "messagesRequest"
+	chatsRequestEvent                = "chatsRequest"
+	readMessageRequestEvent          = "readMessageRequest"
+	onlineUsersRequestEvent          = "onlineUsersRequest"
+	typingInRequestEvent             = "typingInRequest"
+	editMessageEvent                 = "editMessage"
+	deleteMessageEvent               = "deleteMessage"
+	messageEditsRequestEvent         = "messageEditsRequest"
+	reactMessageEvent                = "reactMessage"
+	unreactMessageEvent              = "unreactMessage"
+	messageReactionsRequestEvent     = "messageReactionsRequest"
+	messageReactionUsersRequestEvent = "messageReactionUsersRequest"
+	pongMessageEvent                 = "pongMessage"
+	subscribeEvent                   = "subscribe"
+	unsubscribeEvent                 = "unsubscribe"
+
+	conversationMessageEvent         = "conversationMessage"
+	conversationMessagesRequestEvent = "conversationMessagesRequest"
+	conversationReadRequestEvent     = "conversationReadRequest"
+)
+
+// outgoing event types
+const (
+	messagesResponseEvent             = "messagesResponse"
+	chatsResponseEvent                = "chatsResponse"
+	readMessageResponseEvent          = "readMessageResponse"
+	onlineUsersResponseEvent          = "onlineUsersResponse"
+	typingInResponseEvent             = "typingInResponse"
+	messageEditedEvent                = "messageEdited"
+	messageDeletedEvent               = "messageDeleted"
+	messageEditsResponseEvent         = "messageEditsResponse"
+	messageReactionsResponseEvent     = "messageReactionsResponse"
+	messageReactionUsersResponseEvent = "messageReactionUsersResponse"
+	successConnectionEvent            = "successConnection"
+	pingMessageEvent                  = "pingMessage"
+	presenceEvent                     = "presence"
+	subscribedEvent                   = "subscribed"
+	unsubscribedEvent                 = "unsubscribed"
+
+	conversationMessagesResponseEvent = "conversationMessagesResponse"
+
+	errorEvent = "error"
+)
+
+// Event is a single frame sent to a client.
+type Event struct {
+	Type string      `json:"type"`
+	Body interface{} `json:"body,omitempty"`
+}
+
+// rawEvent is a single frame received from a client, the body is decoded by the event handler.
+type rawEvent struct {
+	Type string          `json:"type"`
+	Body json.RawMessage `json:"body"`
+}
+
+type messageInput struct {
+	RecipientID int    `json:"recipientID"`
+	Message     string `json:"message"`
+}
+
+type messagesRequestInput struct {
+	UserID int    `json:"userID"`
+	Cursor string `json:"cursor"`
+}
+
+type messagesResponse struct {
+	UserID   int             `json:"userID"`
+	Messages []model.Message `json:"messages"`
+	service.Cursors
+}
+
+type editMessageInput struct {
+	MessageID int    `json:"messageID"`
+	Message   string `json:"message"`
+}
+
+type messageIDInput struct {
+	MessageID int `json:"messageID"`
+}
+
+type messageReactionInput struct {
+	MessageID int    `json:"messageID"`
+	Emoji     string `json:"emoji"`
+}
+
+type typingInInput struct {
+	RecipientID int `json:"recipientID"`
+}
+
+type typingInResponse struct {
+	SenderID    int  `json:"senderID"`
+	RecipientID int  `json:"recipientID"`
+	Typing      bool `json:"typing"`
+}
+
+type presenceResponse struct {
+	UserID   int       `json:"userID"`
+	Online   bool      `json:"online"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+type subscriptionInput struct {
+	Topic string `json:"topic"`
+}
+
+type conversationMessageInput struct {
+	ConversationID int    `json:"conversationID"`
+	Message        string `json:"message"`
+}
+
+type conversationMessagesRequestInput struct {
+	ConversationID int    `json:"conversationID"`
+	Cursor         string `json:"cursor"`
+}
+
+type conversationMessagesResponse struct {
+	ConversationID int                         `json:"conversationID"`
+	Messages       []model.ConversationMessage `json:"messages"`
+	service.Cursors
+}
+
+type conversationReadInput struct {
+	ConversationID int `json:"conversationID"`
+	MessageID      int `json:"messageID"`
+}
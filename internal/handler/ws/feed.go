@@ -0,0 +1,41 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+)
+
+var ErrUnknownTopic = errors.New("unknown topic")
+
+// topics are "category:<id>" and "post:<id>", see service.CategoryTopic and service.PostTopic
+var topicRe = regexp.MustCompile(`^(category|post):[1-9]\d*$`)
+
+func (h *Handler) subscribe(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input subscriptionInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	if !topicRe.MatchString(input.Topic) {
+		return ErrUnknownTopic
+	}
+
+	h.hub.subscribe(c, input.Topic)
+	c.write(Event{Type: subscribedEvent, Body: input.Topic})
+
+	return nil
+}
+
+func (h *Handler) unsubscribe(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input subscriptionInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	h.hub.unsubscribe(c, input.Topic)
+	c.write(Event{Type: unsubscribedEvent, Body: input.Topic})
+
+	return nil
+}
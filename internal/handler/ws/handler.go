@@ -1 +1,222 @@
 package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"real-time-forum/internal/config"
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/logger"
+
+	"github.com/gorilla/websocket"
+	"github.com/rshezarr/gorr"
+)
+
+var (
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrUnknownEventType = errors.New("unknown event type")
+	ErrInvalidEventBody = errors.New("invalid event body")
+)
+
+type eventHandler func(ctx context.Context, c *Client, body json.RawMessage) error
+
+type Handler struct {
+	service   *service.Service
+	hub       *Hub
+	typing    *typingTracker
+	log       *logger.Logger
+	upgrader  websocket.Upgrader
+	heartbeat heartbeat
+	events    map[string]eventHandler
+}
+
+func NewHandler(service *service.Service, hub *Hub, cfg config.WS) *Handler {
+	h := &Handler{
+		service:   service,
+		hub:       hub,
+		typing:    newTypingTracker(hub),
+		heartbeat: newHeartbeat(cfg),
+		log:       logger.NewLogger("[WS]"),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// web client is served from another port
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+
+	h.events = map[string]eventHandler{
+		messageEvent:                     h.sendMessage,
+		messagesRequestEvent:             h.getMessages,
+		chatsRequestEvent:                h.getChats,
+		readMessageRequestEvent:          h.readMessage,
+		onlineUsersRequestEvent:          h.getOnlineUsers,
+		typingInRequestEvent:             h.typingIn,
+		editMessageEvent:                 h.editMessage,
+		deleteMessageEvent:               h.deleteMessage,
+		messageEditsRequestEvent:         h.getMessageEdits,
+		reactMessageEvent:                h.reactMessage,
+		unreactMessageEvent:              h.unreactMessage,
+		messageReactionsRequestEvent:     h.getMessageReactions,
+		messageReactionUsersRequestEvent: h.getMessageReactionUsers,
+		pongMessageEvent:                 h.pong,
+		subscribeEvent:                   h.subscribe,
+		unsubscribeEvent:                 h.unsubscribe,
+
+		conversationMessageEvent:         h.sendConversationMessage,
+		conversationMessagesRequestEvent: h.getConversationMessages,
+		conversationReadRequestEvent:     h.readConversation,
+	}
+
+	return h
+}
+
+func (h *Handler) Hub() *Hub {
+	return h.hub
+}
+
+// ServeWS upgrades the request and serves the connection until it is closed.
+func (h *Handler) ServeWS(c *gorr.Context) {
+	conn, err := h.upgrader.Upgrade(c.ResponseWriter, c.Request, nil)
+	if err != nil {
+		// upgrader has already written the error response
+		return
+	}
+
+	client := newClient(conn, h.heartbeat)
+
+	go client.writePump()
+	h.readPump(client)
+}
+
+func (h *Handler) readPump(c *Client) {
+	defer func() {
+		if c.userID != 0 {
+			h.disconnect(c)
+		}
+		h.hub.unsubscribeAll(c)
+		c.close()
+	}()
+
+	c.conn.SetReadLimit(c.heartbeat.maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(c.heartbeat.pongWait()))
+	c.conn.SetPongHandler(func(string) error {
+		c.pong()
+		return nil
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				h.log.Info("read message: %s", err.Error())
+			}
+			return
+		}
+
+		var event rawEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			c.write(Event{Type: errorEvent, Body: newErrorResponse(ErrInvalidEventBody)})
+			continue
+		}
+
+		if err := h.handleEvent(context.Background(), c, event); err != nil {
+			c.write(Event{Type: errorEvent, Body: newErrorResponse(err)})
+		}
+	}
+}
+
+func (h *Handler) handleEvent(ctx context.Context, c *Client, event rawEvent) error {
+	switch event.Type {
+	case tokenEvent:
+		return h.authenticate(ctx, c, event.Body)
+	case pongMessageEvent:
+		// the heartbeat runs before the connection is authenticated too
+		return h.pong(ctx, c, event.Body)
+	}
+
+	if c.userID == 0 {
+		return ErrUnauthorized
+	}
+
+	handle, ok := h.events[event.Type]
+	if !ok {
+		return ErrUnknownEventType
+	}
+
+	return handle(ctx, c, event.Body)
+}
+
+// authenticate binds the connection to the owner of the token, the client may resend a fresh token on the same connection.
+func (h *Handler) authenticate(ctx context.Context, c *Client, body json.RawMessage) error {
+	var token string
+	if err := json.Unmarshal(body, &token); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	identity, err := h.service.User.IdentifyConnection(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	h.hub.setSession(c, identity.SessionID)
+
+	// a refreshed token of the same user doesn't change the presence
+	if c.userID != identity.UserID {
+		if c.userID != 0 {
+			h.disconnect(c)
+		}
+
+		c.userID = identity.UserID
+		h.connect(c)
+	}
+
+	c.write(Event{Type: successConnectionEvent})
+
+	return nil
+}
+
+// connect registers the connection and tells everyone the user came online with the first one.
+func (h *Handler) connect(c *Client) {
+	h.hub.register(c)
+
+	cameOnline, err := h.service.Presence.Connect(context.Background(), c.userID)
+	if err != nil {
+		h.log.Info("connect: %s", err.Error())
+	}
+
+	if cameOnline {
+		h.hub.Broadcast(Event{Type: presenceEvent, Body: presenceResponse{
+			UserID:   c.userID,
+			Online:   true,
+			LastSeen: time.Now(),
+		}})
+	}
+}
+
+// disconnect unregisters the connection and tells everyone the user went offline with the last one.
+func (h *Handler) disconnect(c *Client) {
+	h.hub.unregister(c)
+
+	wentOffline, err := h.service.Presence.Disconnect(context.Background(), c.userID)
+	if err != nil {
+		h.log.Info("disconnect: %s", err.Error())
+	}
+
+	if wentOffline {
+		h.typing.stopAll(c.userID)
+		h.hub.Broadcast(Event{Type: presenceEvent, Body: presenceResponse{
+			UserID:   c.userID,
+			Online:   false,
+			LastSeen: time.Now(),
+		}})
+	}
+}
+
+func (h *Handler) pong(ctx context.Context, c *Client, body json.RawMessage) error {
+	c.pong()
+	return nil
+}
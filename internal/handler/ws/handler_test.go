@@ -0,0 +1,529 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/config"
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+	"real-time-forum/internal/service"
+	"real-time-forum/pkg/validator"
+)
+
+// fakeUsers knows the users and the tokens issued to them.
+type fakeUsers struct {
+	service.User
+	users  map[int]model.User
+	tokens map[string]int
+}
+
+func (f fakeUsers) GetByID(ctx context.Context, userID int) (model.User, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return model.User{}, service.ErrUserDoesNotExists
+	}
+	return user, nil
+}
+
+// IdentifyConnection issues every token in a session of its own, named after it.
+func (f fakeUsers) IdentifyConnection(ctx context.Context, token string) (service.Identity, error) {
+	userID, ok := f.tokens[token]
+	if !ok {
+		return service.Identity{}, service.ErrInvalidToken
+	}
+	return service.Identity{UserID: userID, SessionID: token}, nil
+}
+
+// fakeUserRepo backs the presence service, the users are never seen.
+type fakeUserRepo struct {
+	repository.User
+	users map[int]model.User
+}
+
+func (f fakeUserRepo) GetByID(ctx context.Context, userID int) (model.User, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return model.User{}, repository.ErrNoRows
+	}
+	return user, nil
+}
+
+func (f fakeUserRepo) UpdateLastSeen(ctx context.Context, userID int, lastSeen time.Time) error {
+	return nil
+}
+
+// fakeChat stores every message but the ones to users it doesn't know.
+type fakeChat struct {
+	service.Chat
+	users map[int]model.User
+}
+
+func (f fakeChat) SendMessage(ctx context.Context, message model.Message) (model.Message, error) {
+	if _, ok := f.users[message.RecipientID]; !ok {
+		return model.Message{}, service.ErrUnknownRecipient
+	}
+
+	message.ID = 1
+	return message, nil
+}
+
+// EditMessage keeps "hi bob" as the text of message 1 from alice to bob, the only one that can be edited.
+func (f fakeChat) EditMessage(ctx context.Context, userID int, messageID int, text string) (model.Message, error) {
+	switch {
+	case messageID != 1:
+		return model.Message{}, service.ErrEditExpired
+	case text == "hi bob":
+		return model.Message{}, service.ErrMessageUnchanged
+	}
+
+	return model.Message{ID: 1, SenderID: 1, RecipientID: 2, Message: text}, nil
+}
+
+func newTestHandler() *Handler {
+	users := map[int]model.User{
+		1: {ID: 1, Username: "alice", Email: "alice@example.com"},
+		2: {ID: 2, Username: "bob", Email: "bob@example.com"},
+	}
+
+	return NewHandler(&service.Service{
+		User: fakeUsers{
+			users:  users,
+			tokens: map[string]int{"alice-token": 1, "bob-token": 2},
+		},
+		Chat:     fakeChat{users: users},
+		Presence: service.NewPresence(fakeUserRepo{users: users}),
+	}, NewHub(), config.WS{})
+}
+
+// newTestClient is a connection without a socket, the events written to it stay in its queue.
+// The events of its own connection are taken from the queue.
+func newTestClient(h *Handler, userID int) *Client {
+	c := newClient(nil, newHeartbeat(config.WS{}))
+	if userID != 0 {
+		c.userID = userID
+		h.connect(c)
+		received(c)
+	}
+	return c
+}
+
+func event(t *testing.T, eventType string, body interface{}) rawEvent {
+	t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rawEvent{Type: eventType, Body: data}
+}
+
+// received takes the events queued for the client.
+func received(c *Client) []Event {
+	var events []Event
+	for {
+		select {
+		case e := <-c.send:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name       string
+		events     []rawEvent
+		wantErr    error
+		wantUserID int
+	}{
+		{"valid token", []rawEvent{{Type: tokenEvent, Body: json.RawMessage(`"alice-token"`)}}, nil, 1},
+		{"unknown token", []rawEvent{{Type: tokenEvent, Body: json.RawMessage(`"forged"`)}}, service.ErrInvalidToken, 0},
+		{"token not a string", []rawEvent{{Type: tokenEvent, Body: json.RawMessage(`42`)}}, ErrInvalidEventBody, 0},
+		{"event before token", []rawEvent{{Type: messageEvent, Body: json.RawMessage(`{}`)}}, ErrUnauthorized, 0},
+		{
+			name: "token of another user",
+			events: []rawEvent{
+				{Type: tokenEvent, Body: json.RawMessage(`"alice-token"`)},
+				{Type: tokenEvent, Body: json.RawMessage(`"bob-token"`)},
+			},
+			wantUserID: 2,
+		},
+		{
+			name: "unknown event",
+			events: []rawEvent{
+				{Type: tokenEvent, Body: json.RawMessage(`"alice-token"`)},
+				{Type: "dance", Body: json.RawMessage(`{}`)},
+			},
+			wantErr:    ErrUnknownEventType,
+			wantUserID: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			c := newTestClient(h, 0)
+
+			var err error
+			for _, e := range tt.events {
+				err = h.handleEvent(context.Background(), c, e)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("handleEvent() error = %v, want %v", err, tt.wantErr)
+			}
+			if c.userID != tt.wantUserID {
+				t.Errorf("userID = %d, want %d", c.userID, tt.wantUserID)
+			}
+
+			registered := 0
+			for userID, conns := range h.hub.clients {
+				if _, ok := conns[c]; ok {
+					registered = userID
+				}
+			}
+			if registered != tt.wantUserID {
+				t.Errorf("registered as %d, want %d", registered, tt.wantUserID)
+			}
+		})
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   messageInput
+		wantErr *errorResponse
+	}{
+		{"delivered", messageInput{RecipientID: 2, Message: "hi bob"}, nil},
+		{"longest message", messageInput{RecipientID: 2, Message: strings.Repeat("é", maxMessageLength)}, nil},
+		{"not stored", messageInput{RecipientID: 3, Message: "hi?"}, &errorResponse{
+			Error:  "invalid input",
+			Fields: validator.Errors{{Field: "recipientID", Message: service.ErrUnknownRecipient.Error()}},
+		}},
+		{"no recipient", messageInput{Message: "hi?"}, &errorResponse{
+			Error:  "invalid input",
+			Fields: validator.Errors{{Field: "recipientID", Message: "recipientID must be the id of a user"}},
+		}},
+		{"blank message", messageInput{RecipientID: 2, Message: " \n "}, &errorResponse{
+			Error:  "invalid input",
+			Fields: validator.Errors{{Field: "message", Message: "message is required"}},
+		}},
+		{"too long", messageInput{RecipientID: 2, Message: strings.Repeat("é", maxMessageLength+1)}, &errorResponse{
+			Error:  "invalid input",
+			Fields: validator.Errors{{Field: "message", Message: "message must be at most 2000 characters"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			alice, aliceTab, bob := newTestClient(h, 1), newTestClient(h, 1), newTestClient(h, 2)
+			received(alice)
+			received(aliceTab)
+
+			err := h.handleEvent(context.Background(), alice, event(t, messageEvent, tt.input))
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("handleEvent() error = %v", err)
+			}
+			if tt.wantErr != nil {
+				// the client gets the error event with the fields to show the problems next to
+				if got := newErrorResponse(err); err == nil || !reflect.DeepEqual(got, *tt.wantErr) {
+					t.Fatalf("error event body = %+v, want %+v", got, *tt.wantErr)
+				}
+			}
+
+			want := 0
+			if tt.wantErr == nil {
+				want = 1
+			}
+
+			// every tab of both users gets the message
+			for name, c := range map[string]*Client{"alice": alice, "alice's other tab": aliceTab, "bob": bob} {
+				events := received(c)
+				if len(events) != want {
+					t.Errorf("%s got %v, want %d message", name, events, want)
+					continue
+				}
+				if want == 1 {
+					message, ok := events[0].Body.(model.Message)
+					if events[0].Type != messageEvent || !ok || message.Message != tt.input.Message || message.SenderID != 1 {
+						t.Errorf("%s got %+v, want the message from alice", name, events[0])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEditMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      editMessageInput
+		wantErr    error
+		wantEdited bool
+	}{
+		{"edited", editMessageInput{MessageID: 1, Message: "hi bob!"}, nil, true},
+		{"unchanged", editMessageInput{MessageID: 1, Message: "hi bob"}, nil, false},
+		{"too late", editMessageInput{MessageID: 2, Message: "hi bob!"}, service.ErrEditExpired, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			alice, bob := newTestClient(h, 1), newTestClient(h, 2)
+			received(alice)
+
+			err := h.handleEvent(context.Background(), alice, event(t, editMessageEvent, tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("handleEvent() error = %v, want %v", err, tt.wantErr)
+			}
+
+			for name, c := range map[string]*Client{"alice": alice, "bob": bob} {
+				events := received(c)
+				edited := len(events) == 1 && events[0].Type == messageEditedEvent
+				if edited != tt.wantEdited || (!tt.wantEdited && len(events) != 0) {
+					t.Errorf("%s got %v, want the edit sent: %v", name, events, tt.wantEdited)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageInputs(t *testing.T) {
+	tooLong := strings.Repeat("a", maxMessageLength+1)
+
+	tests := []struct {
+		name       string
+		eventType  string
+		body       interface{}
+		wantFields validator.Errors
+	}{
+		{"edit", editMessageEvent, editMessageInput{MessageID: 1, Message: "fixed"}, nil},
+		{"edit without a message id", editMessageEvent, editMessageInput{Message: "fixed"},
+			validator.Errors{{Field: "messageID", Message: "messageID must be the id of a message"}}},
+		{"edit to a blank message", editMessageEvent, editMessageInput{MessageID: 1, Message: "  "},
+			validator.Errors{{Field: "message", Message: "message is required"}}},
+		{"edit to a long message", editMessageEvent, editMessageInput{MessageID: 1, Message: tooLong},
+			validator.Errors{{Field: "message", Message: "message must be at most 2000 characters"}}},
+		{"conversation message", conversationMessageEvent, conversationMessageInput{ConversationID: 1, Message: "hi all"}, nil},
+		{"conversation message without a conversation", conversationMessageEvent, conversationMessageInput{Message: "hi all"},
+			validator.Errors{{Field: "conversationID", Message: "conversationID must be the id of a conversation"}}},
+		{"long conversation message", conversationMessageEvent, conversationMessageInput{ConversationID: 1, Message: tooLong},
+			validator.Errors{{Field: "message", Message: "message must be at most 2000 characters"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var in input
+			switch tt.eventType {
+			case editMessageEvent:
+				in = &editMessageInput{}
+			default:
+				in = &conversationMessageInput{}
+			}
+
+			err := readInput(event(t, tt.eventType, tt.body).Body, in)
+
+			var fields validator.Errors
+			errors.As(err, &fields)
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("readInput() error = %v, want fields %v", err, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestNewErrorResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errorResponse
+	}{
+		{"event error", ErrUnknownEventType, errorResponse{Error: "unknown event type"}},
+		{"invalid fields", fieldError("message", service.ErrEmptyMessage), errorResponse{
+			Error:  "invalid input",
+			Fields: validator.Errors{{Field: "message", Message: "message is empty"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newErrorResponse(tt.err)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newErrorResponse() = %+v, want %+v", got, tt.want)
+			}
+
+			// the body has the shape of the error responses of the API
+			data, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want.Fields == nil && string(data) != `{"error":"unknown event type"}` {
+				t.Errorf("marshaled to %s, want the fields left out", data)
+			}
+		})
+	}
+}
+
+func TestGetOnlineUsersHidesPrivateFields(t *testing.T) {
+	h := newTestHandler()
+	alice := newTestClient(h, 1)
+	newTestClient(h, 2)
+	received(alice)
+
+	if err := h.handleEvent(context.Background(), alice, rawEvent{Type: onlineUsersRequestEvent}); err != nil {
+		t.Fatalf("handleEvent() error = %v", err)
+	}
+
+	events := received(alice)
+	if len(events) != 1 {
+		t.Fatalf("got %v, want the online users", events)
+	}
+
+	data, err := json.Marshal(events[0].Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var users []map[string]interface{}
+	if err := json.Unmarshal(data, &users); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("got %d users, want 2", len(users))
+	}
+	for _, user := range users {
+		if _, ok := user["email"]; ok {
+			t.Errorf("online user %v has the email", user)
+		}
+	}
+}
+
+func TestPresenceEvents(t *testing.T) {
+	h := newTestHandler()
+	bob := newTestClient(h, 2)
+	alice := newTestClient(h, 0)
+	received(bob)
+
+	// the steps are applied in order, each one sees the connections before it
+	tests := []struct {
+		name       string
+		step       func()
+		wantOnline []bool
+	}{
+		{"first tab", func() { h.authenticate(context.Background(), alice, json.RawMessage(`"alice-token"`)) }, []bool{true}},
+		{"same token again", func() { h.authenticate(context.Background(), alice, json.RawMessage(`"alice-token"`)) }, nil},
+		{"second tab", func() { newTestClient(h, 1) }, nil},
+		{"first tab closed", func() { h.disconnect(alice) }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.step()
+
+			var online []bool
+			for _, e := range received(bob) {
+				if e.Type == presenceEvent {
+					presence := e.Body.(presenceResponse)
+					if presence.UserID != 1 {
+						t.Errorf("presence of user %d, want alice", presence.UserID)
+					}
+					online = append(online, presence.Online)
+				}
+			}
+
+			if len(online) != len(tt.wantOnline) || (len(online) == 1 && online[0] != tt.wantOnline[0]) {
+				t.Errorf("presence events = %v, want %v", online, tt.wantOnline)
+			}
+		})
+	}
+
+	// closing the last tab takes alice offline
+	for c := range h.hub.clients[1] {
+		h.disconnect(c)
+	}
+
+	events := received(bob)
+	if len(events) != 1 || events[0].Type != presenceEvent || events[0].Body.(presenceResponse).Online {
+		t.Errorf("events after the last tab closed = %v, want alice offline", events)
+	}
+}
+
+func TestTypingIn(t *testing.T) {
+	tests := []struct {
+		name        string
+		recipientID int
+		wantErr     error
+		wantTyping  int
+	}{
+		{"partner", 2, nil, 1},
+		{"self", 1, ErrInvalidEventBody, 0},
+		{"no recipient", 0, ErrInvalidEventBody, 0},
+		{"unknown user", 3, service.ErrUnknownRecipient, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			alice, bob := newTestClient(h, 1), newTestClient(h, 2)
+			received(alice)
+
+			err := h.handleEvent(context.Background(), alice, event(t, typingInRequestEvent, typingInInput{RecipientID: tt.recipientID}))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("handleEvent() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := len(typingEvents(bob)); got != tt.wantTyping {
+				t.Errorf("bob got %d typing events, want %d", got, tt.wantTyping)
+			}
+			if got := len(typingEvents(alice)); got != 0 {
+				t.Errorf("alice got %d typing events, want none", got)
+			}
+		})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		topic     string
+		wantErr   error
+		wantTopic bool
+	}{
+		{"category", subscribeEvent, "category:2", nil, true},
+		{"post", subscribeEvent, "post:11", nil, true},
+		{"unknown kind", subscribeEvent, "user:1", ErrUnknownTopic, false},
+		{"zero id", subscribeEvent, "post:0", ErrUnknownTopic, false},
+		{"no id", subscribeEvent, "post:", ErrUnknownTopic, false},
+		{"unsubscribe", unsubscribeEvent, "post:10", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler()
+			alice := newTestClient(h, 1)
+			// already following the post
+			h.hub.subscribe(alice, "post:10")
+
+			err := h.handleEvent(context.Background(), alice, event(t, tt.eventType, subscriptionInput{Topic: tt.topic}))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("handleEvent() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if _, ok := alice.topics[tt.topic]; ok != tt.wantTopic {
+				t.Errorf("subscribed to %s: %v, want %v", tt.topic, ok, tt.wantTopic)
+			}
+		})
+	}
+}
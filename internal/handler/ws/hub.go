@@ -0,0 +1,168 @@
+package ws
+
+import "sync"
+
+// Hub keeps track of every authenticated connection, a user may be connected from several tabs at once.
+// Connections also subscribe to feed topics, the hub publishes feed events of the services to them.
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[int]map[*Client]struct{}
+	topics  map[string]map[*Client]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients: make(map[int]map[*Client]struct{}),
+		topics:  make(map[string]map[*Client]struct{}),
+	}
+}
+
+func (h *Hub) register(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c.userID]; !ok {
+		h.clients[c.userID] = make(map[*Client]struct{})
+	}
+
+	h.clients[c.userID][c] = struct{}{}
+}
+
+func (h *Hub) unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	conns, ok := h.clients[c.userID]
+	if !ok {
+		return
+	}
+
+	delete(conns, c)
+	if len(conns) == 0 {
+		delete(h.clients, c.userID)
+	}
+}
+
+// setSession records the session the token of the connection was issued in.
+func (h *Hub) setSession(c *Client, sessionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c.sessionID = sessionID
+}
+
+// CloseSession closes the connections of the user authenticated in the session.
+func (h *Hub) CloseSession(userID int, sessionID string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for c := range h.clients[userID] {
+		if c.sessionID == sessionID {
+			c.close()
+		}
+	}
+}
+
+// CloseOtherSessions closes the connections of the user authenticated in any session but the one given.
+func (h *Hub) CloseOtherSessions(userID int, sessionID string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for c := range h.clients[userID] {
+		if c.sessionID != sessionID {
+			c.close()
+		}
+	}
+}
+
+// SendToUser delivers the event to every open connection of the user.
+func (h *Hub) SendToUser(userID int, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for c := range h.clients[userID] {
+		c.write(event)
+	}
+}
+
+// Broadcast delivers the event to every authenticated connection.
+func (h *Hub) Broadcast(event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, conns := range h.clients {
+		for c := range conns {
+			c.write(event)
+		}
+	}
+}
+
+func (h *Hub) subscribe(c *Client, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.topics[topic]; !ok {
+		h.topics[topic] = make(map[*Client]struct{})
+	}
+
+	h.topics[topic][c] = struct{}{}
+	c.topics[topic] = struct{}{}
+}
+
+func (h *Hub) unsubscribe(c *Client, topic string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.removeSubscription(c, topic)
+}
+
+func (h *Hub) unsubscribeAll(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for topic := range c.topics {
+		h.removeSubscription(c, topic)
+	}
+}
+
+func (h *Hub) removeSubscription(c *Client, topic string) {
+	delete(c.topics, topic)
+
+	subscribers, ok := h.topics[topic]
+	if !ok {
+		return
+	}
+
+	delete(subscribers, c)
+	if len(subscribers) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+// Publish delivers the event once to every connection subscribed to any of the topics.
+func (h *Hub) Publish(topics []string, eventType string, body interface{}) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	event := Event{Type: eventType, Body: body}
+	sent := make(map[*Client]bool)
+
+	for _, topic := range topics {
+		for c := range h.topics[topic] {
+			if sent[c] {
+				continue
+			}
+
+			sent[c] = true
+			c.write(event)
+		}
+	}
+}
+
+func (h *Hub) PublishToUsers(userIDs []int, eventType string, body interface{}) {
+	event := Event{Type: eventType, Body: body}
+
+	for _, userID := range userIDs {
+		h.SendToUser(userID, event)
+	}
+}
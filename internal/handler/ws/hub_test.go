@@ -0,0 +1,164 @@
+package ws
+
+import "testing"
+
+func TestHub(t *testing.T) {
+	h := NewHub()
+
+	alice, aliceTab, bob := &Client{userID: 1, send: make(chan Event, 1), done: make(chan struct{})},
+		&Client{userID: 1, send: make(chan Event, 1), done: make(chan struct{})},
+		&Client{userID: 2, send: make(chan Event, 1), done: make(chan struct{})}
+
+	for _, c := range []*Client{alice, aliceTab, bob} {
+		h.register(c)
+	}
+
+	tests := []struct {
+		name       string
+		unregister *Client
+		sendTo     int
+		wantGot    map[*Client]bool
+	}{
+		{"every tab of the user", nil, 1, map[*Client]bool{alice: true, aliceTab: true}},
+		{"other user", nil, 2, map[*Client]bool{bob: true}},
+		{"user not connected", nil, 3, map[*Client]bool{}},
+		{"everyone", nil, 0, map[*Client]bool{alice: true, aliceTab: true, bob: true}},
+		{"closed tab", aliceTab, 1, map[*Client]bool{alice: true}},
+		{"last connection", alice, 0, map[*Client]bool{bob: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.unregister != nil {
+				h.unregister(tt.unregister)
+			}
+
+			// user 0 is everyone
+			if tt.sendTo == 0 {
+				h.Broadcast(Event{Type: messageEvent})
+			} else {
+				h.SendToUser(tt.sendTo, Event{Type: messageEvent})
+			}
+
+			for _, c := range []*Client{alice, aliceTab, bob} {
+				got := len(received(c)) == 1
+				if got != tt.wantGot[c] {
+					t.Errorf("client of user %d got the event: %v, want %v", c.userID, got, tt.wantGot[c])
+				}
+			}
+
+		})
+	}
+}
+
+func TestCloseSessions(t *testing.T) {
+	newClient := func(userID int, sessionID string) *Client {
+		return &Client{userID: userID, sessionID: sessionID, send: make(chan Event, 1), done: make(chan struct{})}
+	}
+
+	tests := []struct {
+		name       string
+		close      func(h *Hub)
+		wantClosed []string
+	}{
+		{"session", func(h *Hub) { h.CloseSession(1, "laptop") }, []string{"alice laptop", "alice laptop tab"}},
+		{"other sessions", func(h *Hub) { h.CloseOtherSessions(1, "laptop") }, []string{"alice phone"}},
+		{"session of another user", func(h *Hub) { h.CloseSession(2, "laptop") }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHub()
+			clients := map[string]*Client{
+				"alice laptop":     newClient(1, "laptop"),
+				"alice laptop tab": newClient(1, "laptop"),
+				"alice phone":      newClient(1, "phone"),
+				"bob phone":        newClient(2, "phone"),
+			}
+			for _, c := range clients {
+				h.register(c)
+			}
+
+			tt.close(h)
+
+			wantClosed := make(map[string]bool)
+			for _, name := range tt.wantClosed {
+				wantClosed[name] = true
+			}
+
+			for name, c := range clients {
+				closed := false
+				select {
+				case <-c.done:
+					closed = true
+				default:
+				}
+				if closed != wantClosed[name] {
+					t.Errorf("%s closed: %v, want %v", name, closed, wantClosed[name])
+				}
+			}
+		})
+	}
+}
+
+func TestWriteDropsSlowClient(t *testing.T) {
+	c := &Client{send: make(chan Event, 1), done: make(chan struct{})}
+
+	c.write(Event{Type: messageEvent})
+	c.write(Event{Type: messageEvent})
+
+	select {
+	case <-c.done:
+	default:
+		t.Error("client with a full queue is still open")
+	}
+
+	// writes after the close are dropped
+	c.write(Event{Type: messageEvent})
+}
+
+func TestPublish(t *testing.T) {
+	h := NewHub()
+	h.register(&Client{userID: 1, send: make(chan Event, 1), done: make(chan struct{})})
+
+	newSubscriber := func(topics ...string) *Client {
+		c := &Client{send: make(chan Event, 4), done: make(chan struct{}), topics: make(map[string]struct{})}
+		for _, topic := range topics {
+			h.subscribe(c, topic)
+		}
+		return c
+	}
+
+	post, category, both, gone, none := newSubscriber("post:1"), newSubscriber("category:2"),
+		newSubscriber("post:1", "category:2"), newSubscriber("post:1", "category:2"), newSubscriber()
+	h.unsubscribeAll(gone)
+	h.subscribe(none, "post:1")
+	h.unsubscribe(none, "post:1")
+
+	tests := []struct {
+		name    string
+		topics  []string
+		wantGot map[*Client]int
+	}{
+		{"post", []string{"post:1"}, map[*Client]int{post: 1, both: 1}},
+		{"category", []string{"category:2"}, map[*Client]int{category: 1, both: 1}},
+		{"once for several topics", []string{"post:1", "category:1", "category:2"}, map[*Client]int{post: 1, category: 1, both: 1}},
+		{"no subscribers", []string{"post:2"}, map[*Client]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h.Publish(tt.topics, messageEvent, nil)
+
+			for name, c := range map[string]*Client{"post": post, "category": category, "both": both, "unsubscribed": gone, "resubscribed": none} {
+				if got := len(received(c)); got != tt.wantGot[c] {
+					t.Errorf("%s subscriber got %d events, want %d", name, got, tt.wantGot[c])
+				}
+			}
+		})
+	}
+
+	if len(h.topics["post:1"]) != 2 || len(gone.topics) != 0 {
+		t.Errorf("subscribers of post:1 = %d, topics of the closed connection = %v, want 2 and none", len(h.topics["post:1"]), gone.topics)
+	}
+}
@@ -0,0 +1,63 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+
+	"real-time-forum/internal/service"
+)
+
+// reactMessage adds the reaction, the service sends the new counts to both parties.
+func (h *Handler) reactMessage(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messageReactionInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	_, err := h.service.Reaction.React(ctx, c.userID, service.MessageTarget, input.MessageID, input.Emoji)
+
+	return err
+}
+
+func (h *Handler) unreactMessage(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messageReactionInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	_, err := h.service.Reaction.Unreact(ctx, c.userID, service.MessageTarget, input.MessageID, input.Emoji)
+
+	return err
+}
+
+func (h *Handler) getMessageReactions(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messageIDInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	reactions, err := h.service.Reaction.GetReactions(ctx, c.userID, service.MessageTarget, input.MessageID)
+	if err != nil {
+		return err
+	}
+
+	c.write(Event{Type: messageReactionsResponseEvent, Body: reactions})
+
+	return nil
+}
+
+func (h *Handler) getMessageReactionUsers(ctx context.Context, c *Client, body json.RawMessage) error {
+	var input messageReactionInput
+	if err := json.Unmarshal(body, &input); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	users, err := h.service.Reaction.GetReactionUsers(ctx, c.userID, service.MessageTarget, input.MessageID, input.Emoji)
+	if err != nil {
+		return err
+	}
+
+	c.write(Event{Type: messageReactionUsersResponseEvent, Body: users})
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package ws
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// a burst of typing events is forwarded at most once per typingThrottle
+	typingThrottle = 2 * time.Second
+	// the partner is told typing stopped when no typing event came for typingTimeout
+	typingTimeout = 5 * time.Second
+)
+
+type typingKey struct {
+	senderID    int
+	recipientID int
+}
+
+type typingState struct {
+	lastSent time.Time
+	timer    *time.Timer
+	// generation tells a timer that fired while being replaced from the current one
+	generation int
+}
+
+// typingTracker relays typing state of a sender to the conversation partner only.
+type typingTracker struct {
+	hub      *Hub
+	throttle time.Duration
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	typing map[typingKey]*typingState
+}
+
+func newTypingTracker(hub *Hub) *typingTracker {
+	return &typingTracker{
+		hub:      hub,
+		throttle: typingThrottle,
+		timeout:  typingTimeout,
+		typing:   make(map[typingKey]*typingState),
+	}
+}
+
+// start notifies the recipient unless it was notified recently and postpones the expiry.
+func (t *typingTracker) start(senderID int, recipientID int) {
+	key := typingKey{senderID: senderID, recipientID: recipientID}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.typing[key]
+	if !ok {
+		state = &typingState{}
+		t.typing[key] = state
+	}
+
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+
+	state.generation++
+	generation := state.generation
+	state.timer = time.AfterFunc(t.timeout, func() {
+		t.expire(key, state, generation)
+	})
+
+	if time.Since(state.lastSent) < t.throttle {
+		return
+	}
+
+	state.lastSent = time.Now()
+	t.send(key, true)
+}
+
+// stop notifies the recipient that the sender stopped typing, if it was told otherwise.
+func (t *typingTracker) stop(senderID int, recipientID int) {
+	key := typingKey{senderID: senderID, recipientID: recipientID}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.typing[key]
+	if !ok {
+		return
+	}
+
+	state.timer.Stop()
+	delete(t.typing, key)
+	t.send(key, false)
+}
+
+// stopAll stops every conversation the sender is typing in, used when the sender goes offline.
+func (t *typingTracker) stopAll(senderID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, state := range t.typing {
+		if key.senderID != senderID {
+			continue
+		}
+
+		state.timer.Stop()
+		delete(t.typing, key)
+		t.send(key, false)
+	}
+}
+
+func (t *typingTracker) expire(key typingKey, state *typingState, generation int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the state was stopped or postponed after the timer fired
+	if t.typing[key] != state || state.generation != generation {
+		return
+	}
+
+	delete(t.typing, key)
+	t.send(key, false)
+}
+
+func (t *typingTracker) send(key typingKey, typing bool) {
+	t.hub.SendToUser(key.recipientID, Event{
+		Type: typingInResponseEvent,
+		Body: typingInResponse{
+			SenderID:    key.senderID,
+			RecipientID: key.recipientID,
+			Typing:      typing,
+		},
+	})
+}
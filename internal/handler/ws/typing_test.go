@@ -0,0 +1,121 @@
+package ws
+
+import (
+	"testing"
+	"time"
+)
+
+// typingEvents takes the typing states queued for the client.
+func typingEvents(c *Client) []bool {
+	var typing []bool
+	for _, e := range received(c) {
+		if e.Type == typingInResponseEvent {
+			typing = append(typing, e.Body.(typingInResponse).Typing)
+		}
+	}
+	return typing
+}
+
+func TestTypingTracker(t *testing.T) {
+	const (
+		throttle = 40 * time.Millisecond
+		timeout  = 150 * time.Millisecond
+	)
+
+	tests := []struct {
+		name  string
+		steps func(tracker *typingTracker)
+		wait  time.Duration
+		want  []bool
+	}{
+		{
+			name:  "started",
+			steps: func(tracker *typingTracker) { tracker.start(1, 2) },
+			want:  []bool{true},
+		},
+		{
+			name: "burst is throttled",
+			steps: func(tracker *typingTracker) {
+				tracker.start(1, 2)
+				tracker.start(1, 2)
+				tracker.start(1, 2)
+			},
+			want: []bool{true},
+		},
+		{
+			name: "sent again after the throttle",
+			steps: func(tracker *typingTracker) {
+				tracker.start(1, 2)
+				time.Sleep(throttle + 10*time.Millisecond)
+				tracker.start(1, 2)
+			},
+			want: []bool{true, true},
+		},
+		{
+			name:  "expired",
+			steps: func(tracker *typingTracker) { tracker.start(1, 2) },
+			wait:  timeout + 50*time.Millisecond,
+			want:  []bool{true, false},
+		},
+		{
+			name: "typing postpones the expiry",
+			steps: func(tracker *typingTracker) {
+				tracker.start(1, 2)
+				time.Sleep(timeout * 2 / 3)
+				tracker.start(1, 2)
+				time.Sleep(timeout * 2 / 3)
+			},
+			want: []bool{true, true},
+		},
+		{
+			name: "stopped",
+			steps: func(tracker *typingTracker) {
+				tracker.start(1, 2)
+				tracker.stop(1, 2)
+			},
+			wait: timeout + 50*time.Millisecond,
+			want: []bool{true, false},
+		},
+		{
+			name:  "stop without typing",
+			steps: func(tracker *typingTracker) { tracker.stop(1, 2) },
+			want:  nil,
+		},
+		{
+			name: "sender went offline",
+			steps: func(tracker *typingTracker) {
+				tracker.start(1, 2)
+				tracker.start(3, 2)
+				tracker.stopAll(1)
+			},
+			want: []bool{true, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hub := NewHub()
+			recipient := &Client{userID: 2, send: make(chan Event, 8), done: make(chan struct{})}
+			hub.register(recipient)
+
+			tracker := newTypingTracker(hub)
+			tracker.throttle, tracker.timeout = throttle, timeout
+
+			tt.steps(tracker)
+			time.Sleep(tt.wait)
+
+			got := typingEvents(recipient)
+			if len(got) != len(tt.want) {
+				t.Fatalf("typing events = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("typing events = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+
+	"real-time-forum/pkg/validator"
+)
+
+// maxMessageLength is the limit of comments, a message can't be longer than a reply under a post.
+const maxMessageLength = 2000
+
+var errInvalidInput = errors.New("invalid input")
+
+// errorResponse is the body of the error event, the same as the body of the error responses
+// of the API, Fields tells which fields of the event body caused the error and why.
+type errorResponse struct {
+	Error  string           `json:"error"`
+	Fields validator.Errors `json:"fields,omitempty"`
+}
+
+// input is an event body checking its own fields.
+type input interface {
+	validate(v *validator.Validator)
+}
+
+// readInput decodes the body into in and validates it, invalid fields are returned as validator.Errors.
+func readInput(body json.RawMessage, in input) error {
+	if err := json.Unmarshal(body, in); err != nil {
+		return ErrInvalidEventBody
+	}
+
+	v := validator.New()
+	in.validate(v)
+
+	return v.Err()
+}
+
+// fieldError is an error of the service caused by the value of one field.
+func fieldError(field string, err error) error {
+	return validator.Errors{{Field: field, Message: err.Error()}}
+}
+
+func newErrorResponse(err error) errorResponse {
+	var fields validator.Errors
+	if errors.As(err, &fields) {
+		return errorResponse{Error: errInvalidInput.Error(), Fields: fields}
+	}
+
+	return errorResponse{Error: err.Error()}
+}
+
+func checkMessage(v *validator.Validator, message string) {
+	v.Check(validator.NotBlank(message), "message", "message is required")
+	v.Check(validator.Length(message, 0, maxMessageLength), "message", "message must be at most 2000 characters")
+}
+
+func (i messageInput) validate(v *validator.Validator) {
+	v.Check(validator.Positive(i.RecipientID), "recipientID", "recipientID must be the id of a user")
+	checkMessage(v, i.Message)
+}
+
+func (i editMessageInput) validate(v *validator.Validator) {
+	v.Check(validator.Positive(i.MessageID), "messageID", "messageID must be the id of a message")
+	checkMessage(v, i.Message)
+}
+
+func (i conversationMessageInput) validate(v *validator.Validator) {
+	v.Check(validator.Positive(i.ConversationID), "conversationID", "conversationID must be the id of a conversation")
+	checkMessage(v, i.Message)
+}
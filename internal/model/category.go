@@ -1,7 +1,10 @@
 package model
 
 type Category struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Posts []Post `json:"posts"`
+	ID           int         `json:"id"`
+	Name         string      `json:"name"`
+	Position     int         `json:"position"`
+	Archived     bool        `json:"archived"`
+	PostsCount   int         `json:"postsCount"`
+	LastActivity interface{} `json:"lastActivity"`
 }
@@ -4,9 +4,16 @@ type Comment struct {
 	ID           int         `json:"id"`
 	Author       User        `json:"author"`
 	PostID       int         `json:"postID"`
-	Content      string      `json:"content"`
-	ImagePath    string      `json:"image_path"`
-	CreationTime interface{} `json:"creation_time"`
+	Content      string      `json:"data"`
+	ImagePath    string      `json:"image"`
+	CreationTime interface{} `json:"date"`
 	UserRate     int         `json:"userRate"`
 	Rating       int         `json:"rating"`
+	ParentID     int         `json:"parentID"` // 0 for a top-level comment
+	Depth        int         `json:"depth"`
+	RepliesCount int         `json:"repliesCount"`
+	Replies      []Comment   `json:"replies,omitempty"`
+	// MoreReplies is the cursor of the replies after the loaded ones, read from /api/comments/:id/replies
+	// while RepliesCount is above the number of Replies. It's empty when none of them are loaded.
+	MoreReplies string `json:"moreReplies,omitempty"`
 }
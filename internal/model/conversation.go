@@ -0,0 +1,34 @@
+package model
+
+// Conversation is a group chat, every member sees the messages of the others.
+type Conversation struct {
+	ID                  int                  `json:"id"`
+	Title               string               `json:"title"`
+	OwnerID             int                  `json:"ownerID"`
+	CreationTime        interface{}          `json:"date"`
+	Members             []ConversationMember `json:"members"`
+	LastMessage         ConversationMessage  `json:"lastMessage"`
+	UnreadMessagesCount int                  `json:"unreadMessagesCount"`
+}
+
+// ConversationMember is a member and the last message the member has read.
+type ConversationMember struct {
+	User              User        `json:"user"`
+	JoinedAt          interface{} `json:"joinedAt"`
+	LastReadMessageID int         `json:"lastReadMessageID"`
+}
+
+type ConversationMessage struct {
+	ID             int         `json:"id"`
+	ConversationID int         `json:"conversationID"`
+	SenderID       int         `json:"senderID"`
+	Message        string      `json:"message"`
+	CreationTime   interface{} `json:"date"`
+}
+
+// ConversationRead tells the members how far a member has read the conversation.
+type ConversationRead struct {
+	ConversationID    int `json:"conversationID"`
+	UserID            int `json:"userID"`
+	LastReadMessageID int `json:"lastReadMessageID"`
+}
@@ -2,9 +2,28 @@ package model
 
 type Message struct {
 	ID           int         `json:"id"`
-	SenderID     int         `json:"sender_id"`
-	RecipientID  int         `json:"recipient_id"`
+	SenderID     int         `json:"senderID"`
+	RecipientID  int         `json:"recipientID"`
 	Message      string      `json:"message"`
-	CreationTime interface{} `json:"creation_time"`
-	Readed       bool        `json:"readed"`
+	CreationTime interface{} `json:"date"`
+	Readed       bool        `json:"read"`
+	EditedAt     interface{} `json:"editedAt"`
+	DeletedAt    interface{} `json:"deletedAt"`
+}
+
+// MessageEdit is a previous version of an edited message, replaced at EditedAt.
+type MessageEdit struct {
+	ID        int         `json:"id"`
+	MessageID int         `json:"messageID"`
+	Message   string      `json:"message"`
+	EditedAt  interface{} `json:"editedAt"`
+}
+
+// ReadReceipt tells that messages of the sender up to MessageID were read by the recipient.
+type ReadReceipt struct {
+	MessageID           int `json:"id"`
+	SenderID            int `json:"senderID"`
+	RecipientID         int `json:"recipientID"`
+	ReadCount           int `json:"readCount"`
+	UnreadMessagesCount int `json:"unreadMessagesCount"`
 }
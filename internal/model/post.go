@@ -1,14 +1,18 @@
 package model
 
 type Post struct {
-	ID           int         `json:"id"`
-	Author       User        `json:"author"`
-	Title        string      `json:"title"`
-	Content      string      `json:"content"`
-	CreationTime interface{} `json:"creation_time"`
-	ImagePath    string      `json:"image_path"`
-	Categories   []Category  `json:"categories"`
-	Comments     []Comment   `json:"comments"`
-	Rating       int         `json:"rating"`
-	UserRate     int         `json:"user_rate"`
+	ID            int         `json:"id"`
+	Author        User        `json:"author"`
+	Title         string      `json:"title"`
+	Content       string      `json:"data"`
+	CreationTime  interface{} `json:"date"`
+	ImagePath     string      `json:"image"`
+	Images        []string    `json:"images,omitempty"`
+	Categories    []Category  `json:"categories"`
+	Comments      []Comment   `json:"comments"`
+	Rating        int         `json:"rating"`
+	CommentsCount int         `json:"commentsCount"`
+	UserRate      int         `json:"userRate"`
+	// SortKey is the position of the post in the feed it was read from
+	SortKey float64 `json:"-"`
 }
@@ -0,0 +1,22 @@
+package model
+
+type Reaction struct {
+	UserID   int
+	TargetID int
+	Emoji    string
+}
+
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// Reactions are the aggregated reactions of a post, a comment or a message.
+// UserReactions are the emoji of the requesting user, they are left out of the broadcasts.
+type Reactions struct {
+	Target        string          `json:"target"`
+	TargetID      int             `json:"targetID"`
+	PostID        int             `json:"postID,omitempty"`
+	Reactions     []ReactionCount `json:"reactions"`
+	UserReactions []string        `json:"userReactions,omitempty"`
+}
@@ -0,0 +1,18 @@
+package model
+
+const (
+	SearchPost    = "post"
+	SearchComment = "comment"
+)
+
+// SearchResult is a post or a comment matching the query. Title and Snippet are
+// escaped HTML with the matched terms wrapped in <mark>.
+type SearchResult struct {
+	Type         string      `json:"type"`
+	ID           int         `json:"id"`
+	PostID       int         `json:"postID"`
+	Title        string      `json:"title"`
+	Snippet      string      `json:"snippet"`
+	Author       User        `json:"author"`
+	CreationTime interface{} `json:"date"`
+}
@@ -2,8 +2,11 @@ package model
 
 import "time"
 
+// Session is a single refresh token, tokens issued by rotation of one sign in share the same family.
 type Session struct {
 	UserID    int       `json:"user_id"`
 	Token     string    `json:"token"`
+	Family    string    `json:"family"`
+	Used      bool      `json:"used"`
 	ExpiresAt time.Time `json:"expiresAt"`
 }
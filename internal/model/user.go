@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type User struct {
 	ID           int         `json:"id"`
 	Email        string      `json:"email"`
@@ -11,4 +13,44 @@ type User struct {
 	Gender       string      `json:"gender"`
 	CreationTime interface{} `json:"registered"`
 	Avatar       string      `json:"avatar"`
+	Role         string      `json:"role"`
+	LastSeen     interface{} `json:"lastSeen"`
+	Online       bool        `json:"online"`
+	PendingEmail string      `json:"pendingEmail,omitempty"` // waiting for verification, shown to the user only
+}
+
+// EmailVerification is a change of the email waiting for the link sent to the new address to be opened.
+type EmailVerification struct {
+	UserID    int
+	Email     string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// PublicUser is the part of a user anyone can see, the email, age, gender and role are shown to the user only.
+type PublicUser struct {
+	ID        int         `json:"id"`
+	Username  string      `json:"username"`
+	FirstName string      `json:"firstName"`
+	LastName  string      `json:"lastName"`
+	Avatar    string      `json:"avatar"`
+	LastSeen  interface{} `json:"lastSeen"`
+	Online    bool        `json:"online"`
+}
+
+func (u User) Public() PublicUser {
+	return PublicUser{
+		ID:        u.ID,
+		Username:  u.Username,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Avatar:    u.Avatar,
+		LastSeen:  u.LastSeen,
+		Online:    u.Online,
+	}
 }
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
@@ -1,15 +1,21 @@
 package model
 
-type PostVotes struct {
-	ID     int
-	UserID int
-	PostID int
-	Vote   int
+type Vote struct {
+	UserID   int
+	TargetID int
+	Vote     int
 }
 
-type CommentVotes struct {
-	ID        int
-	UserID    int
-	CommentID int
-	Vote      int
+// VoteResult is the state of the votes of a post or a comment after the vote.
+type VoteResult struct {
+	Rating   int `json:"rating"`
+	UserRate int `json:"userRate"`
+}
+
+// ScoreChange is the new rating of a post or a comment pushed to the feed.
+type ScoreChange struct {
+	Target   string `json:"target"`
+	TargetID int    `json:"targetID"`
+	PostID   int    `json:"postID"`
+	Rating   int    `json:"rating"`
 }
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+type Category interface {
+	Create(ctx context.Context, name string) (int, error)
+	GetByID(ctx context.Context, categoryID int) (model.Category, error)
+	GetAll(ctx context.Context, withArchived bool) ([]model.Category, error)
+	Rename(ctx context.Context, categoryID int, name string) error
+	SetArchived(ctx context.Context, categoryID int, archived bool) error
+	Reorder(ctx context.Context, categoryIDs []int) error
+}
+
+type CategoryRepository struct {
+	db *sql.DB
+}
+
+func NewCategory(db *sql.DB) *CategoryRepository {
+	return &CategoryRepository{
+		db: db,
+	}
+}
+
+// Create appends the category to the end of the list.
+func (r *CategoryRepository) Create(ctx context.Context, name string) (int, error) {
+	var id int
+
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO
+			category (name, position)
+		VALUES
+			($1, (SELECT IFNULL(MAX(position), 0) + 1 FROM category))
+		RETURNING id;`,
+		name,
+	).Scan(&id)
+	if err != nil {
+		if isAlreadyExists(err) {
+			return 0, ErrCategoryExists
+		}
+		return 0, fmt.Errorf("repo: create category: %w", err)
+	}
+
+	return id, nil
+}
+
+// the stats are aggregated over the posts of the category and the comments under them,
+// a query selecting categoryColumns reads from categoryTables grouped by category.id.
+// The latest activity is the latest post or comment, a comment can't be older than its post
+// so the latest post is the fallback
+const categoryColumns = `
+	category.id,
+	category.name,
+	category.position,
+	category.archived,
+	COUNT(DISTINCT post_category.post_id) AS posts_count,
+	MAX(IFNULL(MAX(comment.creation_time), MAX(post.creation_time)), MAX(post.creation_time)) AS last_activity`
+
+const categoryTables = `
+	category
+	LEFT JOIN post_category ON post_category.category_id = category.id
+	LEFT JOIN post ON post.id = post_category.post_id
+	LEFT JOIN comment ON comment.post_id = post.id`
+
+func scanCategory(row interface{ Scan(...interface{}) error }) (model.Category, error) {
+	var (
+		category     model.Category
+		lastActivity sql.NullString
+	)
+
+	err := row.Scan(
+		&category.ID,
+		&category.Name,
+		&category.Position,
+		&category.Archived,
+		&category.PostsCount,
+		&lastActivity,
+	)
+	if err != nil {
+		return model.Category{}, err
+	}
+
+	// aggregates lose the DATETIME type, so the time comes back as text
+	if lastActivity.Valid {
+		t, err := time.Parse(sqliteTimeLayout, lastActivity.String)
+		if err != nil {
+			return model.Category{}, err
+		}
+
+		category.LastActivity = t
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, categoryID int) (model.Category, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT`+categoryColumns+`
+		FROM`+categoryTables+`
+		WHERE
+			category.id = $1
+		GROUP BY
+			category.id;`,
+		categoryID,
+	)
+
+	category, err := scanCategory(row)
+	if err != nil {
+		if isNoRowsError(err) {
+			return model.Category{}, ErrNoRows
+		}
+		return model.Category{}, fmt.Errorf("repo: get category: %w", err)
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepository) GetAll(ctx context.Context, withArchived bool) ([]model.Category, error) {
+	var categories []model.Category
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT`+categoryColumns+`
+		FROM`+categoryTables+`
+		WHERE
+			$1 OR NOT category.archived
+		GROUP BY
+			category.id
+		ORDER BY
+			category.position, category.id;`,
+		withArchived,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get categories: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repo: get categories: %w", err)
+		}
+
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+func (r *CategoryRepository) Rename(ctx context.Context, categoryID int, name string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			category
+		SET
+			name = $1
+		WHERE
+			id = $2;`,
+		name, categoryID,
+	)
+	if err != nil {
+		if isAlreadyExists(err) {
+			return ErrCategoryExists
+		}
+		return fmt.Errorf("repo: rename category: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo: rename category: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *CategoryRepository) SetArchived(ctx context.Context, categoryID int, archived bool) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			category
+		SET
+			archived = $1
+		WHERE
+			id = $2;`,
+		archived, categoryID,
+	)
+	if err != nil {
+		return fmt.Errorf("repo: archive category: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo: archive category: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	return nil
+}
+
+// Reorder sets positions of the categories by their index in the list, starting from 1.
+func (r *CategoryRepository) Reorder(ctx context.Context, categoryIDs []int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repo: reorder categories: %w", err)
+	}
+
+	for i, id := range categoryIDs {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE
+				category
+			SET
+				position = $1
+			WHERE
+				id = $2;`,
+			i+1, id,
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("repo: reorder categories: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repo: reorder categories: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+func TestCategoryStats(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewCategory(db)
+	ctx := context.Background()
+
+	alice := createUser(t, db, "alice")
+	posted := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	commented := posted.Add(time.Hour)
+
+	posts := map[string][]int{"Go": {2, 3}, "Rust": {2}}
+	postIDs := map[string]int{}
+	for title, categoryIDs := range posts {
+		postIDs[title] = createPost(t, db, alice, title)
+		for _, categoryID := range categoryIDs {
+			if _, err := db.Exec(`INSERT INTO post_category (post_id, category_id) VALUES ($1, $2);`, postIDs[title], categoryID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := db.Exec(`UPDATE post SET creation_time = $1 WHERE id = $2;`, posted, postIDs[title]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the comments of a post don't count as posts of its categories
+	for i, content := range []string{"first", "second"} {
+		if _, err := NewComment(db).Create(ctx, model.Comment{
+			PostID:       postIDs["Go"],
+			Author:       model.User{ID: alice},
+			Content:      content,
+			CreationTime: commented.Add(-time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		categoryID       int
+		wantPostsCount   int
+		wantLastActivity interface{}
+	}{
+		{"posts and a comment", 2, 2, commented},
+		{"post with a comment in another category", 3, 1, commented},
+		{"empty", 4, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			category, err := repo.GetByID(ctx, tt.categoryID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if category.PostsCount != tt.wantPostsCount {
+				t.Errorf("posts count = %d, want %d", category.PostsCount, tt.wantPostsCount)
+			}
+
+			got, _ := category.LastActivity.(time.Time)
+			want, _ := tt.wantLastActivity.(time.Time)
+			if (category.LastActivity == nil) != (tt.wantLastActivity == nil) || !got.Equal(want) {
+				t.Errorf("last activity = %v, want %v", category.LastActivity, tt.wantLastActivity)
+			}
+		})
+	}
+
+	// the list groups the same stats per category
+	categories, err := repo.GetAll(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[int]int{}
+	for _, category := range categories {
+		counts[category.ID] = category.PostsCount
+	}
+	if counts[2] != 2 || counts[3] != 1 || counts[4] != 0 {
+		t.Errorf("GetAll() posts counts = %v, want 2, 1 and 0 for categories 2 to 4", counts)
+	}
+
+	if _, err := repo.GetByID(ctx, 99); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetByID() of a missing category error = %v, want %v", err, ErrNoRows)
+	}
+}
+
+func TestCategoryChanges(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewCategory(db)
+	ctx := context.Background()
+
+	id, err := repo.Create(ctx, "Sport")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Sport", "SPORT", "sport"} {
+		if _, err := repo.Create(ctx, name); !errors.Is(err, ErrCategoryExists) {
+			t.Errorf("Create(%q) of a taken name error = %v, want %v", name, err, ErrCategoryExists)
+		}
+	}
+	if err := repo.Rename(ctx, id, "music"); !errors.Is(err, ErrCategoryExists) {
+		t.Errorf("Rename() to a taken name error = %v, want %v", err, ErrCategoryExists)
+	}
+	if err := repo.Rename(ctx, id, "SPORT"); err != nil {
+		t.Errorf("Rename() to the same name in another case error = %v", err)
+	}
+	if err := repo.Rename(ctx, id+1, "Chess"); !errors.Is(err, ErrNoRows) {
+		t.Errorf("Rename() of a missing category error = %v, want %v", err, ErrNoRows)
+	}
+	if err := repo.SetArchived(ctx, id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := repo.GetAll(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := repo.GetAll(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != len(active)+1 || all[len(all)-1].ID != id {
+		t.Fatalf("all = %d categories ending with %d, active = %d, want the archived one last and only in all", len(all), all[len(all)-1].ID, len(active))
+	}
+
+	// reversed order, "All" stays first
+	var order []int
+	for i := len(all) - 1; i > 0; i-- {
+		order = append(order, all[i].ID)
+	}
+	if err := repo.Reorder(ctx, order); err != nil {
+		t.Fatal(err)
+	}
+
+	reordered, err := repo.GetAll(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reordered[0].ID != 1 {
+		t.Errorf("first category = %d, want \"All\"", reordered[0].ID)
+	}
+	for i, id := range order {
+		if reordered[i+1].ID != id {
+			t.Errorf("category %d = %d, want %d", i+1, reordered[i+1].ID, id)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"real-time-forum/internal/model"
+)
+
+type Comment interface {
+	Create(ctx context.Context, comment model.Comment) (int, error)
+	GetByID(ctx context.Context, commentID int, userID int) (model.Comment, error)
+	GetByPostID(ctx context.Context, postID int, userID int, cursor Cursor, limit int) ([]model.Comment, error)
+	GetReplies(ctx context.Context, commentID int, userID int, cursor Cursor, limit int) ([]model.Comment, error)
+	GetRepliesPreview(ctx context.Context, commentIDs []int, userID int, depth int, limit int) ([]model.Comment, error)
+}
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+func NewComment(db *sql.DB) *CommentRepository {
+	return &CommentRepository{
+		db: db,
+	}
+}
+
+func (r *CommentRepository) Create(ctx context.Context, comment model.Comment) (int, error) {
+	stmt, err := r.db.PrepareContext(ctx, `
+		INSERT INTO
+			comment (post_id, user_id, content, image, creation_time, parent_id)
+		VALUES
+			($1, $2, $3, $4, $5, NULLIF($6, 0))
+		RETURNING id;`)
+	if err != nil {
+		return 0, fmt.Errorf("repo: create comment: %w", err)
+	}
+
+	defer stmt.Close()
+
+	var id int
+	err = stmt.QueryRowContext(ctx,
+		comment.PostID,
+		comment.Author.ID,
+		comment.Content,
+		comment.ImagePath,
+		comment.CreationTime,
+		comment.ParentID,
+	).Scan(&id)
+	if err != nil {
+		if isForeignKeyConstraintError(err) {
+			return 0, ErrForeignKeyConstraint
+		}
+		return 0, fmt.Errorf("repo: create comment: %w", err)
+	}
+
+	return id, nil
+}
+
+const commentColumns = `
+	comment.id,
+	comment.post_id,
+	comment.user_id AS author_id,
+	user.username AS author_username,
+	user.first_name AS author_first_name,
+	user.last_name AS author_last_name,
+	user.avatar AS author_avatar,
+	comment.content,
+	IFNULL(comment.image, ''),
+	comment.creation_time,
+	CASE (SELECT vote FROM vote_comment WHERE comment_id = comment.id AND user_id = $1)
+		WHEN 1 THEN 1
+		WHEN -1 THEN 2
+		ELSE 0
+	END AS user_rate,
+	comment.rating,
+	IFNULL(comment.parent_id, 0),
+	comment.depth,
+	comment.replies_count`
+
+func scanComment(row interface{ Scan(...interface{}) error }) (model.Comment, error) {
+	var comment model.Comment
+
+	err := row.Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.Author.ID,
+		&comment.Author.Username,
+		&comment.Author.FirstName,
+		&comment.Author.LastName,
+		&comment.Author.Avatar,
+		&comment.Content,
+		&comment.ImagePath,
+		&comment.CreationTime,
+		&comment.UserRate,
+		&comment.Rating,
+		&comment.ParentID,
+		&comment.Depth,
+		&comment.RepliesCount,
+	)
+
+	return comment, err
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, commentID int, userID int) (model.Comment, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT`+commentColumns+`
+		FROM
+			comment
+		LEFT JOIN user
+		ON comment.user_id = user.id
+		WHERE
+			comment.id = $2;`,
+		userID, commentID,
+	)
+
+	comment, err := scanComment(row)
+	if err != nil {
+		if isNoRowsError(err) {
+			return model.Comment{}, ErrNoRows
+		}
+		return model.Comment{}, fmt.Errorf("repo: get comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// GetByPostID returns a page of top-level comments of the post, newest first.
+func (r *CommentRepository) GetByPostID(ctx context.Context, postID int, userID int, cursor Cursor, limit int) ([]model.Comment, error) {
+	var comments []model.Comment
+
+	page, orderBy := cursor.keyset([]string{"comment.id"}, []string{"$3"})
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT`+commentColumns+`
+		FROM
+			comment
+		LEFT JOIN user
+		ON comment.user_id = user.id
+		WHERE
+			comment.post_id = $2 AND comment.parent_id IS NULL AND %s
+		ORDER BY
+			%s
+		LIMIT $4;`, page, orderBy),
+		userID, postID, cursor.ID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get comments: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repo: get comments: %w", err)
+		}
+
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get comments: %w", err)
+	}
+
+	return inListOrder(comments, cursor), nil
+}
+
+// GetReplies returns a page of the direct replies to the comment, oldest first.
+func (r *CommentRepository) GetReplies(ctx context.Context, commentID int, userID int, cursor Cursor, limit int) ([]model.Comment, error) {
+	var replies []model.Comment
+
+	position := cursor.ascending()
+	page, orderBy := position.keyset([]string{"comment.id"}, []string{"$3"})
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT`+commentColumns+`
+		FROM
+			comment
+		LEFT JOIN user
+		ON comment.user_id = user.id
+		WHERE
+			comment.parent_id = $2 AND %s
+		ORDER BY
+			%s
+		LIMIT $4;`, page, orderBy),
+		userID, commentID, position.ID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get replies: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		reply, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repo: get replies: %w", err)
+		}
+
+		replies = append(replies, reply)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get replies: %w", err)
+	}
+
+	return inListOrder(replies, cursor), nil
+}
+
+// GetRepliesPreview returns the first replies under each of the comments: the oldest limit replies
+// to a comment, down to depth levels below it. Replies are sorted by path, in reading order.
+func (r *CommentRepository) GetRepliesPreview(ctx context.Context, commentIDs []int, userID int, depth int, limit int) ([]model.Comment, error) {
+	var replies []model.Comment
+
+	ids, err := json.Marshal(commentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get replies preview: %w", err)
+	}
+
+	// every level takes the replies up to the limit-th one of their parent, or all of them when there are
+	// fewer, the bound doesn't depend on the reply so they are read as a range of comment_parent_idx
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT`+commentColumns+`
+		FROM (
+			WITH RECURSIVE thread (id, level) AS (
+				SELECT
+					value, 0
+				FROM
+					json_each($2)
+				UNION ALL
+				SELECT
+					reply.id, thread.level + 1
+				FROM
+					thread
+				INNER JOIN comment AS reply
+				ON reply.parent_id = thread.id
+				WHERE
+					thread.level < $3
+					AND reply.id <= IFNULL(
+						(SELECT id FROM comment WHERE parent_id = thread.id ORDER BY id LIMIT 1 OFFSET $4 - 1),
+						9223372036854775807
+					)
+			)
+			SELECT id FROM thread WHERE level > 0
+		) AS thread
+		INNER JOIN comment
+		ON comment.id = thread.id
+		LEFT JOIN user
+		ON comment.user_id = user.id
+		ORDER BY
+			comment.path;`,
+		userID, string(ids), depth, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get replies preview: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		reply, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("repo: get replies preview: %w", err)
+		}
+
+		replies = append(replies, reply)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get replies preview: %w", err)
+	}
+
+	return replies, nil
+}
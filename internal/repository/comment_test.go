@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+func TestCreateCommentOnUnknownPost(t *testing.T) {
+	db := newTestDB(t)
+	alice := createUser(t, db, "alice")
+
+	_, err := NewComment(db).Create(context.Background(), model.Comment{
+		PostID:       1,
+		Author:       model.User{ID: alice},
+		Content:      "first",
+		CreationTime: time.Now(),
+	})
+	if !errors.Is(err, ErrForeignKeyConstraint) {
+		t.Errorf("Create() error = %v, want %v", err, ErrForeignKeyConstraint)
+	}
+}
+
+func TestReplies(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	r := NewComment(db)
+
+	alice := createUser(t, db, "alice")
+	postID := createPost(t, db, alice, "first")
+
+	reply := func(parentID int, content string) int {
+		t.Helper()
+
+		id, err := r.Create(ctx, model.Comment{
+			PostID:       postID,
+			ParentID:     parentID,
+			Author:       model.User{ID: alice},
+			Content:      content,
+			CreationTime: time.Now(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	top := reply(0, "top")
+	first := reply(top, "first reply")
+	nested := reply(first, "nested reply")
+	second := reply(top, "second reply")
+	latest := reply(0, "latest")
+
+	tests := []struct {
+		id          int
+		wantPath    string
+		wantDepth   int
+		wantReplies int
+	}{
+		{top, fmt.Sprintf("%010d/", top), 0, 2},
+		{first, fmt.Sprintf("%010d/%010d/", top, first), 1, 1},
+		{nested, fmt.Sprintf("%010d/%010d/%010d/", top, first, nested), 2, 0},
+		{second, fmt.Sprintf("%010d/%010d/", top, second), 1, 0},
+	}
+
+	for _, tt := range tests {
+		var path string
+		var depth, replies int
+
+		if err := db.QueryRow(`SELECT path, depth, replies_count FROM comment WHERE id = $1;`, tt.id).Scan(&path, &depth, &replies); err != nil {
+			t.Fatal(err)
+		}
+		if path != tt.wantPath || depth != tt.wantDepth || replies != tt.wantReplies {
+			t.Errorf("comment %d = %s %d %d, want %s %d %d", tt.id, path, depth, replies, tt.wantPath, tt.wantDepth, tt.wantReplies)
+		}
+	}
+
+	ids := func(comments []model.Comment, err error) []int {
+		t.Helper()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var ids []int
+		for _, comment := range comments {
+			ids = append(ids, comment.ID)
+		}
+		return ids
+	}
+
+	pages := []struct {
+		name string
+		got  []int
+		want []int
+	}{
+		{"top-level comments", ids(r.GetByPostID(ctx, postID, alice, FirstPage, 10)), []int{latest, top}},
+		{"replies oldest first", ids(r.GetReplies(ctx, top, alice, FirstPage, 10)), []int{first, second}},
+		{"replies after a reply", ids(r.GetReplies(ctx, top, alice, Cursor{ID: first}, 10)), []int{second}},
+		{"replies before a reply", ids(r.GetReplies(ctx, top, alice, Cursor{ID: second, Before: true}, 10)), []int{first}},
+		{"preview in reading order", ids(r.GetRepliesPreview(ctx, []int{top}, alice, 2, 1)), []int{first, nested}},
+		{"preview one level deep", ids(r.GetRepliesPreview(ctx, []int{top}, alice, 1, 3)), []int{first, second}},
+	}
+
+	for _, page := range pages {
+		if !reflect.DeepEqual(page.got, page.want) {
+			t.Errorf("%s = %v, want %v", page.name, page.got, page.want)
+		}
+	}
+
+	// the replies go with the comment
+	if _, err := db.Exec(`DELETE FROM comment WHERE id = $1;`, first); err != nil {
+		t.Fatal(err)
+	}
+
+	var count, replies int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM comment;`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow(`SELECT replies_count FROM comment WHERE id = $1;`, top).Scan(&replies); err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || replies != 1 {
+		t.Errorf("%d comments with %d replies to the top one left, want 3 and 1", count, replies)
+	}
+}
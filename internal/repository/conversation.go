@@ -0,0 +1,440 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+type Conversation interface {
+	Create(ctx context.Context, conversation model.Conversation, memberIDs []int) (int, error)
+	GetByID(ctx context.Context, conversationID int) (model.Conversation, error)
+	GetByUserID(ctx context.Context, userID int) ([]model.Conversation, error)
+	Rename(ctx context.Context, conversationID int, title string) error
+	SetOwner(ctx context.Context, conversationID int, ownerID int) error
+	Delete(ctx context.Context, conversationID int) error
+	AddMember(ctx context.Context, conversationID int, userID int, joinedAt time.Time) error
+	RemoveMember(ctx context.Context, conversationID int, userID int) error
+	CreateMessage(ctx context.Context, message model.ConversationMessage) (int, error)
+	GetMessages(ctx context.Context, conversationID int, cursor Cursor, limit int) ([]model.ConversationMessage, error)
+	MarkRead(ctx context.Context, conversationID int, userID int, messageID int) (bool, error)
+}
+
+type ConversationRepository struct {
+	db *sql.DB
+}
+
+func NewConversation(db *sql.DB) *ConversationRepository {
+	return &ConversationRepository{
+		db: db,
+	}
+}
+
+// Create creates the conversation with its members, the owner must be one of them.
+func (r *ConversationRepository) Create(ctx context.Context, conversation model.Conversation, memberIDs []int) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("repo: create conversation: %w", err)
+	}
+
+	var id int
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO
+			conversation (title, owner_id, creation_time)
+		VALUES
+			($1, $2, $3)
+		RETURNING id;`,
+		conversation.Title, conversation.OwnerID, conversation.CreationTime,
+	).Scan(&id)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("repo: create conversation: %w", err)
+	}
+
+	for _, userID := range memberIDs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO
+				conversation_member (conversation_id, user_id, joined_at)
+			VALUES
+				($1, $2, $3);`,
+			id, userID, conversation.CreationTime,
+		); err != nil {
+			tx.Rollback()
+			if isForeignKeyConstraintError(err) {
+				return 0, ErrForeignKeyConstraint
+			}
+			return 0, fmt.Errorf("repo: create conversation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("repo: create conversation: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *ConversationRepository) GetByID(ctx context.Context, conversationID int) (model.Conversation, error) {
+	var (
+		conversation model.Conversation
+		ownerID      sql.NullInt64
+	)
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			id, title, owner_id, creation_time
+		FROM
+			conversation
+		WHERE
+			id = $1;`,
+		conversationID,
+	).Scan(&conversation.ID, &conversation.Title, &ownerID, &conversation.CreationTime)
+	if err != nil {
+		if isNoRowsError(err) {
+			return model.Conversation{}, ErrNoRows
+		}
+		return model.Conversation{}, fmt.Errorf("repo: get conversation: %w", err)
+	}
+
+	conversation.OwnerID = int(ownerID.Int64)
+
+	conversation.Members, err = r.getMembers(ctx, conversation.ID)
+	if err != nil {
+		return model.Conversation{}, fmt.Errorf("repo: get conversation: %w", err)
+	}
+
+	return conversation, nil
+}
+
+func (r *ConversationRepository) getMembers(ctx context.Context, conversationID int) ([]model.ConversationMember, error) {
+	var members []model.ConversationMember
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			user.id,
+			user.username,
+			user.first_name,
+			user.last_name,
+			user.avatar,
+			conversation_member.joined_at,
+			conversation_member.last_read_message_id
+		FROM
+			conversation_member
+		INNER JOIN user
+		ON user.id = conversation_member.user_id
+		WHERE
+			conversation_member.conversation_id = $1
+		ORDER BY
+			conversation_member.joined_at, user.id;`,
+		conversationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var member model.ConversationMember
+
+		if err := rows.Scan(
+			&member.User.ID,
+			&member.User.Username,
+			&member.User.FirstName,
+			&member.User.LastName,
+			&member.User.Avatar,
+			&member.JoinedAt,
+			&member.LastReadMessageID,
+		); err != nil {
+			return nil, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// GetByUserID returns conversations of the user, the ones with the most recent messages go first.
+func (r *ConversationRepository) GetByUserID(ctx context.Context, userID int) ([]model.Conversation, error) {
+	var conversations []model.Conversation
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			conversation.id,
+			conversation.title,
+			conversation.owner_id,
+			conversation.creation_time,
+			IFNULL(last_message.id, 0),
+			IFNULL(last_message.sender_id, 0),
+			IFNULL(last_message.message, ''),
+			last_message.creation_time,
+			(
+				SELECT
+					COUNT(*)
+				FROM
+					conversation_message
+				WHERE
+					conversation_id = conversation.id AND id > member.last_read_message_id AND sender_id != $1
+			) AS unread_messages_count
+		FROM
+			conversation_member member
+		INNER JOIN conversation
+		ON conversation.id = member.conversation_id
+		LEFT JOIN conversation_message last_message
+		ON last_message.id = (
+			SELECT
+				MAX(id)
+			FROM
+				conversation_message
+			WHERE
+				conversation_id = conversation.id
+		)
+		WHERE
+			member.user_id = $1
+		ORDER BY
+			IFNULL(last_message.id, 0) DESC, conversation.id DESC;`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get conversations: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			conversation model.Conversation
+			ownerID      sql.NullInt64
+		)
+
+		if err := rows.Scan(
+			&conversation.ID,
+			&conversation.Title,
+			&ownerID,
+			&conversation.CreationTime,
+			&conversation.LastMessage.ID,
+			&conversation.LastMessage.SenderID,
+			&conversation.LastMessage.Message,
+			&conversation.LastMessage.CreationTime,
+			&conversation.UnreadMessagesCount,
+		); err != nil {
+			return nil, fmt.Errorf("repo: get conversations: %w", err)
+		}
+
+		conversation.OwnerID = int(ownerID.Int64)
+		if conversation.LastMessage.ID != 0 {
+			conversation.LastMessage.ConversationID = conversation.ID
+		}
+
+		conversations = append(conversations, conversation)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get conversations: %w", err)
+	}
+
+	for i := range conversations {
+		conversations[i].Members, err = r.getMembers(ctx, conversations[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("repo: get conversations: %w", err)
+		}
+	}
+
+	return conversations, nil
+}
+
+func (r *ConversationRepository) Rename(ctx context.Context, conversationID int, title string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			conversation
+		SET
+			title = $1
+		WHERE
+			id = $2;`,
+		title, conversationID,
+	)
+	if err != nil {
+		return fmt.Errorf("repo: rename conversation: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo: rename conversation: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) SetOwner(ctx context.Context, conversationID int, ownerID int) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE
+			conversation
+		SET
+			owner_id = $1
+		WHERE
+			id = $2;`,
+		ownerID, conversationID,
+	); err != nil {
+		return fmt.Errorf("repo: set conversation owner: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) Delete(ctx context.Context, conversationID int) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			conversation
+		WHERE
+			id = $1;`,
+		conversationID,
+	); err != nil {
+		return fmt.Errorf("repo: delete conversation: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) AddMember(ctx context.Context, conversationID int, userID int, joinedAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO
+			conversation_member (conversation_id, user_id, joined_at)
+		VALUES
+			($1, $2, $3);`,
+		conversationID, userID, joinedAt,
+	); err != nil {
+		if isAlreadyExists(err) {
+			return ErrMemberExists
+		}
+		if isForeignKeyConstraintError(err) {
+			return ErrForeignKeyConstraint
+		}
+		return fmt.Errorf("repo: add member: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) RemoveMember(ctx context.Context, conversationID int, userID int) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			conversation_member
+		WHERE
+			conversation_id = $1 AND user_id = $2;`,
+		conversationID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("repo: remove member: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo: remove member: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *ConversationRepository) CreateMessage(ctx context.Context, message model.ConversationMessage) (int, error) {
+	var id int
+
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO
+			conversation_message (conversation_id, sender_id, message, creation_time)
+		VALUES
+			($1, $2, $3, $4)
+		RETURNING id;`,
+		message.ConversationID, message.SenderID, message.Message, message.CreationTime,
+	).Scan(&id)
+	if err != nil {
+		if isForeignKeyConstraintError(err) {
+			return 0, ErrForeignKeyConstraint
+		}
+		return 0, fmt.Errorf("repo: create conversation message: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetMessages returns a page of the conversation history, newest first.
+func (r *ConversationRepository) GetMessages(ctx context.Context, conversationID int, cursor Cursor, limit int) ([]model.ConversationMessage, error) {
+	var messages []model.ConversationMessage
+
+	page, orderBy := cursor.keyset([]string{"id"}, []string{"$2"})
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			id, conversation_id, sender_id, message, creation_time
+		FROM
+			conversation_message
+		WHERE
+			conversation_id = $1 AND %s
+		ORDER BY
+			%s
+		LIMIT $3;`, page, orderBy),
+		conversationID, cursor.ID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get conversation messages: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var message model.ConversationMessage
+
+		if err := rows.Scan(
+			&message.ID,
+			&message.ConversationID,
+			&message.SenderID,
+			&message.Message,
+			&message.CreationTime,
+		); err != nil {
+			return nil, fmt.Errorf("repo: get conversation messages: %w", err)
+		}
+
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get conversation messages: %w", err)
+	}
+
+	return inListOrder(messages, cursor), nil
+}
+
+// MarkRead moves the read state of the member forward, it reports false if it was already there.
+func (r *ConversationRepository) MarkRead(ctx context.Context, conversationID int, userID int, messageID int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			conversation_member
+		SET
+			last_read_message_id = $1
+		WHERE
+			conversation_id = $2 AND user_id = $3 AND last_read_message_id < $1;`,
+		messageID, conversationID, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("repo: mark conversation read: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("repo: mark conversation read: %w", err)
+	}
+
+	return n > 0, nil
+}
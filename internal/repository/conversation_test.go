@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+func TestConversationMembers(t *testing.T) {
+	db := newTestDB(t)
+	r := NewConversation(db)
+	ctx := context.Background()
+	alice, bob, carol := createUser(t, db, "alice"), createUser(t, db, "bob"), createUser(t, db, "carol")
+
+	if _, err := r.Create(ctx, model.Conversation{Title: "Friends", OwnerID: alice, CreationTime: time.Now()}, []int{alice, 42}); !errors.Is(err, ErrForeignKeyConstraint) {
+		t.Fatalf("Create() with an unknown member error = %v, want %v", err, ErrForeignKeyConstraint)
+	}
+
+	id, err := r.Create(ctx, model.Conversation{Title: "Friends", OwnerID: alice, CreationTime: time.Now()}, []int{alice, bob})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		change      func() error
+		wantErr     error
+		wantMembers []int
+	}{
+		{"joined", func() error { return r.AddMember(ctx, id, carol, time.Now()) }, nil, []int{alice, bob, carol}},
+		{"joined again", func() error { return r.AddMember(ctx, id, carol, time.Now()) }, ErrMemberExists, []int{alice, bob, carol}},
+		{"unknown user", func() error { return r.AddMember(ctx, id, 42, time.Now()) }, ErrForeignKeyConstraint, []int{alice, bob, carol}},
+		{"left", func() error { return r.RemoveMember(ctx, id, alice) }, nil, []int{bob, carol}},
+		{"left again", func() error { return r.RemoveMember(ctx, id, alice) }, ErrNoRows, []int{bob, carol}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			conversation, err := r.GetByID(ctx, id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// members are ordered by the time they joined
+			if len(conversation.Members) != len(tt.wantMembers) {
+				t.Fatalf("members = %v, want %v", conversation.Members, tt.wantMembers)
+			}
+			for i, member := range conversation.Members {
+				if member.User.ID != tt.wantMembers[i] {
+					t.Errorf("member %d = %d, want %d", i, member.User.ID, tt.wantMembers[i])
+				}
+			}
+		})
+	}
+
+	if err := r.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetByID(ctx, id); !errors.Is(err, ErrNoRows) {
+		t.Errorf("GetByID() of a deleted conversation error = %v, want %v", err, ErrNoRows)
+	}
+}
+
+func TestConversationUnread(t *testing.T) {
+	db := newTestDB(t)
+	r := NewConversation(db)
+	ctx := context.Background()
+	alice, bob, carol := createUser(t, db, "alice"), createUser(t, db, "bob"), createUser(t, db, "carol")
+
+	first, err := r.Create(ctx, model.Conversation{Title: "Quiet", OwnerID: alice, CreationTime: time.Now()}, []int{alice, bob, carol})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := r.Create(ctx, model.Conversation{Title: "Busy", OwnerID: alice, CreationTime: time.Now()}, []int{alice, bob})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	for _, message := range []model.ConversationMessage{
+		{ConversationID: first, SenderID: bob, Message: "1"},
+		{ConversationID: second, SenderID: bob, Message: "2"},
+		{ConversationID: second, SenderID: alice, Message: "3"},
+		{ConversationID: second, SenderID: bob, Message: "4"},
+	} {
+		message.CreationTime = time.Now()
+		id, err := r.CreateMessage(ctx, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	changed, err := r.MarkRead(ctx, second, alice, ids[1])
+	if err != nil || !changed {
+		t.Fatalf("MarkRead() = %v, %v, want a change", changed, err)
+	}
+	if changed, err := r.MarkRead(ctx, second, alice, ids[0]); err != nil || changed {
+		t.Errorf("MarkRead() back = %v, %v, want no change", changed, err)
+	}
+
+	conversations, err := r.GetByUserID(ctx, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the latest message goes first, own messages aren't unread
+	want := []struct {
+		id          int
+		lastMessage string
+		unread      int
+	}{
+		{second, "4", 1},
+		{first, "1", 1},
+	}
+
+	if len(conversations) != len(want) {
+		t.Fatalf("GetByUserID() returned %d conversations, want %d", len(conversations), len(want))
+	}
+	for i, w := range want {
+		c := conversations[i]
+		if c.ID != w.id || c.LastMessage.Message != w.lastMessage || c.UnreadMessagesCount != w.unread {
+			t.Errorf("conversation %d = %d, %q, %d unread, want %d, %q, %d unread",
+				i, c.ID, c.LastMessage.Message, c.UnreadMessagesCount, w.id, w.lastMessage, w.unread)
+		}
+	}
+
+	if conversations, err := r.GetByUserID(ctx, carol); err != nil || len(conversations) != 1 {
+		t.Errorf("GetByUserID() of carol = %d conversations, %v, want 1", len(conversations), err)
+	}
+}
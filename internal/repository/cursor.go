@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Cursor is a position in a list sorted by a key and then by the id, both descending. A page is
+// read after the position, or right before it when Before is set. Lists sorted by the id alone
+// ignore the key.
+type Cursor struct {
+	Key    float64
+	ID     int
+	Before bool
+}
+
+// FirstPage is the position in front of the first item of every list.
+var FirstPage = Cursor{Key: math.MaxFloat64, ID: math.MaxInt}
+
+// keyset returns the condition selecting the items past the cursor and the order to read them in,
+// the columns are compared with the params as a row value so an index on them is used.
+func (c Cursor) keyset(columns []string, params []string) (string, string) {
+	op, direction := "<", "DESC"
+	if c.Before {
+		op, direction = ">", "ASC"
+	}
+
+	order := make([]string, len(columns))
+	for i, column := range columns {
+		order[i] = column + " " + direction
+	}
+
+	where := fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(params, ", "))
+
+	return where, strings.Join(order, ", ")
+}
+
+// ascending is the position to read a list sorted by the key and then by the id ascending from,
+// the comparison is turned around and the first page starts below every id. The page read is
+// put in order with the cursor itself.
+func (c Cursor) ascending() Cursor {
+	if c == FirstPage {
+		return Cursor{Key: -math.MaxFloat64, ID: 0, Before: true}
+	}
+
+	c.Before = !c.Before
+
+	return c
+}
+
+// inListOrder puts the items of a page read before the cursor back in the order of the list.
+func inListOrder[T any](items []T, c Cursor) []T {
+	if !c.Before {
+		return items
+	}
+
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+
+	return items
+}
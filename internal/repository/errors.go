@@ -10,8 +10,14 @@ var (
 	ErrNoRows               = errors.New("no rows")
 	ErrForeignKeyConstraint = errors.New("foreign key constraint failed")
 	ErrUserExists           = errors.New("user already exists")
+	ErrEmailExists          = errors.New("email already exists")
+	ErrCategoryExists       = errors.New("category already exists")
+	ErrMemberExists         = errors.New("member already exists")
 )
 
+// sqliteTimeLayout is the layout go-sqlite3 stores time.Time values with.
+const sqliteTimeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
 func isNoRowsError(err error) bool {
 	if err == nil {
 		return false
@@ -27,6 +33,11 @@ func isAlreadyExists(err error) bool {
 	return strings.HasPrefix(err.Error(), "UNIQUE constraint failed:")
 }
 
+// isColumnTaken tells if the unique constraint failed on the column, given as table.column.
+func isColumnTaken(err error, column string) bool {
+	return isAlreadyExists(err) && strings.Contains(err.Error(), column)
+}
+
 func isForeignKeyConstraintError(err error) bool {
 	if err == nil {
 		return false
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type Image interface {
+	AddPostImage(ctx context.Context, postID int, image string) error
+	SetCommentImage(ctx context.Context, commentID int, image string) (string, error)
+	SetAvatar(ctx context.Context, userID int, image string) (string, error)
+	GetPostFiles(ctx context.Context, postID int) ([]string, error)
+	IsReferenced(ctx context.Context, image string) (bool, error)
+}
+
+type ImageRepository struct {
+	db *sql.DB
+}
+
+func NewImage(db *sql.DB) *ImageRepository {
+	return &ImageRepository{
+		db: db,
+	}
+}
+
+// AddPostImage attaches the image to the post once, the first image becomes the cover in post.image.
+func (r *ImageRepository) AddPostImage(ctx context.Context, postID int, image string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repo: add post image: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO
+			post_image (post_id, image)
+		VALUES
+			($1, $2)
+		ON CONFLICT (post_id, image) DO NOTHING;`,
+		postID, image,
+	); err != nil {
+		tx.Rollback()
+		if isForeignKeyConstraintError(err) {
+			return ErrForeignKeyConstraint
+		}
+		return fmt.Errorf("repo: add post image: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE
+			post
+		SET
+			image = $1
+		WHERE
+			id = $2 AND IFNULL(image, '') = '';`,
+		image, postID,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: add post image: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repo: add post image: %w", err)
+	}
+
+	return nil
+}
+
+// SetCommentImage replaces the image of the comment and returns the previous one.
+func (r *ImageRepository) SetCommentImage(ctx context.Context, commentID int, image string) (string, error) {
+	return r.replace(ctx, "comment", "image", commentID, image)
+}
+
+// SetAvatar replaces the avatar of the user and returns the previous one.
+func (r *ImageRepository) SetAvatar(ctx context.Context, userID int, image string) (string, error) {
+	return r.replace(ctx, "user", "avatar", userID, image)
+}
+
+func (r *ImageRepository) replace(ctx context.Context, table string, column string, id int, image string) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("repo: set %s %s: %w", table, column, err)
+	}
+
+	var old string
+
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT
+			IFNULL(%s, '')
+		FROM
+			%s
+		WHERE
+			id = $1;`, column, table),
+		id,
+	).Scan(&old)
+	if err != nil {
+		tx.Rollback()
+		if isNoRowsError(err) {
+			return "", ErrNoRows
+		}
+		return "", fmt.Errorf("repo: set %s %s: %w", table, column, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		UPDATE
+			%s
+		SET
+			%s = $1
+		WHERE
+			id = $2;`, table, column),
+		image, id,
+	); err != nil {
+		tx.Rollback()
+		return "", fmt.Errorf("repo: set %s %s: %w", table, column, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("repo: set %s %s: %w", table, column, err)
+	}
+
+	return old, nil
+}
+
+// GetPostFiles returns the images of the post and its comments, they may be left unused when the post is deleted.
+func (r *ImageRepository) GetPostFiles(ctx context.Context, postID int) ([]string, error) {
+	var files []string
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			image
+		FROM
+			post_image
+		WHERE
+			post_id = $1
+		UNION
+		SELECT
+			image
+		FROM
+			post
+		WHERE
+			id = $1 AND IFNULL(image, '') != ''
+		UNION
+		SELECT
+			image
+		FROM
+			comment
+		WHERE
+			post_id = $1 AND IFNULL(image, '') != '';`,
+		postID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get post files: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var file string
+
+		if err := rows.Scan(&file); err != nil {
+			return nil, fmt.Errorf("repo: get post files: %w", err)
+		}
+
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+// IsReferenced tells if anything still shows the image, the same upload is stored once for everyone.
+func (r *ImageRepository) IsReferenced(ctx context.Context, image string) (bool, error) {
+	var referenced bool
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			EXISTS (SELECT 1 FROM post_image WHERE image = $1)
+			OR EXISTS (SELECT 1 FROM post WHERE image = $1)
+			OR EXISTS (SELECT 1 FROM comment WHERE image = $1)
+			OR EXISTS (SELECT 1 FROM user WHERE avatar = $1);`,
+		image,
+	).Scan(&referenced)
+	if err != nil {
+		return false, fmt.Errorf("repo: check image references: %w", err)
+	}
+
+	return referenced, nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestImages(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	r := NewImage(db)
+
+	alice := createUser(t, db, "alice")
+	postID := createPost(t, db, alice, "first")
+
+	for _, image := range []string{"cover.jpg", "second.jpg", "cover.jpg"} {
+		if err := r.AddPostImage(ctx, postID, image); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cover string
+	if err := db.QueryRow(`SELECT image FROM post WHERE id = $1;`, postID).Scan(&cover); err != nil {
+		t.Fatal(err)
+	}
+	if cover != "cover.jpg" {
+		t.Errorf("cover = %q, want the first image", cover)
+	}
+
+	old, err := r.SetAvatar(ctx, alice, "avatar.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old != "default.jpg" {
+		t.Errorf("old avatar = %q, want default.jpg", old)
+	}
+
+	if _, err := r.SetAvatar(ctx, 42, "avatar.jpg"); err != ErrNoRows {
+		t.Errorf("SetAvatar() of a missing user error = %v, want %v", err, ErrNoRows)
+	}
+
+	if err := r.AddPostImage(ctx, 42, "lost.jpg"); err != ErrForeignKeyConstraint {
+		t.Errorf("AddPostImage() of a missing post error = %v, want %v", err, ErrForeignKeyConstraint)
+	}
+
+	files, err := r.GetPostFiles(ctx, postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	if len(files) != 2 || files[0] != "cover.jpg" || files[1] != "second.jpg" {
+		t.Errorf("post files = %v, want cover.jpg and second.jpg", files)
+	}
+
+	tests := []struct {
+		image string
+		want  bool
+	}{
+		{"cover.jpg", true},
+		{"second.jpg", true},
+		{"avatar.jpg", true},
+		{"default.jpg", false},
+		{"lost.jpg", false},
+	}
+
+	for _, tt := range tests {
+		referenced, err := r.IsReferenced(ctx, tt.image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if referenced != tt.want {
+			t.Errorf("IsReferenced(%q) = %v, want %v", tt.image, referenced, tt.want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+type Message interface {
+	Create(ctx context.Context, message model.Message) (int, error)
+	GetMessages(ctx context.Context, userID int, companionID int, cursor Cursor, limit int) ([]model.Message, error)
+	GetChats(ctx context.Context, userID int) ([]model.Chat, error)
+	GetByID(ctx context.Context, messageID int) (model.Message, error)
+	MarkRead(ctx context.Context, recipientID int, senderID int, lastMessageID int) (int, error)
+	GetUnreadCount(ctx context.Context, recipientID int, senderID int) (int, error)
+	Update(ctx context.Context, messageID int, text string, editedAt time.Time) error
+	Delete(ctx context.Context, messageID int, deletedAt time.Time) error
+	GetEdits(ctx context.Context, messageID int) ([]model.MessageEdit, error)
+}
+
+type MessageRepository struct {
+	db *sql.DB
+}
+
+func NewMessage(db *sql.DB) *MessageRepository {
+	return &MessageRepository{
+		db: db,
+	}
+}
+
+func (r *MessageRepository) Create(ctx context.Context, message model.Message) (int, error) {
+	stmt, err := r.db.PrepareContext(ctx, `
+		INSERT INTO
+			message (sender_id, recipient_id, message, creation_time)
+		VALUES
+			($1, $2, $3, $4)
+		RETURNING id;`)
+	if err != nil {
+		return 0, fmt.Errorf("repo: create message: %w", err)
+	}
+
+	defer stmt.Close()
+
+	var id int
+	err = stmt.QueryRowContext(ctx,
+		message.SenderID,
+		message.RecipientID,
+		message.Message,
+		message.CreationTime,
+	).Scan(&id)
+	if err != nil {
+		if isForeignKeyConstraintError(err) {
+			return 0, ErrForeignKeyConstraint
+		}
+		return 0, fmt.Errorf("repo: create message: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetMessages returns a page of the history with the companion, newest first.
+func (r *MessageRepository) GetMessages(ctx context.Context, userID int, companionID int, cursor Cursor, limit int) ([]model.Message, error) {
+	var messages []model.Message
+
+	page, orderBy := cursor.keyset([]string{"id"}, []string{"$3"})
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			id, sender_id, recipient_id, message, creation_time, readed, edited_at, deleted_at
+		FROM
+			message
+		WHERE
+			((sender_id = $1 AND recipient_id = $2) OR (sender_id = $2 AND recipient_id = $1))
+		AND
+			%s
+		ORDER BY
+			%s
+		LIMIT $4;`, page, orderBy),
+		userID, companionID, cursor.ID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get messages: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var message model.Message
+
+		err := rows.Scan(
+			&message.ID,
+			&message.SenderID,
+			&message.RecipientID,
+			&message.Message,
+			&message.CreationTime,
+			&message.Readed,
+			&message.EditedAt,
+			&message.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("repo: get messages: %w", err)
+		}
+
+		messages = append(messages, message)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get messages: %w", err)
+	}
+
+	return inListOrder(messages, cursor), nil
+}
+
+// GetChats returns a chat with every other user, chats with the most recent messages go first,
+// users without messages are sorted by username.
+func (r *MessageRepository) GetChats(ctx context.Context, userID int) ([]model.Chat, error) {
+	var chats []model.Chat
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			user.id,
+			user.username,
+			user.first_name,
+			user.last_name,
+			user.avatar,
+			IFNULL(last_message.id, 0),
+			IFNULL(last_message.sender_id, 0),
+			IFNULL(last_message.recipient_id, 0),
+			IFNULL(last_message.message, ''),
+			last_message.creation_time,
+			IFNULL(last_message.readed, FALSE),
+			last_message.edited_at,
+			last_message.deleted_at,
+			IFNULL(message_unread.count, 0) AS unread_messages_count
+		FROM
+			user
+		LEFT JOIN message_unread
+		ON message_unread.recipient_id = $1 AND message_unread.sender_id = user.id
+		LEFT JOIN message last_message
+		ON last_message.id = (
+			SELECT
+				id
+			FROM
+				message
+			WHERE
+				(sender_id = user.id AND recipient_id = $1) OR (sender_id = $1 AND recipient_id = user.id)
+			ORDER BY
+				id DESC
+			LIMIT 1
+		)
+		WHERE
+			user.id != $1
+		ORDER BY
+			last_message.id IS NULL, last_message.id DESC, user.username COLLATE NOCASE;`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get chats: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var chat model.Chat
+
+		err := rows.Scan(
+			&chat.User.ID,
+			&chat.User.Username,
+			&chat.User.FirstName,
+			&chat.User.LastName,
+			&chat.User.Avatar,
+			&chat.LastMessage.ID,
+			&chat.LastMessage.SenderID,
+			&chat.LastMessage.RecipientID,
+			&chat.LastMessage.Message,
+			&chat.LastMessage.CreationTime,
+			&chat.LastMessage.Readed,
+			&chat.LastMessage.EditedAt,
+			&chat.LastMessage.DeletedAt,
+			&chat.UnreadMessagesCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("repo: get chats: %w", err)
+		}
+
+		chats = append(chats, chat)
+	}
+
+	return chats, rows.Err()
+}
+
+// GetByID returns the message, its creation time is always a time.Time since the delete window is checked against it.
+func (r *MessageRepository) GetByID(ctx context.Context, messageID int) (model.Message, error) {
+	var (
+		message model.Message
+		sent    time.Time
+	)
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			id, sender_id, recipient_id, message, creation_time, readed, edited_at, deleted_at
+		FROM
+			message
+		WHERE
+			id = $1;`,
+		messageID,
+	).Scan(
+		&message.ID,
+		&message.SenderID,
+		&message.RecipientID,
+		&message.Message,
+		&sent,
+		&message.Readed,
+		&message.EditedAt,
+		&message.DeletedAt,
+	)
+	if err != nil {
+		if isNoRowsError(err) {
+			return model.Message{}, ErrNoRows
+		}
+		return model.Message{}, fmt.Errorf("repo: get message: %w", err)
+	}
+
+	message.CreationTime = sent
+
+	return message, nil
+}
+
+// MarkRead marks unread messages of the sender up to lastMessageID as read and returns their count.
+func (r *MessageRepository) MarkRead(ctx context.Context, recipientID int, senderID int, lastMessageID int) (int, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			message
+		SET
+			readed = TRUE
+		WHERE
+			recipient_id = $1 AND sender_id = $2 AND id <= $3 AND readed = FALSE;`,
+		recipientID, senderID, lastMessageID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("repo: mark read: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("repo: mark read: %w", err)
+	}
+
+	return int(n), nil
+}
+
+func (r *MessageRepository) GetUnreadCount(ctx context.Context, recipientID int, senderID int) (int, error) {
+	var count int
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			count
+		FROM
+			message_unread
+		WHERE
+			recipient_id = $1 AND sender_id = $2;`,
+		recipientID, senderID,
+	).Scan(&count)
+	if err != nil && !isNoRowsError(err) {
+		return 0, fmt.Errorf("repo: get unread count: %w", err)
+	}
+
+	return count, nil
+}
+
+// Update replaces the text of the message and keeps the previous one in the history.
+func (r *MessageRepository) Update(ctx context.Context, messageID int, text string, editedAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repo: update message: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO
+			message_edit (message_id, message, edited_at)
+		SELECT
+			id, message, $1
+		FROM
+			message
+		WHERE
+			id = $2 AND deleted_at IS NULL;`,
+		editedAt, messageID,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: update message: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE
+			message
+		SET
+			message = $1, edited_at = $2
+		WHERE
+			id = $3 AND deleted_at IS NULL;`,
+		text, editedAt, messageID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: update message: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: update message: %w", err)
+	}
+
+	if n == 0 {
+		tx.Rollback()
+		return ErrNoRows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repo: update message: %w", err)
+	}
+
+	return nil
+}
+
+// Delete turns the message into a tombstone, the text, its history and reactions are dropped.
+// The tombstone counts as read so it leaves the unread counter of the recipient.
+func (r *MessageRepository) Delete(ctx context.Context, messageID int, deletedAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repo: delete message: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE
+			message
+		SET
+			message = '', readed = TRUE, deleted_at = $1
+		WHERE
+			id = $2 AND deleted_at IS NULL;`,
+		deletedAt, messageID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: delete message: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: delete message: %w", err)
+	}
+
+	if n == 0 {
+		tx.Rollback()
+		return ErrNoRows
+	}
+
+	for _, table := range []string{"message_edit", "reaction_message"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`
+			DELETE FROM
+				%s
+			WHERE
+				message_id = $1;`, table),
+			messageID,
+		); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("repo: delete message: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repo: delete message: %w", err)
+	}
+
+	return nil
+}
+
+// GetEdits returns previous versions of the message, the oldest first.
+func (r *MessageRepository) GetEdits(ctx context.Context, messageID int) ([]model.MessageEdit, error) {
+	edits := make([]model.MessageEdit, 0)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			id, message_id, message, edited_at
+		FROM
+			message_edit
+		WHERE
+			message_id = $1
+		ORDER BY
+			id;`,
+		messageID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get message edits: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var edit model.MessageEdit
+
+		if err := rows.Scan(&edit.ID, &edit.MessageID, &edit.Message, &edit.EditedAt); err != nil {
+			return nil, fmt.Errorf("repo: get message edits: %w", err)
+		}
+
+		edits = append(edits, edit)
+	}
+
+	return edits, rows.Err()
+}
@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+// sendMessages stores the messages in order and returns their ids.
+func sendMessages(t *testing.T, r *MessageRepository, messages ...model.Message) []int {
+	t.Helper()
+
+	ids := make([]int, len(messages))
+	for i, message := range messages {
+		message.CreationTime = time.Now()
+
+		id, err := r.Create(context.Background(), message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = id
+	}
+
+	return ids
+}
+
+func TestCreateMessageToUnknownUser(t *testing.T) {
+	db := newTestDB(t)
+	r := NewMessage(db)
+	alice := createUser(t, db, "alice")
+
+	_, err := r.Create(context.Background(), model.Message{SenderID: alice, RecipientID: 42, Message: "hi", CreationTime: time.Now()})
+	if !errors.Is(err, ErrForeignKeyConstraint) {
+		t.Errorf("Create() error = %v, want %v", err, ErrForeignKeyConstraint)
+	}
+}
+
+func TestGetMessages(t *testing.T) {
+	db := newTestDB(t)
+	r := NewMessage(db)
+	alice, bob, carol := createUser(t, db, "alice"), createUser(t, db, "bob"), createUser(t, db, "carol")
+
+	ids := sendMessages(t, r,
+		model.Message{SenderID: alice, RecipientID: bob, Message: "1"},
+		model.Message{SenderID: bob, RecipientID: alice, Message: "2"},
+		model.Message{SenderID: alice, RecipientID: carol, Message: "to carol"},
+		model.Message{SenderID: alice, RecipientID: bob, Message: "3"},
+		model.Message{SenderID: bob, RecipientID: alice, Message: "4"},
+	)
+
+	tests := []struct {
+		name        string
+		userID      int
+		companionID int
+		cursor      Cursor
+		limit       int
+		want        []string
+	}{
+		{"latest first", alice, bob, FirstPage, 10, []string{"4", "3", "2", "1"}},
+		{"same from the other side", bob, alice, FirstPage, 10, []string{"4", "3", "2", "1"}},
+		{"limited", alice, bob, FirstPage, 2, []string{"4", "3"}},
+		{"older than a message", alice, bob, Cursor{ID: ids[3]}, 2, []string{"2", "1"}},
+		{"older than the first", alice, bob, Cursor{ID: ids[0]}, 10, nil},
+		{"newer than a message", alice, bob, Cursor{ID: ids[0], Before: true}, 2, []string{"3", "2"}},
+		{"other chat", alice, carol, FirstPage, 10, []string{"to carol"}},
+		{"no messages", bob, carol, FirstPage, 10, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := r.GetMessages(context.Background(), tt.userID, tt.companionID, tt.cursor, tt.limit)
+			if err != nil {
+				t.Fatalf("GetMessages() error = %v", err)
+			}
+
+			var got []string
+			for _, message := range messages {
+				got = append(got, message.Message)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetMessages() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("GetMessages() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestGetChats(t *testing.T) {
+	db := newTestDB(t)
+	r := NewMessage(db)
+	alice, bob, carol, dave := createUser(t, db, "alice"), createUser(t, db, "bob"), createUser(t, db, "carol"), createUser(t, db, "dave")
+
+	sendMessages(t, r,
+		model.Message{SenderID: bob, RecipientID: alice, Message: "hi alice"},
+		model.Message{SenderID: bob, RecipientID: alice, Message: "are you there?"},
+		model.Message{SenderID: alice, RecipientID: carol, Message: "hi carol"},
+	)
+
+	chats, err := r.GetChats(context.Background(), alice)
+	if err != nil {
+		t.Fatalf("GetChats() error = %v", err)
+	}
+
+	// the latest chat goes first, users without messages follow by username
+	want := []struct {
+		userID      int
+		lastMessage string
+		unread      int
+	}{
+		{carol, "hi carol", 0},
+		{bob, "are you there?", 2},
+		{dave, "", 0},
+	}
+
+	if len(chats) != len(want) {
+		t.Fatalf("GetChats() returned %d chats, want %d", len(chats), len(want))
+	}
+
+	for i, w := range want {
+		chat := chats[i]
+		if chat.User.ID != w.userID || chat.LastMessage.Message != w.lastMessage || chat.UnreadMessagesCount != w.unread {
+			t.Errorf("chat %d = user %d, %q, %d unread, want user %d, %q, %d unread",
+				i, chat.User.ID, chat.LastMessage.Message, chat.UnreadMessagesCount, w.userID, w.lastMessage, w.unread)
+		}
+	}
+}
+
+func TestUnreadCounters(t *testing.T) {
+	db := newTestDB(t)
+	r := NewMessage(db)
+	ctx := context.Background()
+	alice, bob, carol := createUser(t, db, "alice"), createUser(t, db, "bob"), createUser(t, db, "carol")
+
+	ids := sendMessages(t, r,
+		model.Message{SenderID: bob, RecipientID: alice, Message: "1"},
+		model.Message{SenderID: bob, RecipientID: alice, Message: "2"},
+		model.Message{SenderID: carol, RecipientID: alice, Message: "from carol"},
+		model.Message{SenderID: bob, RecipientID: alice, Message: "3"},
+		model.Message{SenderID: bob, RecipientID: alice, Message: "4"},
+	)
+
+	// the steps are applied in order, each one sees the changes before it
+	tests := []struct {
+		name       string
+		step       func() (int, error)
+		wantRead   int
+		wantUnread int
+	}{
+		{"received", func() (int, error) { return 0, nil }, 0, 4},
+		{"read up to a message", func() (int, error) { return r.MarkRead(ctx, alice, bob, ids[1]) }, 2, 2},
+		{"read again", func() (int, error) { return r.MarkRead(ctx, alice, bob, ids[1]) }, 0, 2},
+		{"other sender read", func() (int, error) { return r.MarkRead(ctx, alice, carol, ids[3]) }, 1, 2},
+		{"read message deleted", func() (int, error) {
+			_, err := db.Exec(`DELETE FROM message WHERE id = $1;`, ids[0])
+			return 0, err
+		}, 0, 2},
+		{"unread message deleted", func() (int, error) {
+			_, err := db.Exec(`DELETE FROM message WHERE id = $1;`, ids[3])
+			return 0, err
+		}, 0, 1},
+		{"new message", func() (int, error) {
+			sendMessages(t, r, model.Message{SenderID: bob, RecipientID: alice, Message: "5"})
+			return 0, nil
+		}, 0, 2},
+		{"read all", func() (int, error) { return r.MarkRead(ctx, alice, bob, ids[4]+1) }, 2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read, err := tt.step()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if read != tt.wantRead {
+				t.Errorf("read %d messages, want %d", read, tt.wantRead)
+			}
+
+			unread, err := r.GetUnreadCount(ctx, alice, bob)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unread != tt.wantUnread {
+				t.Errorf("GetUnreadCount() = %d, want %d", unread, tt.wantUnread)
+			}
+
+			chats, err := r.GetChats(ctx, alice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, chat := range chats {
+				if chat.User.ID == bob && chat.UnreadMessagesCount != tt.wantUnread {
+					t.Errorf("chat with bob has %d unread, want %d", chat.UnreadMessagesCount, tt.wantUnread)
+				}
+			}
+		})
+	}
+
+	// the counter of nobody is zero
+	if unread, err := r.GetUnreadCount(ctx, bob, alice); err != nil || unread != 0 {
+		t.Errorf("GetUnreadCount() without messages = %d, %v, want 0", unread, err)
+	}
+}
+
+func TestEditAndDeleteMessage(t *testing.T) {
+	db := newTestDB(t)
+	r := NewMessage(db)
+	ctx := context.Background()
+	alice, bob := createUser(t, db, "alice"), createUser(t, db, "bob")
+
+	ids := sendMessages(t, r,
+		model.Message{SenderID: alice, RecipientID: bob, Message: "helo"},
+		model.Message{SenderID: alice, RecipientID: bob, Message: "second"},
+	)
+
+	// the steps are applied in order, each one sees the changes before it
+	tests := []struct {
+		name       string
+		change     func() error
+		wantErr    error
+		wantText   string
+		wantEdits  []string
+		wantUnread int
+	}{
+		{"edited", func() error { return r.Update(ctx, ids[0], "hello", time.Now()) }, nil, "hello", []string{"helo"}, 2},
+		{"edited again", func() error { return r.Update(ctx, ids[0], "hello bob", time.Now()) }, nil, "hello bob", []string{"helo", "hello"}, 2},
+		{"deleted", func() error { return r.Delete(ctx, ids[0], time.Now()) }, nil, "", []string{}, 1},
+		{"deleted twice", func() error { return r.Delete(ctx, ids[0], time.Now()) }, ErrNoRows, "", []string{}, 1},
+		{"tombstone edited", func() error { return r.Update(ctx, ids[0], "back", time.Now()) }, ErrNoRows, "", []string{}, 1},
+		{"missing message edited", func() error { return r.Update(ctx, ids[1]+1, "hi", time.Now()) }, ErrNoRows, "", []string{}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.change(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			message, err := r.GetByID(ctx, ids[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if message.Message != tt.wantText {
+				t.Errorf("text = %q, want %q", message.Message, tt.wantText)
+			}
+			// the delete window is checked against the sending time
+			if _, ok := message.CreationTime.(time.Time); !ok {
+				t.Errorf("creation time = %T, want time.Time", message.CreationTime)
+			}
+
+			edits, err := r.GetEdits(ctx, ids[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, edit := range edits {
+				got = append(got, edit.Message)
+			}
+			if len(got) != len(tt.wantEdits) {
+				t.Fatalf("edits = %v, want %v", got, tt.wantEdits)
+			}
+			for i := range got {
+				if got[i] != tt.wantEdits[i] {
+					t.Errorf("edits = %v, want %v", got, tt.wantEdits)
+				}
+			}
+
+			// the tombstone leaves the unread counter
+			unread, err := r.GetUnreadCount(ctx, bob, alice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unread != tt.wantUnread {
+				t.Errorf("unread = %d, want %d", unread, tt.wantUnread)
+			}
+		})
+	}
+
+	message, err := r.GetByID(ctx, ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.DeletedAt == nil || message.EditedAt == nil {
+		t.Errorf("tombstone = %+v, want the edit and delete times", message)
+	}
+}
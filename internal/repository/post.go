@@ -5,16 +5,40 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
+
 	"real-time-forum/internal/model"
 )
 
 type Post interface {
 	Create(ctx context.Context, post model.Post) (int, error)
 	GetByID(ctx context.Context, postID int, userID int) (model.Post, error)
+	Update(ctx context.Context, post model.Post) error
 	Delete(ctx context.Context, userID int, postID int) error
-	GetPostsByCategoryID(ctx context.Context, categoryID int, limit int, offset int) ([]model.Post, error)
-	LikePost(ctx context.Context, like model.PostVotes) (bool, error)
-	DislikePost(ctx context.Context, dislike model.PostVotes) (bool, error)
+	GetPostsByCategoryID(ctx context.Context, feed Feed, cursor Cursor, limit int) ([]model.Post, error)
+}
+
+// FeedOrder is the sort key of posts in a feed, ties go to the newer post.
+type FeedOrder struct {
+	key string // sorted by the id alone when empty
+}
+
+var (
+	NewestPosts = FeedOrder{}
+	TopPosts    = FeedOrder{key: "post.rating"}
+	// votes and comments divided by the squared age in hours at @now, fresh activity outranks old
+	HotPosts = FeedOrder{key: `(post.rating + post.comments_count + 1.0)
+			/ (((julianday(@now) - julianday(post.creation_time)) * 24 + 2)
+			* ((julianday(@now) - julianday(post.creation_time)) * 24 + 2))`}
+	DiscussedPosts = FeedOrder{key: "post.comments_count"}
+)
+
+// Feed selects the posts of a category feed.
+type Feed struct {
+	CategoryID int
+	Order      FeedOrder
+	Since      time.Time // a zero time doesn't limit the age
+	Now        time.Time // the time hot posts are ranked at, the same for every page
 }
 
 type PostRepository struct {
@@ -28,7 +52,7 @@ func NewPost(db *sql.DB) *PostRepository {
 }
 
 func (r *PostRepository) Create(ctx context.Context, post model.Post) (int, error) {
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, fmt.Errorf("repo: create post: %w", err)
 	}
@@ -64,6 +88,7 @@ func (r *PostRepository) Create(ctx context.Context, post model.Post) (int, erro
 			VALUES
 				($1, $2);`)
 		if err != nil {
+			tx.Rollback()
 			return 0, fmt.Errorf("repo: create post: %w", err)
 		}
 
@@ -73,7 +98,7 @@ func (r *PostRepository) Create(ctx context.Context, post model.Post) (int, erro
 			if isForeignKeyConstraintError(err) {
 				return 0, fmt.Errorf("repo: create post: %w", ErrForeignKeyConstraint)
 			}
-			return 0, err
+			return 0, fmt.Errorf("repo: create post: %w", err)
 		}
 	}
 
@@ -86,58 +111,53 @@ func (r *PostRepository) Create(ctx context.Context, post model.Post) (int, erro
 
 func (r *PostRepository) GetByID(ctx context.Context, postID int, userID int) (model.Post, error) {
 	var post model.Post
-	var isPostExists bool
-
-	row := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT id FROM post WHERE id = $1`, postID)
-	if err := row.Scan(&isPostExists); err != nil {
-		return model.Post{}, fmt.Errorf("repo: get post: %w", err)
-	}
 
 	stmt, err := r.db.PrepareContext(ctx, `
 		SELECT
 			post.id,
-			post.user_id AS author_id
-			user.first_name AS author_first_name
-			user.last_name AS author_last_name
-			post.title
-			post.content
-			post.creation_time
-			post.image
-			IFNULL(vote_post.vote, 0) AS user_vote,
-			COUNT(DISTINCT pl.id) - COUNT(DISTINCT pd.id) AS vote
-		FROM 
-			post 
-		LEFT JOIN user 
-		ON post.user_id = user.id 
-		
-		LEFT JOIN vote_post pr 
-		ON pr.post_id = posts.id 
-		AND pr.user_id = $1 
-		
-		LEFT JOIN vote_post pl 
-		ON pl.post_id = posts.id 
-		AND pl.type = $2 
-		
-		LEFT JOIN vote_post pd ON pd.post_id = post.id 
-		AND pd.type = $3
-	WHERE 
-		post.id = $4`)
+			post.user_id AS author_id,
+			user.username AS author_username,
+			user.first_name AS author_first_name,
+			user.last_name AS author_last_name,
+			user.avatar AS author_avatar,
+			post.title,
+			post.content,
+			post.creation_time,
+			IFNULL(post.image, ''),
+			CASE (SELECT vote FROM vote_post WHERE post_id = post.id AND user_id = $1)
+				WHEN 1 THEN 1
+				WHEN -1 THEN 2
+				ELSE 0
+			END AS user_rate,
+			post.rating,
+			post.comments_count
+		FROM
+			post
+		LEFT JOIN user
+		ON post.user_id = user.id
+		WHERE
+			post.id = $2;`)
 	if err != nil {
 		return model.Post{}, fmt.Errorf("repo: get post: %w", err)
 	}
 
-	row = stmt.QueryRowContext(ctx, 1, -1, postID)
+	defer stmt.Close()
+
+	row := stmt.QueryRowContext(ctx, userID, postID)
 	if err := row.Scan(
 		&post.ID,
 		&post.Author.ID,
+		&post.Author.Username,
 		&post.Author.FirstName,
 		&post.Author.LastName,
+		&post.Author.Avatar,
 		&post.Title,
 		&post.Content,
 		&post.CreationTime,
 		&post.ImagePath,
 		&post.UserRate,
 		&post.Rating,
+		&post.CommentsCount,
 	); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.Post{}, ErrNoRows
@@ -150,26 +170,66 @@ func (r *PostRepository) GetByID(ctx context.Context, postID int, userID int) (m
 		return model.Post{}, fmt.Errorf("repo: get post: %w", err)
 	}
 
+	post.Images, err = r.getPostImages(ctx, postID)
+	if err != nil {
+		return model.Post{}, fmt.Errorf("repo: get post: %w", err)
+	}
+
 	return post, nil
 }
 
+func (r *PostRepository) getPostImages(ctx context.Context, postID int) ([]string, error) {
+	var images []string
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			image
+		FROM
+			post_image
+		WHERE
+			post_id = $1
+		ORDER BY
+			id;`,
+		postID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var image string
+
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
 func (r *PostRepository) getPostCategories(postID int) ([]model.Category, error) {
 	var categories []model.Category
 
 	rows, err := r.db.Query(`
 		SELECT
-			id, name
+			id, name, position, archived
 		FROM
-			categories
+			category
 		WHERE
 			id IN (
 					SELECT
 						category_id
 					FROM
-						posts_category
+						post_category
 					WHERE
 						post_id = $1
 			)
+		ORDER BY
+			id
 		`, postID,
 	)
 	if err != nil {
@@ -181,7 +241,7 @@ func (r *PostRepository) getPostCategories(postID int) ([]model.Category, error)
 	for rows.Next() {
 		var category model.Category
 
-		err := rows.Scan(&category.ID, &category.Name)
+		err := rows.Scan(&category.ID, &category.Name, &category.Position, &category.Archived)
 		if err != nil {
 			return nil, err
 		}
@@ -191,6 +251,67 @@ func (r *PostRepository) getPostCategories(postID int) ([]model.Category, error)
 	return categories, nil
 }
 
+// Update replaces title, content and categories of the post.
+func (r *PostRepository) Update(ctx context.Context, post model.Post) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repo: update post: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE
+			post
+		SET
+			title = $1, content = $2
+		WHERE
+			id = $3;`,
+		post.Title, post.Content, post.ID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: update post: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: update post: %w", err)
+	}
+
+	if n == 0 {
+		tx.Rollback()
+		return ErrNoRows
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM post_category WHERE post_id = $1;`, post.ID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("repo: update post: %w", err)
+	}
+
+	for _, category := range post.Categories {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO
+				post_category (post_id, category_id)
+			VALUES
+				($1, $2);`,
+			post.ID, category.ID,
+		)
+		if err != nil {
+			tx.Rollback()
+			if isForeignKeyConstraintError(err) {
+				return fmt.Errorf("repo: update post: %w", ErrForeignKeyConstraint)
+			}
+			return fmt.Errorf("repo: update post: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repo: update post: %w", err)
+	}
+
+	return nil
+}
+
 func (r *PostRepository) Delete(ctx context.Context, userID int, postID int) error {
 	res, err := r.db.Exec(`DELETE FROM post WHERE id = $1 AND user_id = $2;`, postID, userID)
 	if err != nil {
@@ -212,40 +333,64 @@ func (r *PostRepository) Delete(ctx context.Context, userID int, postID int) err
 	return nil
 }
 
-func (r *PostRepository) GetPostsByCategoryID(ctx context.Context, categoryID int, limit int, offset int) ([]model.Post, error) {
+// GetPostsByCategoryID returns a page of the feed with the sort key of every post.
+func (r *PostRepository) GetPostsByCategoryID(ctx context.Context, feed Feed, cursor Cursor, limit int) ([]model.Post, error) {
 	var posts []model.Post
 
-	rows, err := r.db.Query(`
+	// posts are read in the order of the feed index and checked for the category, so a page stops early.
+	// The unary + keeps sqlite off the time index when the age is not limited.
+	window := "post.creation_time >= @since"
+	if feed.Since.IsZero() {
+		window = "+post.creation_time >= @since"
+	}
+
+	key, columns, params := "post.id", []string{"post.id"}, []string{"@id"}
+	if feed.Order.key != "" {
+		key, columns, params = feed.Order.key, []string{feed.Order.key, "post.id"}, []string{"@key", "@id"}
+	}
+
+	page, orderBy := cursor.keyset(columns, params)
+
+	// the feed is built from whitelisted parts, named parameters are left out where they're not used
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
 		SELECT
 			post.id,
 			post.user_id AS author_id,
 			user.first_name AS author_first_name,
 			user.last_name AS author_last_name,
 			post.title,
-			post.creation_time
-		FROM 
+			post.creation_time,
+			post.rating,
+			post.comments_count,
+			%s AS sort_key
+		FROM
 			post
-			LEFT JOIN user ON post.user_id = user.id
+		LEFT JOIN user
+		ON post.user_id = user.id
 		WHERE
-			post.id IN (
+			EXISTS (
 				SELECT
-					post_id
+					1
 				FROM
 					post_category
 				WHERE
-					category_id = $1
+					post_category.post_id = post.id AND post_category.category_id = @category
 			)
-		GROUP BY
-			post.id
-		ORDER BY 
-			post.id DESC
+			AND %s
+			AND %s
+		ORDER BY
+			%s
 		LIMIT
-			$2 OFFSET $3
-		`,
-		categoryID, limit, offset,
+			@limit;`, key, window, page, orderBy),
+		sql.Named("category", feed.CategoryID),
+		sql.Named("since", feed.Since),
+		sql.Named("now", feed.Now),
+		sql.Named("key", cursor.Key),
+		sql.Named("id", cursor.ID),
+		sql.Named("limit", limit),
 	)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("repo: get posts by category: %w", err)
 	}
 
 	defer rows.Close()
@@ -259,9 +404,12 @@ func (r *PostRepository) GetPostsByCategoryID(ctx context.Context, categoryID in
 			&post.Author.LastName,
 			&post.Title,
 			&post.CreationTime,
+			&post.Rating,
+			&post.CommentsCount,
+			&post.SortKey,
 		)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("repo: get posts by category: %w", err)
 		}
 
 		post.Categories, err = r.getPostCategories(post.ID)
@@ -272,72 +420,9 @@ func (r *PostRepository) GetPostsByCategoryID(ctx context.Context, categoryID in
 		posts = append(posts, post)
 	}
 
-	return posts, nil
-}
-
-func (r *PostRepository) LikePost(ctx context.Context, like model.PostVotes) (bool, error) {
-	var isLiked bool
-
-	tx, err := r.db.Begin()
-	if err != nil {
-		return isLiked, err
-	}
-
-	var oldLike model.PostVotes
-
-	row := tx.QueryRowContext(ctx, `
-		SELECT
-			id, post_id, user_id, vote
-		FROM
-			post_vote
-		WHERE
-			post_id = $1
-		AND
-			user_id = $2
-		`,
-		like.PostID, like.UserID)
-
-	err = row.Scan(&oldLike.ID, &oldLike.PostID, &oldLike.UserID, &oldLike.Vote)
-	if err != nil && err != sql.ErrNoRows {
-		tx.Rollback()
-		return isLiked, err
-	}
-
-	if err == nil {
-		_, err = tx.Exec(`DELETE FROM post_vote WHERE id = $1`, oldLike.ID)
-		if err != nil {
-			tx.Rollback()
-			return isLiked, err
-		}
-	}
-
-	if err == sql.ErrNoRows || like.Vote != oldLike.Vote {
-		_, err = tx.ExecContext(ctx, `
-			INSERT INTO 
-				post_vote (post_id, user_id, vote)
-			VALUES
-				($1, $2, $3)
-			`,
-			like.PostID, like.UserID, like.Vote,
-		)
-		if err != nil {
-			tx.Rollback()
-			if isForeignKeyConstraintError(err) {
-				return isLiked, ErrForeignKeyConstraint
-			}
-			return isLiked, err
-		}
-
-		isLiked = true
-	}
-
-	if err := tx.Commit(); err != nil {
-		return isLiked, err
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get posts by category: %w", err)
 	}
 
-	return isLiked, nil
-}
-
-func (r *PostRepository) DislikePost(ctx context.Context, dislike model.PostVotes) (bool, error) {
-	panic("not implemented") // TODO: Implement
+	return inListOrder(posts, cursor), nil
 }
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFeedOrders(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	repo := NewPost(db)
+
+	alice := createUser(t, db, "alice")
+
+	// the age in hours, the rating and the comments of every post
+	posts := []struct {
+		title    string
+		age      int
+		rating   int
+		comments int
+	}{
+		{"old and top", 24 * 40, 9, 0},
+		{"discussed", 24 * 3, 1, 3},
+		{"fresh", 1, 2, 1},
+		{"newest", 0, 0, 0},
+	}
+
+	ids := map[string]int{}
+	for _, post := range posts {
+		id := createPost(t, db, alice, post.title)
+		ids[post.title] = id
+
+		if _, err := db.Exec(`
+			UPDATE post SET creation_time = $1, rating = $2 WHERE id = $3;`,
+			time.Now().Add(-time.Duration(post.age)*time.Hour), post.rating, id,
+		); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := db.Exec(`INSERT INTO post_category (post_id, category_id) VALUES ($1, 1);`, id); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < post.comments; i++ {
+			if _, err := db.Exec(`
+				INSERT INTO comment (post_id, user_id, content, creation_time) VALUES ($1, $2, 'hi', CURRENT_TIMESTAMP);`,
+				id, alice,
+			); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// the trigger follows deleted comments
+	if _, err := db.Exec(`DELETE FROM comment WHERE id = 1;`); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		order FeedOrder
+		age   time.Duration
+		want  []string
+	}{
+		{"newest", NewestPosts, 0, []string{"newest", "fresh", "discussed", "old and top"}},
+		{"top", TopPosts, 0, []string{"old and top", "fresh", "discussed", "newest"}},
+		{"top of the week", TopPosts, 7 * 24 * time.Hour, []string{"fresh", "discussed", "newest"}},
+		{"hot", HotPosts, 0, []string{"fresh", "newest", "discussed", "old and top"}},
+		{"discussed", DiscussedPosts, 0, []string{"discussed", "fresh", "newest", "old and top"}},
+		{"discussed of the day", DiscussedPosts, 24 * time.Hour, []string{"fresh", "newest"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var since time.Time
+			if tt.age > 0 {
+				since = time.Now().Add(-tt.age)
+			}
+
+			got, err := repo.GetPostsByCategoryID(ctx, Feed{CategoryID: 1, Order: tt.order, Since: since, Now: time.Now()}, FirstPage, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d posts, want %v", len(got), tt.want)
+			}
+			for i, post := range got {
+				if post.ID != ids[tt.want[i]] {
+					t.Errorf("post %d = %q, want %q", i, post.Title, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// the next page goes on from the sort key and the id of the last post
+	feed := Feed{CategoryID: 1, Order: TopPosts, Now: time.Now()}
+
+	first, err := repo.GetPostsByCategoryID(ctx, feed, FirstPage, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := first[len(first)-1]
+
+	next, err := repo.GetPostsByCategoryID(ctx, feed, Cursor{Key: last.SortKey, ID: last.ID}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next) != 2 || next[0].ID != ids["discussed"] || next[1].ID != ids["newest"] {
+		t.Errorf("next page = %+v, want discussed and newest", next)
+	}
+
+	back, err := repo.GetPostsByCategoryID(ctx, feed, Cursor{Key: next[0].SortKey, ID: next[0].ID, Before: true}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != 2 || back[0].ID != first[0].ID || back[1].ID != first[1].ID {
+		t.Errorf("previous page = %+v, want the first one", back)
+	}
+
+	post, err := repo.GetByID(ctx, ids["discussed"], alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.CommentsCount != 2 {
+		t.Errorf("comments count = %d, want 2", post.CommentsCount)
+	}
+}
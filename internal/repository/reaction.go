@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+// ReactionTarget describes the table of reactions on a post, a comment or a message.
+type ReactionTarget struct {
+	reactionsTable string
+	column         string
+}
+
+var (
+	PostReactions    = ReactionTarget{reactionsTable: "reaction_post", column: "post_id"}
+	CommentReactions = ReactionTarget{reactionsTable: "reaction_comment", column: "comment_id"}
+	MessageReactions = ReactionTarget{reactionsTable: "reaction_message", column: "message_id"}
+)
+
+type Reaction interface {
+	Add(ctx context.Context, target ReactionTarget, reaction model.Reaction, creationTime time.Time) error
+	Remove(ctx context.Context, target ReactionTarget, reaction model.Reaction) error
+	GetCounts(ctx context.Context, target ReactionTarget, targetID int) ([]model.ReactionCount, error)
+	GetUserReactions(ctx context.Context, target ReactionTarget, targetID int, userID int) ([]string, error)
+	GetUsers(ctx context.Context, target ReactionTarget, targetID int, emoji string) ([]model.User, error)
+}
+
+type ReactionRepository struct {
+	db *sql.DB
+}
+
+func NewReaction(db *sql.DB) *ReactionRepository {
+	return &ReactionRepository{
+		db: db,
+	}
+}
+
+// Add adds the reaction, adding the same reaction again does nothing.
+func (r *ReactionRepository) Add(ctx context.Context, target ReactionTarget, reaction model.Reaction, creationTime time.Time) error {
+	if _, err := r.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO
+			%[1]s (user_id, %[2]s, emoji, creation_time)
+		VALUES
+			($1, $2, $3, $4)
+		ON CONFLICT (%[2]s, user_id, emoji) DO NOTHING;`, target.reactionsTable, target.column),
+		reaction.UserID, reaction.TargetID, reaction.Emoji, creationTime,
+	); err != nil {
+		if isForeignKeyConstraintError(err) {
+			return ErrForeignKeyConstraint
+		}
+		return fmt.Errorf("repo: add reaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ReactionRepository) Remove(ctx context.Context, target ReactionTarget, reaction model.Reaction) error {
+	if _, err := r.db.ExecContext(ctx, fmt.Sprintf(`
+		DELETE FROM
+			%s
+		WHERE
+			user_id = $1 AND %s = $2 AND emoji = $3;`, target.reactionsTable, target.column),
+		reaction.UserID, reaction.TargetID, reaction.Emoji,
+	); err != nil {
+		return fmt.Errorf("repo: remove reaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetCounts returns the number of reactions with every emoji, the most used go first.
+func (r *ReactionRepository) GetCounts(ctx context.Context, target ReactionTarget, targetID int) ([]model.ReactionCount, error) {
+	counts := make([]model.ReactionCount, 0)
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			emoji, COUNT(*) AS count
+		FROM
+			%s
+		WHERE
+			%s = $1
+		GROUP BY
+			emoji
+		ORDER BY
+			count DESC, MIN(creation_time);`, target.reactionsTable, target.column),
+		targetID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get reaction counts: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var count model.ReactionCount
+
+		if err := rows.Scan(&count.Emoji, &count.Count); err != nil {
+			return nil, fmt.Errorf("repo: get reaction counts: %w", err)
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *ReactionRepository) GetUserReactions(ctx context.Context, target ReactionTarget, targetID int, userID int) ([]string, error) {
+	var emoji []string
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			emoji
+		FROM
+			%s
+		WHERE
+			%s = $1 AND user_id = $2
+		ORDER BY
+			creation_time;`, target.reactionsTable, target.column),
+		targetID, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get user reactions: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var e string
+
+		if err := rows.Scan(&e); err != nil {
+			return nil, fmt.Errorf("repo: get user reactions: %w", err)
+		}
+
+		emoji = append(emoji, e)
+	}
+
+	return emoji, rows.Err()
+}
+
+// GetUsers returns who reacted with the emoji, in the order they reacted.
+func (r *ReactionRepository) GetUsers(ctx context.Context, target ReactionTarget, targetID int, emoji string) ([]model.User, error) {
+	users := make([]model.User, 0)
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			user.id,
+			user.username,
+			user.first_name,
+			user.last_name,
+			user.avatar
+		FROM
+			%[1]s
+		INNER JOIN user
+		ON user.id = %[1]s.user_id
+		WHERE
+			%[1]s.%[2]s = $1 AND %[1]s.emoji = $2
+		ORDER BY
+			%[1]s.creation_time;`, target.reactionsTable, target.column),
+		targetID, emoji,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: get reaction users: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var user model.User
+
+		if err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.FirstName,
+			&user.LastName,
+			&user.Avatar,
+		); err != nil {
+			return nil, fmt.Errorf("repo: get reaction users: %w", err)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+func TestReactions(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	r := NewReaction(db)
+
+	alice := createUser(t, db, "alice")
+	bob := createUser(t, db, "bob")
+	postID := createPost(t, db, alice, "first")
+
+	start := time.Now()
+
+	steps := []struct {
+		name       string
+		remove     bool
+		reaction   model.Reaction
+		wantCounts []model.ReactionCount
+	}{
+		{"first emoji", false, model.Reaction{UserID: alice, TargetID: postID, Emoji: "🎉"},
+			[]model.ReactionCount{{Emoji: "🎉", Count: 1}}},
+		{"second emoji goes after the first", false, model.Reaction{UserID: alice, TargetID: postID, Emoji: "👍"},
+			[]model.ReactionCount{{Emoji: "🎉", Count: 1}, {Emoji: "👍", Count: 1}}},
+		{"the same reaction again", false, model.Reaction{UserID: alice, TargetID: postID, Emoji: "👍"},
+			[]model.ReactionCount{{Emoji: "🎉", Count: 1}, {Emoji: "👍", Count: 1}}},
+		{"most used go first", false, model.Reaction{UserID: bob, TargetID: postID, Emoji: "👍"},
+			[]model.ReactionCount{{Emoji: "👍", Count: 2}, {Emoji: "🎉", Count: 1}}},
+		{"remove", true, model.Reaction{UserID: alice, TargetID: postID, Emoji: "🎉"},
+			[]model.ReactionCount{{Emoji: "👍", Count: 2}}},
+		{"remove a missing reaction", true, model.Reaction{UserID: bob, TargetID: postID, Emoji: "🎉"},
+			[]model.ReactionCount{{Emoji: "👍", Count: 2}}},
+	}
+
+	for i, step := range steps {
+		var err error
+		if step.remove {
+			err = r.Remove(ctx, PostReactions, step.reaction)
+		} else {
+			err = r.Add(ctx, PostReactions, step.reaction, start.Add(time.Duration(i)*time.Second))
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		counts, err := r.GetCounts(ctx, PostReactions, postID)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if !reflect.DeepEqual(counts, step.wantCounts) {
+			t.Errorf("%s: counts = %v, want %v", step.name, counts, step.wantCounts)
+		}
+	}
+
+	emoji, err := r.GetUserReactions(ctx, PostReactions, postID, alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(emoji, []string{"👍"}) {
+		t.Errorf("user reactions = %v, want [👍]", emoji)
+	}
+
+	users, err := r.GetUsers(ctx, PostReactions, postID, "👍")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].ID != alice || users[1].ID != bob {
+		t.Errorf("users = %+v, want alice and bob", users)
+	}
+
+	// the reactions on comments are kept apart from the ones on posts
+	counts, err := r.GetCounts(ctx, CommentReactions, postID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 0 {
+		t.Errorf("comment counts = %v, want none", counts)
+	}
+}
+
+func TestReactOnMissingTarget(t *testing.T) {
+	db := newTestDB(t)
+	r := NewReaction(db)
+
+	alice := createUser(t, db, "alice")
+
+	err := r.Add(context.Background(), PostReactions, model.Reaction{UserID: alice, TargetID: 42, Emoji: "🎉"}, time.Now())
+	if err != ErrForeignKeyConstraint {
+		t.Errorf("Add() error = %v, want %v", err, ErrForeignKeyConstraint)
+	}
+}
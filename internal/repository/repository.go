@@ -3,11 +3,29 @@ package repository
 import "database/sql"
 
 type Repository struct {
-	User User
+	User         User
+	Post         Post
+	Comment      Comment
+	Vote         Vote
+	Message      Message
+	Category     Category
+	Conversation Conversation
+	Reaction     Reaction
+	Image        Image
+	Search       Search
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	return &Repository{
-		User: NewUser(db),
+		User:         NewUser(db),
+		Post:         NewPost(db),
+		Comment:      NewComment(db),
+		Vote:         NewVote(db),
+		Message:      NewMessage(db),
+		Category:     NewCategory(db),
+		Conversation: NewConversation(db),
+		Reaction:     NewReaction(db),
+		Image:        NewImage(db),
+		Search:       NewSearch(db),
 	}
 }
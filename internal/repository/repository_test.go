@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"real-time-forum/database/migrations"
+	"real-time-forum/pkg/migrate"
+	"real-time-forum/pkg/sqlite"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestDB opens an empty database with every migration of the forum applied.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "forum.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	// search needs sqlite built with the sqlite_fts5 tag
+	if err := sqlite.CheckFTS5(db); err != nil {
+		if errors.Is(err, sqlite.ErrNoFTS5) {
+			t.Skip(err)
+		}
+		t.Fatal(err)
+	}
+
+	migrator, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
+
+// createUser adds a user with the username and returns its id.
+func createUser(t *testing.T, db *sql.DB, username string) int {
+	t.Helper()
+
+	res, err := db.Exec(`
+		INSERT INTO
+			user (email, username, first_name, last_name, age, gender, password, avatar, creation_time)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+		username+"@example.com", username, username, "Test", 20, "Male", "hash", "default.jpg", time.Now(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return int(id)
+}
+
+// createPost adds a post of the user and returns its id.
+func createPost(t *testing.T, db *sql.DB, userID int, title string) int {
+	t.Helper()
+
+	res, err := db.Exec(`
+		INSERT INTO
+			post (user_id, title, content, creation_time)
+		VALUES
+			($1, $2, $3, $4);`,
+		userID, title, title+" content", time.Now(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return int(id)
+}
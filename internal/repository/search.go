@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"real-time-forum/internal/model"
+)
+
+// Matched terms are wrapped in these control characters by highlight() and snippet(). Control characters
+// are stripped from posts and comments when they are written, so the markers are only the ones added
+// by the search and the text can be escaped before they are turned into tags.
+const (
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+// SearchFilter narrows down the search, zero values don't filter.
+type SearchFilter struct {
+	Match      string // FTS5 query expression
+	CategoryID int
+	Author     string // username
+}
+
+type Search interface {
+	Search(ctx context.Context, filter SearchFilter, limit int, offset int) ([]model.SearchResult, error)
+	SearchUsers(ctx context.Context, prefix string, exceptUserID int, limit int) ([]model.User, error)
+}
+
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func NewSearch(db *sql.DB) *SearchRepository {
+	return &SearchRepository{
+		db: db,
+	}
+}
+
+// Search returns posts and comments matching the filter, the best matches go first.
+// Title matches weigh more than matches in the text.
+func (r *SearchRepository) Search(ctx context.Context, filter SearchFilter, limit int, offset int) ([]model.SearchResult, error) {
+	results := make([]model.SearchResult, 0)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			result.type,
+			result.id,
+			result.post_id,
+			result.title,
+			result.snippet,
+			user.id,
+			user.username,
+			user.first_name,
+			user.last_name,
+			user.avatar,
+			result.creation_time
+		FROM (
+			SELECT
+				'post' AS type,
+				post.id,
+				post.id AS post_id,
+				highlight(post_fts, 0, char(2), char(3)) AS title,
+				snippet(post_fts, 1, char(2), char(3), '…', 32) AS snippet,
+				post.user_id,
+				post.creation_time,
+				bm25(post_fts, 4.0, 1.0) AS rank
+			FROM
+				post_fts
+			INNER JOIN post
+			ON post.id = post_fts.rowid
+			WHERE
+				post_fts MATCH $1
+			UNION ALL
+			SELECT
+				'comment' AS type,
+				comment.id,
+				comment.post_id,
+				post.title,
+				snippet(comment_fts, 0, char(2), char(3), '…', 32) AS snippet,
+				comment.user_id,
+				comment.creation_time,
+				bm25(comment_fts) AS rank
+			FROM
+				comment_fts
+			INNER JOIN comment
+			ON comment.id = comment_fts.rowid
+			INNER JOIN post
+			ON post.id = comment.post_id
+			WHERE
+				comment_fts MATCH $1
+		) AS result
+		INNER JOIN user
+		ON user.id = result.user_id
+		WHERE
+			($2 = 0 OR result.post_id IN (SELECT post_id FROM post_category WHERE category_id = $2))
+			AND ($3 = '' OR user.username = $3 COLLATE NOCASE)
+		ORDER BY
+			result.rank, result.creation_time DESC
+		LIMIT
+			$4 OFFSET $5;`,
+		filter.Match, filter.CategoryID, filter.Author, limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: search: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var result model.SearchResult
+
+		if err := rows.Scan(
+			&result.Type,
+			&result.ID,
+			&result.PostID,
+			&result.Title,
+			&result.Snippet,
+			&result.Author.ID,
+			&result.Author.Username,
+			&result.Author.FirstName,
+			&result.Author.LastName,
+			&result.Author.Avatar,
+			&result.CreationTime,
+		); err != nil {
+			return nil, fmt.Errorf("repo: search: %w", err)
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: search: %w", err)
+	}
+
+	return results, nil
+}
+
+// SearchUsers returns users whose username, first name, last name or full name starts with the prefix,
+// the prefix is a LIKE pattern escaped with '\'.
+func (r *SearchRepository) SearchUsers(ctx context.Context, prefix string, exceptUserID int, limit int) ([]model.User, error) {
+	users := make([]model.User, 0)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			id,
+			username,
+			first_name,
+			last_name,
+			avatar,
+			last_seen
+		FROM
+			user
+		WHERE
+			id != $1
+			AND (
+				username LIKE $2 ESCAPE '\'
+				OR first_name LIKE $2 ESCAPE '\'
+				OR last_name LIKE $2 ESCAPE '\'
+				OR first_name || ' ' || last_name LIKE $2 ESCAPE '\'
+			)
+		ORDER BY
+			username LIKE $2 ESCAPE '\' DESC, username COLLATE NOCASE
+		LIMIT
+			$3;`,
+		exceptUserID, prefix, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("repo: search users: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var user model.User
+
+		if err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.FirstName,
+			&user.LastName,
+			&user.Avatar,
+			&user.LastSeen,
+		); err != nil {
+			return nil, fmt.Errorf("repo: search users: %w", err)
+		}
+
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	r := NewSearch(db)
+
+	alice := createUser(t, db, "alice")
+	bob := createUser(t, db, "bob")
+	goPost := createPost(t, db, alice, "Golang channels")
+	rustPost := createPost(t, db, bob, "Rust traits")
+
+	if _, err := db.Exec(`
+		INSERT INTO
+			comment (post_id, user_id, content, creation_time)
+		VALUES
+			($1, $2, 'goroutines and golang', CURRENT_TIMESTAMP);`,
+		rustPost, bob,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`UPDATE post SET title = 'Rust lifetimes', content = 'borrowing' WHERE id = $1;`, rustPost); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		filter    SearchFilter
+		wantTypes []string
+		wantIDs   []int
+	}{
+		{"title match goes first", SearchFilter{Match: `"golang"`}, []string{"post", "comment"}, []int{goPost, 1}},
+		{"prefix", SearchFilter{Match: `"gorout"*`}, []string{"comment"}, []int{1}},
+		{"diacritics are removed", SearchFilter{Match: `"gölang"`}, []string{"post", "comment"}, []int{goPost, 1}},
+		{"author", SearchFilter{Match: `"golang"`, Author: "BOB"}, []string{"comment"}, []int{1}},
+		{"updated title", SearchFilter{Match: `"lifetimes"`}, []string{"post"}, []int{rustPost}},
+		{"old text is gone", SearchFilter{Match: `"traits"`}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := r.Search(ctx, tt.filter, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != len(tt.wantIDs) {
+				t.Fatalf("results = %+v, want %v %v", results, tt.wantTypes, tt.wantIDs)
+			}
+			for i, result := range results {
+				if result.Type != tt.wantTypes[i] || result.ID != tt.wantIDs[i] {
+					t.Errorf("result %d = %s %d, want %s %d", i, result.Type, result.ID, tt.wantTypes[i], tt.wantIDs[i])
+				}
+			}
+		})
+	}
+
+	results, err := r.Search(ctx, SearchFilter{Match: `"channels"`}, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Title != "Golang "+MatchStart+"channels"+MatchEnd {
+		t.Errorf("results = %+v, want the highlighted title", results)
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	db := newTestDB(t)
+	r := NewSearch(db)
+
+	alice := createUser(t, db, "alice")
+	createUser(t, db, "alfred")
+	createUser(t, db, "bob")
+
+	tests := []struct {
+		name   string
+		prefix string
+		except int
+		want   []string
+	}{
+		{"username prefix", "al%", 0, []string{"alfred", "alice"}},
+		{"except the user", "al%", alice, []string{"alfred"}},
+		{"case is ignored", "BO%", 0, []string{"bob"}},
+		{"last name", "Test%", alice, []string{"alfred", "bob"}},
+		{"escaped wildcard", `\%%`, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := r.SearchUsers(context.Background(), tt.prefix, tt.except, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, user := range users {
+				got = append(got, user.Username)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("users = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("users = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
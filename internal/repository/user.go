@@ -4,18 +4,32 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"real-time-forum/internal/model"
 )
 
 type User interface {
 	Create(ctx context.Context, user model.User) error
-	GetByCredentials(ctx context.Context, usernameOrEmail, password string) (model.User, error)
+	GetByCredentials(ctx context.Context, usernameOrEmail string) (model.User, error)
+	UpdatePassword(ctx context.Context, userID int, password string) error
+	UpdateProfile(ctx context.Context, user model.User) error
+	UpdateUsername(ctx context.Context, userID int, username string, changedAt time.Time, changedBefore time.Time) error
+	SetEmailVerification(ctx context.Context, verification model.EmailVerification) error
+	GetEmailVerification(ctx context.Context, token string) (model.EmailVerification, error)
+	GetPendingEmail(ctx context.Context, userID int) (string, error)
+	UpdateEmail(ctx context.Context, userID int, email string) error
+	UpdateLastSeen(ctx context.Context, userID int, lastSeen time.Time) error
 	GetByID(ctx context.Context, userID int) (model.User, error)
-	GetUsersPosts(ctx context.Context, userID int) ([]model.Post, error)
-	GetUsersVotedPosts(ctx context.Context, userID int) ([]model.Post, error)
+	GetUsersPosts(ctx context.Context, userID int, cursor Cursor, limit int) ([]model.Post, error)
+	GetUsersVotedPosts(ctx context.Context, userID int, cursor Cursor, limit int) ([]model.Post, error)
 	SetSession(ctx context.Context, session model.Session) error
+	GetSession(ctx context.Context, token string) (model.Session, error)
+	UseSession(ctx context.Context, token string) error
 	DeleteSession(ctx context.Context, userID int) error
+	DeleteSessionFamily(ctx context.Context, family string) error
+	HasLiveSession(ctx context.Context, family string) (bool, error)
+	DeleteExpiredSessions(ctx context.Context, userID int) error
 }
 
 type UserRepository struct {
@@ -28,6 +42,7 @@ func NewUser(db *sql.DB) *UserRepository {
 	}
 }
 
+// Create adds the user, a taken email is ErrEmailExists and a taken username is ErrUserExists.
 func (r *UserRepository) Create(ctx context.Context, user model.User) error {
 	stmt, err := r.db.PrepareContext(ctx, `
 		INSERT INTO 
@@ -54,6 +69,10 @@ func (r *UserRepository) Create(ctx context.Context, user model.User) error {
 		user.CreationTime,
 	)
 
+	if isColumnTaken(err, "user.email") {
+		return ErrEmailExists
+	}
+
 	if isAlreadyExists(err) {
 		return ErrUserExists
 	}
@@ -61,24 +80,23 @@ func (r *UserRepository) Create(ctx context.Context, user model.User) error {
 	return err
 }
 
-func (r *UserRepository) GetByCredentials(ctx context.Context, usernameOrEmail string, password string) (model.User, error) {
+// GetByCredentials finds the user by username or email, the password hash is verified by the caller.
+func (r *UserRepository) GetByCredentials(ctx context.Context, usernameOrEmail string) (model.User, error) {
 	var user model.User
 
 	stmt, err := r.db.PrepareContext(ctx, `
 		SELECT 
-			id, email, username, password, first_name, last_name, age, gender, avatar, creation_time
+			id, email, username, password, first_name, last_name, age, gender, avatar, creation_time, role
 		FROM 
 			user
 		WHERE 
-			(username = $1 OR email = $1)
-		AND
-			(password = $2);`)
+			(username = $1 OR email = LOWER($1));`)
 	if err != nil {
 		return model.User{}, err
 	}
 	defer stmt.Close()
 
-	row := stmt.QueryRowContext(ctx, usernameOrEmail, password)
+	row := stmt.QueryRowContext(ctx, usernameOrEmail)
 	err = row.Scan(
 		&user.ID,
 		&user.Email,
@@ -90,6 +108,7 @@ func (r *UserRepository) GetByCredentials(ctx context.Context, usernameOrEmail s
 		&user.Gender,
 		&user.Avatar,
 		&user.CreationTime,
+		&user.Role,
 	)
 
 	if isNoRowsError(err) {
@@ -99,12 +118,210 @@ func (r *UserRepository) GetByCredentials(ctx context.Context, usernameOrEmail s
 	return user, err
 }
 
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, password string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			user
+		SET
+			password = $1
+		WHERE
+			id = $2;`, password, userID)
+	if err != nil {
+		return fmt.Errorf("repo: update password: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo: update password: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateProfile(ctx context.Context, user model.User) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			user
+		SET
+			first_name = $1,
+			last_name = $2,
+			age = $3,
+			gender = $4
+		WHERE
+			id = $5;`,
+		user.FirstName, user.LastName, user.Age, user.Gender, user.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("repo: update profile: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo: update profile: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	return nil
+}
+
+// UpdateUsername changes the username unless it was already changed after changedBefore,
+// ErrNoRows means it was. A username taken by someone else is ErrUserExists.
+func (r *UserRepository) UpdateUsername(ctx context.Context, userID int, username string, changedAt time.Time, changedBefore time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			user
+		SET
+			username = $1,
+			username_changed_at = $2
+		WHERE
+			id = $3 AND (username_changed_at IS NULL OR username_changed_at < $4);`,
+		username, changedAt, userID, changedBefore,
+	)
+	if err != nil {
+		if isAlreadyExists(err) {
+			return ErrUserExists
+		}
+		return fmt.Errorf("repo: update username: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo: update username: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	return nil
+}
+
+// SetEmailVerification replaces the pending email change of the user.
+func (r *UserRepository) SetEmailVerification(ctx context.Context, verification model.EmailVerification) error {
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO
+			email_verification (user_id, email, token, expiration_time)
+		VALUES
+			($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			email = excluded.email,
+			token = excluded.token,
+			expiration_time = excluded.expiration_time;`,
+		verification.UserID, verification.Email, verification.Token, verification.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("repo: set email verification: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) GetEmailVerification(ctx context.Context, token string) (model.EmailVerification, error) {
+	var verification model.EmailVerification
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			user_id, email, token, expiration_time
+		FROM
+			email_verification
+		WHERE
+			token = $1;`, token).Scan(
+		&verification.UserID,
+		&verification.Email,
+		&verification.Token,
+		&verification.ExpiresAt,
+	)
+	if err != nil {
+		if isNoRowsError(err) {
+			return model.EmailVerification{}, ErrNoRows
+		}
+		return model.EmailVerification{}, fmt.Errorf("repo: get email verification: %w", err)
+	}
+
+	return verification, nil
+}
+
+// GetPendingEmail returns the email waiting for verification, empty when there's none or it has expired.
+func (r *UserRepository) GetPendingEmail(ctx context.Context, userID int) (string, error) {
+	var email string
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			email
+		FROM
+			email_verification
+		WHERE
+			user_id = $1 AND expiration_time > $2;`, userID, time.Now()).Scan(&email)
+	if err != nil && !isNoRowsError(err) {
+		return "", fmt.Errorf("repo: get pending email: %w", err)
+	}
+
+	return email, nil
+}
+
+// UpdateEmail sets the verified email and drops the pending change. An email taken by someone else is ErrEmailExists.
+func (r *UserRepository) UpdateEmail(ctx context.Context, userID int, email string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("repo: update email: %w", err)
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE
+			user
+		SET
+			email = $1
+		WHERE
+			id = $2;`, email, userID); err != nil {
+		if isAlreadyExists(err) {
+			return ErrEmailExists
+		}
+		return fmt.Errorf("repo: update email: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM
+			email_verification
+		WHERE
+			user_id = $1;`, userID); err != nil {
+		return fmt.Errorf("repo: update email: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("repo: update email: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) UpdateLastSeen(ctx context.Context, userID int, lastSeen time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE
+			user
+		SET
+			last_seen = $1
+		WHERE
+			id = $2;`, lastSeen, userID); err != nil {
+		return fmt.Errorf("repo: update last seen: %w", err)
+	}
+
+	return nil
+}
+
 func (r *UserRepository) GetByID(ctx context.Context, userID int) (model.User, error) {
 	var user model.User
 
 	stmt, err := r.db.PrepareContext(ctx, `
 	SELECT
-		id, email, username, password, first_name, last_name, age, gender, avatar, creation_time
+		id, email, username, password, first_name, last_name, age, gender, avatar, creation_time, role, last_seen
 	FROM
 		user
 	WHERE
@@ -127,140 +344,99 @@ func (r *UserRepository) GetByID(ctx context.Context, userID int) (model.User, e
 		&user.Gender,
 		&user.Avatar,
 		&user.CreationTime,
+		&user.Role,
+		&user.LastSeen,
 	)
 
 	if isNoRowsError(err) {
 		return model.User{}, ErrNoRows
 	}
 
-	return model.User{}, err
+	return user, err
 }
 
-// GetUsersPosts method receives all posts created by userId
-func (r *UserRepository) GetUsersPosts(ctx context.Context, userID int) ([]model.Post, error) {
-	var isUserExists bool
-
-	tx, err := r.db.Begin()
-	if err != nil {
-		return nil, fmt.Errorf("repo: get users posts: transaction: begin: %w", err)
-	}
-
-	stmt, err := tx.PrepareContext(ctx, `SELECT EXISTS (SELECT id FROM user WHERE id = $1);`)
-	if err != nil {
-		if err = tx.Rollback(); err != nil {
-			return nil, fmt.Errorf("repo: get users posts: rollback: %w", err)
-		}
-		return nil, fmt.Errorf("repo: get users posts: prepare statement 1: %w", err)
-	}
-
-	defer stmt.Close()
-
-	if err := stmt.QueryRowContext(ctx, userID).Scan(&isUserExists); err != nil {
-		return nil, fmt.Errorf("repo: get users posts: row scan: %w", err)
-	}
-
-	if !isUserExists {
-		return nil, ErrUserExists
+// GetUsersPosts returns a page of posts created by the user, newest first.
+func (r *UserRepository) GetUsersPosts(ctx context.Context, userID int, cursor Cursor, limit int) ([]model.Post, error) {
+	if err := r.checkExists(ctx, userID); err != nil {
+		return nil, fmt.Errorf("repo: get users posts: %w", err)
 	}
 
 	var posts []model.Post
 
-	stmt, err = tx.PrepareContext(ctx, `
-		SELECT 
-			post.id, 
-			post.title, 
-			post.content, 
-			post.creation_time, 
-			post.image, 
+	page, orderBy := cursor.keyset([]string{"post.id"}, []string{"$2"})
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT
+			post.id,
+			post.title,
+			post.content,
+			post.creation_time,
+			IFNULL(post.image, ''),
+			post.rating,
+			post.comments_count,
 			user.id AS author_id,
-			user.username AS author_username, 
-			user.first_name AS author_first_name, 
-			user.last_name AS author_last_name, 
+			user.username AS author_username,
+			user.first_name AS author_first_name,
+			user.last_name AS author_last_name,
 			user.avatar AS author_avatar
-		FROM 
-			post LEFT JOIN user ON post.user_id = user.id
-		WHERE 
-			post.user_id = $1
-		ORDER BY 1 DESC;`)
-	if err != nil {
-		if err = tx.Rollback(); err != nil {
-			return nil, fmt.Errorf("repo: get users posts: rollback: %w", err)
-		}
-		return nil, fmt.Errorf("repo: get users posts: prepare statement 2: %w", err)
-	}
-
-	defer stmt.Close()
-
-	rows, err := stmt.QueryContext(ctx, userID)
+		FROM
+			post
+		LEFT JOIN user
+		ON post.user_id = user.id
+		WHERE
+			post.user_id = $1 AND %s
+		ORDER BY
+			%s
+		LIMIT $3;`, page, orderBy),
+		userID, cursor.ID, limit,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("get users posts: exec statemnt: %w", err)
+		return nil, fmt.Errorf("repo: get users posts: %w", err)
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
 		var post model.Post
-		err := rows.Scan(
+
+		if err := rows.Scan(
 			&post.ID,
 			&post.Title,
 			&post.Content,
 			&post.CreationTime,
 			&post.ImagePath,
+			&post.Rating,
+			&post.CommentsCount,
 			&post.Author.ID,
 			&post.Author.Username,
 			&post.Author.FirstName,
 			&post.Author.LastName,
 			&post.Author.Avatar,
-		)
-		if err != nil {
-			return nil, err
+		); err != nil {
+			return nil, fmt.Errorf("repo: get users posts: %w", err)
 		}
 
 		posts = append(posts, post)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("repo: get users posts: transaction: commit %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get users posts: %w", err)
 	}
 
-	return posts, nil
+	return inListOrder(posts, cursor), nil
 }
 
-func (r *UserRepository) GetUsersVotedPosts(ctx context.Context, userID int) ([]model.Post, error) {
-	var isUserExists bool
-
-	tx, err := r.db.Begin()
-	if err != nil {
-		return nil, fmt.Errorf("repo: get users voted posts: transaction: begin: %w", err)
-	}
-
-	stmt, err := tx.PrepareContext(ctx, `SELECT EXISTS (SELECT id FROM user WHERE id = $1);`)
-	if err != nil {
-		if err = tx.Rollback(); err != nil {
-			return nil, fmt.Errorf("repo: get users posts: rollback: %w", err)
-		}
-		return nil, fmt.Errorf("repo: get users voted posts: prepare statement 1: %w", err)
-	}
-
-	defer stmt.Close()
-
-	if err := stmt.QueryRowContext(ctx, userID).Scan(&isUserExists); err != nil {
-		if err = tx.Rollback(); err != nil {
-			return nil, fmt.Errorf("repo: get users posts: rollback: %w", err)
-		}
-		return nil, fmt.Errorf("repo: get users voted posts: row scan: %w", err)
-	}
-
-	if !isUserExists {
-		if err = tx.Rollback(); err != nil {
-			return nil, fmt.Errorf("repo: get users posts: rollback: %w", err)
-		}
-		return nil, ErrUserExists
+// GetUsersVotedPosts returns a page of posts the user voted for with the vote in userRate, newest posts first.
+func (r *UserRepository) GetUsersVotedPosts(ctx context.Context, userID int, cursor Cursor, limit int) ([]model.Post, error) {
+	if err := r.checkExists(ctx, userID); err != nil {
+		return nil, fmt.Errorf("repo: get users voted posts: %w", err)
 	}
 
 	var posts []model.Post
 
-	stmt, err = tx.PrepareContext(ctx, `
+	page, orderBy := cursor.keyset([]string{"post.id"}, []string{"$2"})
+
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`
 		SELECT
 			post.id,
 			post.user_id AS author_id,
@@ -268,43 +444,32 @@ func (r *UserRepository) GetUsersVotedPosts(ctx context.Context, userID int) ([]
 			user.last_name AS author_last_name,
 			post.title,
 			post.creation_time,
-			vote_post.vote AS user_vote
+			post.rating,
+			post.comments_count,
+			CASE vote_post.vote WHEN 1 THEN 1 WHEN -1 THEN 2 ELSE 0 END AS user_rate
 		FROM
-			post
-		LEFT JOIN 
-			user ON post.user_id = user.id
-		LEFT JOIN 
-			vote_post ON post.id = vote_post.post_id AND vote_post.user_id = $1
+			vote_post
+		INNER JOIN post
+		ON post.id = vote_post.post_id
+		LEFT JOIN user
+		ON post.user_id = user.id
 		WHERE
-			post.id IN (
-				SELECT
-					post_id
-				FROM
-					vote_post
-				WHERE
-					user_id = $1
-			)
-			ORDER BY post.id DESC;
-		`)
+			vote_post.user_id = $1 AND %s
+		ORDER BY
+			%s
+		LIMIT $3;`, page, orderBy),
+		userID, cursor.ID, limit,
+	)
 	if err != nil {
-		if err = tx.Rollback(); err != nil {
-			return nil, fmt.Errorf("repo: get users posts: rollback: %w", err)
-		}
-		return nil, fmt.Errorf("repo: get users voted posts: prepare %w", err)
+		return nil, fmt.Errorf("repo: get users voted posts: %w", err)
 	}
 
-	rows, err := stmt.QueryContext(ctx, userID)
-	if err != nil {
-		if err = tx.Rollback(); err != nil {
-			return nil, fmt.Errorf("repo: get users posts: rollback: %w", err)
-		}
-		return nil, fmt.Errorf("repo: get users voted posts: query %w", err)
-	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var post model.Post
 
-		err := rows.Scan(
+		if err := rows.Scan(
 			&post.ID,
 			&post.Author.ID,
 			&post.Author.FirstName,
@@ -312,37 +477,54 @@ func (r *UserRepository) GetUsersVotedPosts(ctx context.Context, userID int) ([]
 			&post.Title,
 			&post.CreationTime,
 			&post.Rating,
-		)
-		if err != nil {
-			if err = tx.Rollback(); err != nil {
-				return nil, fmt.Errorf("repo: get users posts: rollback: %w", err)
-			}
-			return nil, fmt.Errorf("repo: get users voted posts: scan %w", err)
+			&post.CommentsCount,
+			&post.UserRate,
+		); err != nil {
+			return nil, fmt.Errorf("repo: get users voted posts: %w", err)
 		}
 
 		posts = append(posts, post)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("repo: get users voted posts: commit %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("repo: get users voted posts: %w", err)
 	}
 
-	return posts, nil
+	return inListOrder(posts, cursor), nil
+}
+
+// checkExists tells a user without posts from a missing one.
+func (r *UserRepository) checkExists(ctx context.Context, userID int) error {
+	var exists bool
+
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT
+			EXISTS (SELECT 1 FROM user WHERE id = $1);`,
+		userID,
+	).Scan(&exists); err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNoRows
+	}
+
+	return nil
 }
 
 func (r *UserRepository) SetSession(ctx context.Context, session model.Session) error {
 	stmt, err := r.db.PrepareContext(ctx, `
 		INSERT INTO
-			session_tokens (user_id, token, token_expiration_time)
+			session_token (user_id, token, family, token_expiration_time)
 		VALUES
-			($1, $2, $3);`)
+			($1, $2, $3, $4);`)
 	if err != nil {
 		return fmt.Errorf("repo: set session: %w", err)
 	}
 
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, session.UserID, session.Token, session.ExpiresAt)
+	_, err = stmt.ExecContext(ctx, session.UserID, session.Token, session.Family, session.ExpiresAt)
 	if err != nil {
 		return fmt.Errorf("repo: set session: %w", err)
 	}
@@ -350,10 +532,65 @@ func (r *UserRepository) SetSession(ctx context.Context, session model.Session)
 	return nil
 }
 
+func (r *UserRepository) GetSession(ctx context.Context, token string) (model.Session, error) {
+	var session model.Session
+
+	stmt, err := r.db.PrepareContext(ctx, `
+		SELECT
+			user_id, token, family, used, token_expiration_time
+		FROM
+			session_token
+		WHERE
+			token = $1;`)
+	if err != nil {
+		return model.Session{}, fmt.Errorf("repo: get session: %w", err)
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRowContext(ctx, token).Scan(
+		&session.UserID,
+		&session.Token,
+		&session.Family,
+		&session.Used,
+		&session.ExpiresAt,
+	)
+	if isNoRowsError(err) {
+		return model.Session{}, ErrNoRows
+	}
+
+	return session, err
+}
+
+// UseSession marks the refresh token as used, ErrNoRows means the token was already used.
+func (r *UserRepository) UseSession(ctx context.Context, token string) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE
+			session_token
+		SET
+			used = TRUE
+		WHERE
+			token = $1 AND used = FALSE;`, token)
+	if err != nil {
+		return fmt.Errorf("repo: use session: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("repo: use session: %w", err)
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	return nil
+}
+
 func (r *UserRepository) DeleteSession(ctx context.Context, userID int) error {
 	res, err := r.db.Exec(`
 		DELETE FROM 
-			session_tokens
+			session_token
 		WHERE
 			user_id = $1`, userID)
 	if err != nil {
@@ -371,3 +608,47 @@ func (r *UserRepository) DeleteSession(ctx context.Context, userID int) error {
 
 	return nil
 }
+
+func (r *UserRepository) DeleteSessionFamily(ctx context.Context, family string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			session_token
+		WHERE
+			family = $1;`, family); err != nil {
+		return fmt.Errorf("repo: delete session family: %w", err)
+	}
+
+	return nil
+}
+
+// HasLiveSession tells if the family still has a refresh token that can be used, the session
+// ends when it's signed out of, revoked or left until the last token expires.
+func (r *UserRepository) HasLiveSession(ctx context.Context, family string) (bool, error) {
+	var live bool
+
+	if err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT
+				1
+			FROM
+				session_token
+			WHERE
+				family = $1 AND used = FALSE AND token_expiration_time > $2
+		);`, family, time.Now()).Scan(&live); err != nil {
+		return false, fmt.Errorf("repo: has live session: %w", err)
+	}
+
+	return live, nil
+}
+
+func (r *UserRepository) DeleteExpiredSessions(ctx context.Context, userID int) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			session_token
+		WHERE
+			user_id = $1 AND token_expiration_time < $2;`, userID, time.Now()); err != nil {
+		return fmt.Errorf("repo: delete expired sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+func TestUpdateUsername(t *testing.T) {
+	db := newTestDB(t)
+	r := NewUser(db)
+
+	alice := createUser(t, db, "alice")
+	createUser(t, db, "bob")
+
+	now := time.Now()
+	cooldown := 30 * 24 * time.Hour
+
+	steps := []struct {
+		name     string
+		username string
+		at       time.Time
+		wantErr  error
+	}{
+		{"first change", "alice2", now, nil},
+		{"taken username", "bob", now.Add(cooldown + time.Hour), ErrUserExists},
+		{"within the cooldown", "alice3", now.Add(time.Hour), ErrNoRows},
+		{"after the cooldown", "alice3", now.Add(cooldown + time.Hour), nil},
+	}
+
+	for _, step := range steps {
+		err := r.UpdateUsername(context.Background(), alice, step.username, step.at, step.at.Add(-cooldown))
+		if err != step.wantErr {
+			t.Errorf("%s: UpdateUsername() error = %v, want %v", step.name, err, step.wantErr)
+		}
+	}
+
+	user, err := r.GetByID(context.Background(), alice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "alice3" {
+		t.Errorf("username = %q, want alice3", user.Username)
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	r := NewUser(db)
+
+	alice := createUser(t, db, "alice")
+	createUser(t, db, "bob")
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	// a new change replaces the pending one
+	for _, email := range []string{"first@example.com", "alice@example.org"} {
+		if err := r.SetEmailVerification(ctx, model.EmailVerification{
+			UserID:    alice,
+			Email:     email,
+			Token:     "token-" + email,
+			ExpiresAt: expiresAt,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if pending, err := r.GetPendingEmail(ctx, alice); err != nil || pending != "alice@example.org" {
+		t.Errorf("GetPendingEmail() = %q, %v, want alice@example.org", pending, err)
+	}
+
+	if _, err := r.GetEmailVerification(ctx, "token-first@example.com"); err != ErrNoRows {
+		t.Errorf("GetEmailVerification() of the replaced token error = %v, want %v", err, ErrNoRows)
+	}
+
+	v, err := r.GetEmailVerification(ctx, "token-alice@example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.UserID != alice || v.Email != "alice@example.org" {
+		t.Errorf("verification = %+v, want the change of alice", v)
+	}
+
+	if err := r.UpdateEmail(ctx, alice, "bob@example.com"); err != ErrEmailExists {
+		t.Errorf("UpdateEmail() to the email of bob error = %v, want %v", err, ErrEmailExists)
+	}
+
+	if err := r.UpdateEmail(ctx, alice, v.Email); err != nil {
+		t.Fatal(err)
+	}
+
+	if pending, err := r.GetPendingEmail(ctx, alice); err != nil || pending != "" {
+		t.Errorf("GetPendingEmail() after the change = %q, %v, want none", pending, err)
+	}
+}
+
+func TestHasLiveSession(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	r := NewUser(db)
+
+	alice := createUser(t, db, "alice")
+	now := time.Now()
+
+	sessions := []model.Session{
+		{UserID: alice, Token: "rotated", Family: "laptop", ExpiresAt: now.Add(time.Hour)},
+		{UserID: alice, Token: "current", Family: "laptop", ExpiresAt: now.Add(time.Hour)},
+		{UserID: alice, Token: "phone", Family: "phone", ExpiresAt: now.Add(-time.Hour)},
+		{UserID: alice, Token: "tablet", Family: "tablet", ExpiresAt: now.Add(time.Hour)},
+	}
+	for _, session := range sessions {
+		if err := r.SetSession(ctx, session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, token := range []string{"rotated", "tablet"} {
+		if err := r.UseSession(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		family string
+		want   bool
+	}{
+		{"laptop", true},
+		{"phone", false},
+		{"tablet", false},
+		{"unknown", false},
+	}
+
+	for _, tt := range tests {
+		live, err := r.HasLiveSession(ctx, tt.family)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if live != tt.want {
+			t.Errorf("HasLiveSession(%q) = %v, want %v", tt.family, live, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"real-time-forum/internal/model"
+)
+
+// VoteTarget describes the table that is voted on and the table of its votes.
+type VoteTarget struct {
+	table      string
+	votesTable string
+	column     string
+}
+
+var (
+	PostVotes    = VoteTarget{table: "post", votesTable: "vote_post", column: "post_id"}
+	CommentVotes = VoteTarget{table: "comment", votesTable: "vote_comment", column: "comment_id"}
+)
+
+type Vote interface {
+	Vote(ctx context.Context, target VoteTarget, vote model.Vote) (model.VoteResult, error)
+}
+
+type VoteRepository struct {
+	db *sql.DB
+}
+
+func NewVote(db *sql.DB) *VoteRepository {
+	return &VoteRepository{
+		db: db,
+	}
+}
+
+// Vote sets the vote of the user, the same vote again or vote 0 retracts it.
+// The rating of the target is updated by triggers within the same transaction.
+func (r *VoteRepository) Vote(ctx context.Context, target VoteTarget, vote model.Vote) (model.VoteResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.VoteResult{}, fmt.Errorf("repo: vote: %w", err)
+	}
+
+	var oldVote int
+
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT
+			vote
+		FROM
+			%s
+		WHERE
+			user_id = $1 AND %s = $2;`, target.votesTable, target.column),
+		vote.UserID, vote.TargetID,
+	).Scan(&oldVote)
+	if err != nil && !isNoRowsError(err) {
+		tx.Rollback()
+		return model.VoteResult{}, fmt.Errorf("repo: vote: %w", err)
+	}
+
+	if vote.Vote == 0 || vote.Vote == oldVote {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+			DELETE FROM
+				%s
+			WHERE
+				user_id = $1 AND %s = $2;`, target.votesTable, target.column),
+			vote.UserID, vote.TargetID,
+		)
+	} else {
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`
+			INSERT INTO
+				%[1]s (user_id, %[2]s, vote)
+			VALUES
+				($1, $2, $3)
+			ON CONFLICT (user_id, %[2]s) DO UPDATE SET
+				vote = excluded.vote;`, target.votesTable, target.column),
+			vote.UserID, vote.TargetID, vote.Vote,
+		)
+	}
+	if err != nil {
+		tx.Rollback()
+		if isForeignKeyConstraintError(err) {
+			return model.VoteResult{}, ErrForeignKeyConstraint
+		}
+		return model.VoteResult{}, fmt.Errorf("repo: vote: %w", err)
+	}
+
+	var result model.VoteResult
+
+	// user rate is the like type of the client: 1 - like, 2 - dislike
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT
+			rating,
+			CASE (SELECT vote FROM %[2]s WHERE %[3]s = %[1]s.id AND user_id = $1)
+				WHEN 1 THEN 1
+				WHEN -1 THEN 2
+				ELSE 0
+			END AS user_rate
+		FROM
+			%[1]s
+		WHERE
+			id = $2;`, target.table, target.votesTable, target.column),
+		vote.UserID, vote.TargetID,
+	).Scan(&result.Rating, &result.UserRate)
+	if err != nil {
+		tx.Rollback()
+		if isNoRowsError(err) {
+			return model.VoteResult{}, ErrNoRows
+		}
+		return model.VoteResult{}, fmt.Errorf("repo: vote: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return model.VoteResult{}, fmt.Errorf("repo: vote: %w", err)
+	}
+
+	return result, nil
+}
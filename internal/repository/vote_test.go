@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+func TestVote(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewVote(db)
+	ctx := context.Background()
+
+	alice, bob := createUser(t, db, "alice"), createUser(t, db, "bob")
+	postID := createPost(t, db, alice, "Go")
+
+	commentID, err := NewComment(db).Create(ctx, model.Comment{
+		PostID:       postID,
+		Author:       model.User{ID: alice},
+		Content:      "first",
+		CreationTime: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the votes are applied in order, each one sees the votes before it
+	tests := []struct {
+		name         string
+		userID       int
+		vote         int
+		wantRating   int
+		wantUserRate int
+	}{
+		{"like", alice, 1, 1, 1},
+		{"like of another user", bob, 1, 2, 1},
+		{"same vote retracts it", alice, 1, 1, 0},
+		{"dislike", alice, -1, 0, 2},
+		{"opposite vote replaces it", bob, -1, -2, 2},
+		{"vote 0 retracts it", bob, 0, -1, 0},
+		{"retract without a vote", bob, 0, -1, 0},
+	}
+
+	for _, target := range []struct {
+		name     string
+		target   VoteTarget
+		targetID int
+	}{
+		{"post", PostVotes, postID},
+		{"comment", CommentVotes, commentID},
+	} {
+		for _, tt := range tests {
+			t.Run(target.name+"/"+tt.name, func(t *testing.T) {
+				result, err := repo.Vote(ctx, target.target, model.Vote{UserID: tt.userID, TargetID: target.targetID, Vote: tt.vote})
+				if err != nil {
+					t.Fatalf("Vote() error = %v", err)
+				}
+
+				want := model.VoteResult{Rating: tt.wantRating, UserRate: tt.wantUserRate}
+				if result != want {
+					t.Errorf("Vote() = %+v, want %+v", result, want)
+				}
+			})
+		}
+	}
+
+}
+
+func TestVoteTriggersFollowDeletedVoters(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewVote(db)
+	ctx := context.Background()
+
+	alice, bob, carol := createUser(t, db, "alice"), createUser(t, db, "bob"), createUser(t, db, "carol")
+	postID := createPost(t, db, alice, "Go")
+
+	for _, userID := range []int{alice, bob, carol} {
+		if _, err := repo.Vote(ctx, PostVotes, model.Vote{UserID: userID, TargetID: postID, Vote: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := db.Exec(`DELETE FROM user WHERE id = $1;`, bob); err != nil {
+		t.Fatal(err)
+	}
+
+	var rating int
+	if err := db.QueryRow(`SELECT rating FROM post WHERE id = $1;`, postID).Scan(&rating); err != nil {
+		t.Fatal(err)
+	}
+	if rating != 2 {
+		t.Errorf("rating after the voter was deleted = %d, want 2", rating)
+	}
+}
+
+func TestVoteOnMissingTarget(t *testing.T) {
+	db := newTestDB(t)
+	alice := createUser(t, db, "alice")
+
+	for _, tt := range []struct {
+		name string
+		vote int
+	}{
+		{"like", 1},
+		{"retract", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewVote(db).Vote(context.Background(), PostVotes, model.Vote{UserID: alice, TargetID: 1, Vote: tt.vote})
+			if !errors.Is(err, ErrForeignKeyConstraint) && !errors.Is(err, ErrNoRows) {
+				t.Errorf("Vote() error = %v, want %v or %v", err, ErrForeignKeyConstraint, ErrNoRows)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+type ProfileInput struct {
+	FirstName string
+	LastName  string
+	Age       int
+	Gender    string
+}
+
+type EmailInput struct {
+	Email           string
+	CurrentPassword string
+}
+
+type PasswordInput struct {
+	CurrentPassword string
+	NewPassword     string
+}
+
+var (
+	ErrUnknownGender    = errors.New("gender must be Male or Female")
+	ErrUsernameTaken    = errors.New("username is already taken")
+	ErrUsernameCooldown = errors.New("username was changed recently")
+	ErrEmailTaken       = errors.New("email is already taken")
+	ErrSameEmail        = errors.New("email is the current one")
+	ErrInvalidEmailLink = errors.New("email verification link is invalid or has expired")
+	ErrWrongPassword    = errors.New("current password is incorrect")
+)
+
+// GetAccount returns the user with the settings only the user sees.
+func (s *UserService) GetAccount(ctx context.Context, userID int) (model.User, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	user.PendingEmail, err = s.repo.GetPendingEmail(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) UpdateProfile(ctx context.Context, userID int, input ProfileInput) (model.User, error) {
+	user := model.User{
+		ID:        userID,
+		FirstName: strings.TrimSpace(input.FirstName),
+		LastName:  strings.TrimSpace(input.LastName),
+		Age:       input.Age,
+		Gender:    input.Gender,
+	}
+
+	if err := s.repo.UpdateProfile(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.User{}, ErrUserDoesNotExists
+		}
+		return model.User{}, err
+	}
+
+	return s.GetAccount(ctx, userID)
+}
+
+// ChangeUsername renames the user, a username can be changed once in Account.UsernameCooldown days.
+func (s *UserService) ChangeUsername(ctx context.Context, userID int, username string) (model.User, error) {
+	username = strings.TrimSpace(username)
+
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if user.Username == username {
+		return s.GetAccount(ctx, userID)
+	}
+
+	now := time.Now()
+	cooldown := time.Duration(s.cfg.Account.UsernameCooldown) * 24 * time.Hour
+
+	if err := s.repo.UpdateUsername(ctx, userID, username, now, now.Add(-cooldown)); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserExists):
+			return model.User{}, ErrUsernameTaken
+		case errors.Is(err, repository.ErrNoRows):
+			return model.User{}, ErrUsernameCooldown
+		}
+		return model.User{}, err
+	}
+
+	return s.GetAccount(ctx, userID)
+}
+
+// ChangeEmail mails a verification link to the new address, the email is changed once it's opened.
+// The current password is asked for, whoever takes over the email can reset the password with it.
+func (s *UserService) ChangeEmail(ctx context.Context, userID int, input EmailInput) (model.User, error) {
+	email := strings.ToLower(strings.TrimSpace(input.Email))
+
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	if err := s.checkPassword(user, input.CurrentPassword); err != nil {
+		return model.User{}, err
+	}
+
+	if user.Email == email {
+		return model.User{}, ErrSameEmail
+	}
+
+	owner, err := s.repo.GetByCredentials(ctx, email)
+	if err == nil && owner.ID != userID {
+		return model.User{}, ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, repository.ErrNoRows) {
+		return model.User{}, err
+	}
+
+	token, err := s.tokenManager.NewRefreshToken()
+	if err != nil {
+		return model.User{}, fmt.Errorf("generate verification token: %w", err)
+	}
+
+	verification := model.EmailVerification{
+		UserID:    userID,
+		Email:     email,
+		Token:     token,
+		ExpiresAt: time.Now().Add(time.Duration(s.cfg.Account.EmailVerificationTTL) * time.Hour),
+	}
+
+	if err := s.repo.SetEmailVerification(ctx, verification); err != nil {
+		return model.User{}, err
+	}
+
+	body := fmt.Sprintf("Hi %s,\r\n\r\nOpen the link to use this email on the forum:\r\n%s%s\r\n\r\n"+
+		"The link expires in %d hours. If you didn't ask for it, ignore this mail.\r\n",
+		user.Username, s.cfg.Account.VerifyEmailURL, token, s.cfg.Account.EmailVerificationTTL)
+
+	if err := s.mailer.Send(email, "Verify your email", body); err != nil {
+		return model.User{}, err
+	}
+
+	return s.GetAccount(ctx, userID)
+}
+
+// VerifyEmail completes the email change the token was mailed for and returns the new email.
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (string, error) {
+	verification, err := s.repo.GetEmailVerification(ctx, token)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return "", ErrInvalidEmailLink
+		}
+		return "", err
+	}
+
+	if time.Now().After(verification.ExpiresAt) {
+		return "", ErrInvalidEmailLink
+	}
+
+	if err := s.repo.UpdateEmail(ctx, verification.UserID, verification.Email); err != nil {
+		if errors.Is(err, repository.ErrEmailExists) {
+			return "", ErrEmailTaken
+		}
+		return "", err
+	}
+
+	return verification.Email, nil
+}
+
+// ChangePassword replaces the password and signs the user out everywhere, the connections of other
+// sessions are closed. The tokens returned start a new session for the client that made the change
+// from the session sessionID, its connections are kept for it to authenticate them with the new token.
+// Access tokens already issued stay valid until they expire.
+func (s *UserService) ChangePassword(ctx context.Context, userID int, sessionID string, input PasswordInput) (Tokens, error) {
+	user, err := s.GetByID(ctx, userID)
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	if err := s.checkPassword(user, input.CurrentPassword); err != nil {
+		return Tokens{}, err
+	}
+
+	password, err := s.hasher.HashPassword(input.NewPassword)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("hash password: %w", err)
+	}
+
+	if err := s.repo.UpdatePassword(ctx, userID, password); err != nil {
+		return Tokens{}, err
+	}
+
+	if err := s.repo.DeleteSession(ctx, userID); err != nil {
+		return Tokens{}, err
+	}
+
+	s.connections.CloseOtherSessions(userID, sessionID)
+
+	return s.SetToken(ctx, userID)
+}
+
+// checkPassword confirms an account change with the current password of the user.
+func (s *UserService) checkPassword(user model.User, password string) error {
+	ok, err := s.hasher.Verify(password, user.Password)
+	if err != nil {
+		return fmt.Errorf("verify password: %w", err)
+	}
+
+	if !ok {
+		return ErrWrongPassword
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"real-time-forum/internal/config"
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/auth"
+)
+
+// fakeAccounts has the users alice (1) and bob (2) and records the changes of their accounts.
+type fakeAccounts struct {
+	fakeUserRepo
+	verification model.EmailVerification
+	passwords    map[int]string
+	signedOut    []int
+	sessions     []model.Session
+}
+
+func newFakeAccounts() *fakeAccounts {
+	return &fakeAccounts{
+		fakeUserRepo: fakeUserRepo{users: map[int]model.User{
+			1: {ID: 1, Username: "alice", Email: "alice@example.com", Password: "hashed:secret-password"},
+			2: {ID: 2, Username: "bob", Email: "bob@example.com", Password: "hashed:bob-password"},
+		}},
+		passwords: map[int]string{},
+	}
+}
+
+func (f *fakeAccounts) GetByCredentials(ctx context.Context, usernameOrEmail string) (model.User, error) {
+	for _, user := range f.users {
+		if user.Email == usernameOrEmail || user.Username == usernameOrEmail {
+			return user, nil
+		}
+	}
+	return model.User{}, repository.ErrNoRows
+}
+
+func (f *fakeAccounts) SetEmailVerification(ctx context.Context, verification model.EmailVerification) error {
+	f.verification = verification
+	return nil
+}
+
+func (f *fakeAccounts) GetPendingEmail(ctx context.Context, userID int) (string, error) {
+	return f.verification.Email, nil
+}
+
+func (f *fakeAccounts) UpdatePassword(ctx context.Context, userID int, password string) error {
+	f.passwords[userID] = password
+	return nil
+}
+
+func (f *fakeAccounts) DeleteSession(ctx context.Context, userID int) error {
+	f.signedOut = append(f.signedOut, userID)
+	return nil
+}
+
+func (f *fakeAccounts) SetSession(ctx context.Context, session model.Session) error {
+	f.sessions = append(f.sessions, session)
+	return nil
+}
+
+// fakeHasher keeps passwords readable, a hash is the password with a prefix.
+type fakeHasher struct{}
+
+func (fakeHasher) HashPassword(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+func (fakeHasher) Verify(password, hash string) (bool, error) {
+	return hash == "hashed:"+password, nil
+}
+
+func (fakeHasher) NeedsRehash(hash string) bool {
+	return false
+}
+
+// fakeMailer records the mails sent.
+type fakeMailer struct {
+	to   []string
+	body []string
+}
+
+func (f *fakeMailer) Send(to, subject, body string) error {
+	f.to = append(f.to, to)
+	f.body = append(f.body, body)
+	return nil
+}
+
+func newAccountService(t *testing.T, repo *fakeAccounts, mailer *fakeMailer, connections *fakeConnections) *UserService {
+	t.Helper()
+
+	tokenManager, err := auth.NewManager("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewUser(repo, fakeHasher{}, tokenManager, mailer, connections, &config.Config{
+		Auth:    config.Auth{AccessTokenTTL: 15, RefreshTokenTTL: 24},
+		Account: config.Account{EmailVerificationTTL: 24, VerifyEmailURL: "http://localhost/verify-email?token="},
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int
+		input   PasswordInput
+		wantErr error
+	}{
+		{"changed", 1, PasswordInput{CurrentPassword: "secret-password", NewPassword: "new-password"}, nil},
+		{"wrong current password", 1, PasswordInput{CurrentPassword: "bob-password", NewPassword: "new-password"}, ErrWrongPassword},
+		{"missing user", 3, PasswordInput{CurrentPassword: "secret-password", NewPassword: "new-password"}, ErrUserDoesNotExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAccounts()
+			connections := &fakeConnections{}
+			s := newAccountService(t, repo, &fakeMailer{}, connections)
+
+			tokens, err := s.ChangePassword(context.Background(), tt.userID, "laptop", tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangePassword() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(repo.passwords) != 0 || len(repo.signedOut) != 0 || len(connections.closed) != 0 {
+					t.Errorf("password changed to %v, signed out %v, closed %v, want nothing changed",
+						repo.passwords, repo.signedOut, connections.closed)
+				}
+				return
+			}
+
+			if repo.passwords[tt.userID] != "hashed:"+tt.input.NewPassword {
+				t.Errorf("stored password = %q, want the hash of the new one", repo.passwords[tt.userID])
+			}
+
+			// the other sessions are gone, the client that changed it gets a new one
+			if len(repo.signedOut) != 1 || len(repo.sessions) != 1 || repo.sessions[0].Token != tokens.RefreshToken {
+				t.Errorf("signed out %v, new sessions %+v, want one new session", repo.signedOut, repo.sessions)
+			}
+
+			// the connections of the client that changed it are kept to be authenticated with the new token
+			if !reflect.DeepEqual(connections.closed, []string{"1 other than laptop"}) {
+				t.Errorf("closed %v, want the other sessions of alice", connections.closed)
+			}
+		})
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		password  string
+		wantEmail string
+		wantErr   error
+	}{
+		{"new email", "alice@example.org", "secret-password", "alice@example.org", nil},
+		{"case and spaces are ignored", "  Alice@Example.ORG ", "secret-password", "alice@example.org", nil},
+		{"wrong password", "alice@example.org", "bob-password", "", ErrWrongPassword},
+		{"same email", "ALICE@example.com", "secret-password", "", ErrSameEmail},
+		{"taken email", "bob@example.com", "secret-password", "", ErrEmailTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAccounts()
+			mailer := &fakeMailer{}
+			s := newAccountService(t, repo, mailer, &fakeConnections{})
+
+			user, err := s.ChangeEmail(context.Background(), 1, EmailInput{Email: tt.email, CurrentPassword: tt.password})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ChangeEmail() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(mailer.to) != 0 {
+					t.Errorf("mailed %v, want nothing", mailer.to)
+				}
+				return
+			}
+
+			// the email changes once the link is opened
+			if user.Email != "alice@example.com" || user.PendingEmail != tt.wantEmail {
+				t.Errorf("email = %q, pending %q, want alice@example.com and %q", user.Email, user.PendingEmail, tt.wantEmail)
+			}
+
+			v := repo.verification
+			if v.UserID != 1 || v.Email != tt.wantEmail || v.Token == "" {
+				t.Errorf("verification = %+v, want a token for %q", v, tt.wantEmail)
+			}
+
+			if len(mailer.to) != 1 || mailer.to[0] != tt.wantEmail || !strings.Contains(mailer.body[0], "verify-email?token="+v.Token) {
+				t.Errorf("mailed %v %q, want the link to %q", mailer.to, mailer.body, tt.wantEmail)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+type Category interface {
+	GetAll(ctx context.Context, withArchived bool) ([]model.Category, error)
+	Create(ctx context.Context, userID int, name string) (model.Category, error)
+	Rename(ctx context.Context, userID int, categoryID int, name string) error
+	SetArchived(ctx context.Context, userID int, categoryID int, archived bool) error
+	Reorder(ctx context.Context, userID int, categoryIDs []int) error
+}
+
+type CategoryService struct {
+	repo     repository.Category
+	userRepo repository.User
+}
+
+func NewCategory(repo repository.Category, userRepo repository.User) *CategoryService {
+	return &CategoryService{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+}
+
+const maxCategoryNameLength = 32
+
+var (
+	ErrNotAdmin            = errors.New("only admins can manage categories")
+	ErrInvalidCategoryName = errors.New("category name must be from 1 to 32 characters")
+	ErrCategoryExists      = errors.New("category already exists")
+	ErrArchivedCategory    = errors.New("category is archived")
+	ErrAllCategory         = errors.New("category \"All\" can't be changed")
+	ErrInvalidOrder        = errors.New("order must list every category except \"All\" once")
+)
+
+func (s *CategoryService) GetAll(ctx context.Context, withArchived bool) ([]model.Category, error) {
+	return s.repo.GetAll(ctx, withArchived)
+}
+
+func (s *CategoryService) Create(ctx context.Context, userID int, name string) (model.Category, error) {
+	if err := s.checkAdmin(ctx, userID); err != nil {
+		return model.Category{}, err
+	}
+
+	name, err := categoryName(name)
+	if err != nil {
+		return model.Category{}, err
+	}
+
+	id, err := s.repo.Create(ctx, name)
+	if err != nil {
+		if errors.Is(err, repository.ErrCategoryExists) {
+			return model.Category{}, ErrCategoryExists
+		}
+		return model.Category{}, err
+	}
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *CategoryService) Rename(ctx context.Context, userID int, categoryID int, name string) error {
+	if err := s.checkAdmin(ctx, userID); err != nil {
+		return err
+	}
+
+	if categoryID == allCategoryID {
+		return ErrAllCategory
+	}
+
+	name, err := categoryName(name)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Rename(ctx, categoryID, name); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNoRows):
+			return ErrUnknownCategory
+		case errors.Is(err, repository.ErrCategoryExists):
+			return ErrCategoryExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetArchived hides the category from new posts or brings it back.
+func (s *CategoryService) SetArchived(ctx context.Context, userID int, categoryID int, archived bool) error {
+	if err := s.checkAdmin(ctx, userID); err != nil {
+		return err
+	}
+
+	if categoryID == allCategoryID {
+		return ErrAllCategory
+	}
+
+	if err := s.repo.SetArchived(ctx, categoryID, archived); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return ErrUnknownCategory
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Reorder takes the new order of all categories, "All" is always the first one.
+func (s *CategoryService) Reorder(ctx context.Context, userID int, categoryIDs []int) error {
+	if err := s.checkAdmin(ctx, userID); err != nil {
+		return err
+	}
+
+	categories, err := s.repo.GetAll(ctx, true)
+	if err != nil {
+		return err
+	}
+
+	if len(categoryIDs) != len(categories)-1 {
+		return ErrInvalidOrder
+	}
+
+	known := make(map[int]bool, len(categories))
+	for _, category := range categories {
+		if category.ID != allCategoryID {
+			known[category.ID] = true
+		}
+	}
+
+	for _, id := range categoryIDs {
+		if !known[id] {
+			return ErrInvalidOrder
+		}
+
+		// each id once
+		delete(known, id)
+	}
+
+	return s.repo.Reorder(ctx, categoryIDs)
+}
+
+func (s *CategoryService) checkAdmin(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return ErrNotAdmin
+		}
+		return err
+	}
+
+	if user.Role != model.RoleAdmin {
+		return ErrNotAdmin
+	}
+
+	return nil
+}
+
+func categoryName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" || utf8.RuneCountInString(name) > maxCategoryNameLength {
+		return "", ErrInvalidCategoryName
+	}
+
+	return name, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+// fakeUserRepo knows the users given to it and records when they were last seen.
+type fakeUserRepo struct {
+	repository.User
+	users    map[int]model.User
+	lastSeen map[int]time.Time
+}
+
+func (f fakeUserRepo) GetByID(ctx context.Context, userID int) (model.User, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return model.User{}, repository.ErrNoRows
+	}
+	return user, nil
+}
+
+func (f fakeUserRepo) UpdateLastSeen(ctx context.Context, userID int, lastSeen time.Time) error {
+	f.lastSeen[userID] = lastSeen
+	return nil
+}
+
+// fakeCategories has the categories 1 ("All") to 4 and records the changes made to them.
+type fakeCategories struct {
+	repository.Category
+	archived map[int]bool
+	changed  []string
+}
+
+func (f *fakeCategories) GetAll(ctx context.Context, withArchived bool) ([]model.Category, error) {
+	return []model.Category{{ID: 1, Name: "All"}, {ID: 2, Name: "Music"}, {ID: 3, Name: "Games"}}, nil
+}
+
+func (f *fakeCategories) Create(ctx context.Context, name string) (int, error) {
+	if name == "Music" {
+		return 0, repository.ErrCategoryExists
+	}
+
+	f.changed = append(f.changed, "create "+name)
+	return 4, nil
+}
+
+func (f *fakeCategories) GetByID(ctx context.Context, categoryID int) (model.Category, error) {
+	if categoryID > 4 {
+		return model.Category{}, repository.ErrNoRows
+	}
+	return model.Category{ID: categoryID, Archived: f.archived[categoryID]}, nil
+}
+
+func (f *fakeCategories) Rename(ctx context.Context, categoryID int, name string) error {
+	if categoryID > 3 {
+		return repository.ErrNoRows
+	}
+
+	f.changed = append(f.changed, "rename "+name)
+	return nil
+}
+
+func (f *fakeCategories) SetArchived(ctx context.Context, categoryID int, archived bool) error {
+	f.changed = append(f.changed, "archive")
+	return nil
+}
+
+func (f *fakeCategories) Reorder(ctx context.Context, categoryIDs []int) error {
+	f.changed = append(f.changed, "reorder")
+	return nil
+}
+
+func TestCategoryManagement(t *testing.T) {
+	const (
+		admin = 1
+		user  = 2
+	)
+
+	tests := []struct {
+		name    string
+		change  func(s *CategoryService) error
+		wantErr error
+	}{
+		{"create", func(s *CategoryService) error { _, err := s.Create(context.Background(), admin, " Sport "); return err }, nil},
+		{"create by a user", func(s *CategoryService) error { _, err := s.Create(context.Background(), user, "Sport"); return err }, ErrNotAdmin},
+		{"create by an unknown user", func(s *CategoryService) error { _, err := s.Create(context.Background(), 3, "Sport"); return err }, ErrNotAdmin},
+		{"create a taken name", func(s *CategoryService) error { _, err := s.Create(context.Background(), admin, "Music"); return err }, ErrCategoryExists},
+		{"create a blank name", func(s *CategoryService) error { _, err := s.Create(context.Background(), admin, "  "); return err }, ErrInvalidCategoryName},
+		{"create a long name", func(s *CategoryService) error {
+			_, err := s.Create(context.Background(), admin, "ééééééééééééééééééééééééééééééééé")
+			return err
+		}, ErrInvalidCategoryName},
+		{"rename", func(s *CategoryService) error { return s.Rename(context.Background(), admin, 2, "Songs") }, nil},
+		{"rename by a user", func(s *CategoryService) error { return s.Rename(context.Background(), user, 2, "Songs") }, ErrNotAdmin},
+		{"rename All", func(s *CategoryService) error { return s.Rename(context.Background(), admin, 1, "Everything") }, ErrAllCategory},
+		{"rename a missing category", func(s *CategoryService) error { return s.Rename(context.Background(), admin, 5, "Songs") }, ErrUnknownCategory},
+		{"archive", func(s *CategoryService) error { return s.SetArchived(context.Background(), admin, 2, true) }, nil},
+		{"archive by a user", func(s *CategoryService) error { return s.SetArchived(context.Background(), user, 2, true) }, ErrNotAdmin},
+		{"archive All", func(s *CategoryService) error { return s.SetArchived(context.Background(), admin, 1, true) }, ErrAllCategory},
+		{"reorder", func(s *CategoryService) error { return s.Reorder(context.Background(), admin, []int{3, 2}) }, nil},
+		{"reorder by a user", func(s *CategoryService) error { return s.Reorder(context.Background(), user, []int{3, 2}) }, ErrNotAdmin},
+		{"reorder with All", func(s *CategoryService) error { return s.Reorder(context.Background(), admin, []int{1, 3}) }, ErrInvalidOrder},
+		{"reorder with a repeat", func(s *CategoryService) error { return s.Reorder(context.Background(), admin, []int{3, 3}) }, ErrInvalidOrder},
+		{"reorder without a category", func(s *CategoryService) error { return s.Reorder(context.Background(), admin, []int{3}) }, ErrInvalidOrder},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCategories{}
+			s := NewCategory(repo, fakeUserRepo{users: map[int]model.User{
+				admin: {ID: admin, Role: model.RoleAdmin},
+				user:  {ID: user, Role: model.RoleUser},
+			}})
+
+			err := tt.change(s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if changed := len(repo.changed) == 1; changed != (tt.wantErr == nil) {
+				t.Errorf("changes = %v, want a change: %v", repo.changed, tt.wantErr == nil)
+			}
+		})
+	}
+}
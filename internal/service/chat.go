@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/validator"
+)
+
+type Chat interface {
+	SendMessage(ctx context.Context, message model.Message) (model.Message, error)
+	GetMessages(ctx context.Context, userID int, companionID int, token string) ([]model.Message, Cursors, error)
+	GetChats(ctx context.Context, userID int) ([]model.Chat, error)
+	ReadMessages(ctx context.Context, userID int, messageID int) (model.ReadReceipt, error)
+	EditMessage(ctx context.Context, userID int, messageID int, text string) (model.Message, error)
+	DeleteMessage(ctx context.Context, userID int, messageID int) (model.Message, error)
+	GetMessageEdits(ctx context.Context, userID int, messageID int) ([]model.MessageEdit, error)
+}
+
+type ChatService struct {
+	repo repository.Message
+}
+
+func NewChat(repo repository.Message) *ChatService {
+	return &ChatService{
+		repo: repo,
+	}
+}
+
+const messagesLimit = 10
+
+// a message can be edited and deleted for everyone within the window after it was sent
+const messageChangeWindow = time.Hour
+
+var (
+	ErrEmptyMessage     = errors.New("message is empty")
+	ErrSelfRecipient    = errors.New("can't send message to yourself")
+	ErrUnknownRecipient = errors.New("recipient doesn't exists")
+	ErrMessageNotFound  = errors.New("message doesn't exists")
+	ErrNotMessageSender = errors.New("only sender can change the message")
+	ErrMessageDeleted   = errors.New("message is deleted")
+	ErrDeleteExpired    = errors.New("message is too old to be deleted")
+	ErrEditExpired      = errors.New("message is too old to be edited")
+	ErrMessageUnchanged = errors.New("message is unchanged")
+)
+
+func (s *ChatService) SendMessage(ctx context.Context, message model.Message) (model.Message, error) {
+	message.Message = strings.TrimSpace(validator.StripControl(message.Message))
+	if message.Message == "" {
+		return model.Message{}, ErrEmptyMessage
+	}
+
+	if message.SenderID == message.RecipientID {
+		return model.Message{}, ErrSelfRecipient
+	}
+
+	message.CreationTime = time.Now()
+	message.Readed = false
+
+	id, err := s.repo.Create(ctx, message)
+	if err != nil {
+		if errors.Is(err, repository.ErrForeignKeyConstraint) {
+			return model.Message{}, ErrUnknownRecipient
+		}
+		return model.Message{}, err
+	}
+
+	message.ID = id
+
+	return message, nil
+}
+
+// GetMessages returns a page of the history with the companion, Next goes to older messages.
+func (s *ChatService) GetMessages(ctx context.Context, userID int, companionID int, token string) ([]model.Message, Cursors, error) {
+	c, err := decodeCursor(token)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	messages, err := s.repo.GetMessages(ctx, userID, companionID, c.position(), messagesLimit+1)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	messages, cursors := paginate(messages, messagesLimit, c, func(message model.Message) (float64, int) {
+		return 0, message.ID
+	})
+
+	return messages, cursors, nil
+}
+
+func (s *ChatService) GetChats(ctx context.Context, userID int) ([]model.Chat, error) {
+	return s.repo.GetChats(ctx, userID)
+}
+
+// ReadMessages marks messages received from the sender of the message up to it as read.
+func (s *ChatService) ReadMessages(ctx context.Context, userID int, messageID int) (model.ReadReceipt, error) {
+	message, err := s.repo.GetByID(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.ReadReceipt{}, ErrMessageNotFound
+		}
+		return model.ReadReceipt{}, err
+	}
+
+	// only the recipient reads the message, others must not learn it exists
+	if message.RecipientID != userID {
+		return model.ReadReceipt{}, ErrMessageNotFound
+	}
+
+	count, err := s.repo.MarkRead(ctx, userID, message.SenderID, message.ID)
+	if err != nil {
+		return model.ReadReceipt{}, err
+	}
+
+	unread, err := s.repo.GetUnreadCount(ctx, userID, message.SenderID)
+	if err != nil {
+		return model.ReadReceipt{}, err
+	}
+
+	return model.ReadReceipt{
+		MessageID:           message.ID,
+		SenderID:            message.SenderID,
+		RecipientID:         userID,
+		ReadCount:           count,
+		UnreadMessagesCount: unread,
+	}, nil
+}
+
+// EditMessage replaces the text of the message, both parties can see the previous versions.
+// The same text again is ErrMessageUnchanged, so nothing is stored or sent for it.
+func (s *ChatService) EditMessage(ctx context.Context, userID int, messageID int, text string) (model.Message, error) {
+	text = strings.TrimSpace(validator.StripControl(text))
+	if text == "" {
+		return model.Message{}, ErrEmptyMessage
+	}
+
+	message, err := s.getOwnMessage(ctx, userID, messageID)
+	if err != nil {
+		return model.Message{}, err
+	}
+
+	if !sentWithin(message, messageChangeWindow) {
+		return model.Message{}, ErrEditExpired
+	}
+
+	// nothing to store in the history or to tell the partner about
+	if message.Message == text {
+		return model.Message{}, ErrMessageUnchanged
+	}
+
+	if err := s.repo.Update(ctx, messageID, text, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.Message{}, ErrMessageDeleted
+		}
+		return model.Message{}, err
+	}
+
+	return s.repo.GetByID(ctx, messageID)
+}
+
+// DeleteMessage deletes the message for everyone and returns its tombstone.
+func (s *ChatService) DeleteMessage(ctx context.Context, userID int, messageID int) (model.Message, error) {
+	message, err := s.getOwnMessage(ctx, userID, messageID)
+	if err != nil {
+		return model.Message{}, err
+	}
+
+	if !sentWithin(message, messageChangeWindow) {
+		return model.Message{}, ErrDeleteExpired
+	}
+
+	if err := s.repo.Delete(ctx, messageID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.Message{}, ErrMessageDeleted
+		}
+		return model.Message{}, err
+	}
+
+	return s.repo.GetByID(ctx, messageID)
+}
+
+func (s *ChatService) GetMessageEdits(ctx context.Context, userID int, messageID int) ([]model.MessageEdit, error) {
+	message, err := s.getMessage(ctx, userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetEdits(ctx, message.ID)
+}
+
+// getMessage returns the message to its sender or recipient, others must not learn it exists.
+func (s *ChatService) getMessage(ctx context.Context, userID int, messageID int) (model.Message, error) {
+	message, err := s.repo.GetByID(ctx, messageID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.Message{}, ErrMessageNotFound
+		}
+		return model.Message{}, err
+	}
+
+	if message.SenderID != userID && message.RecipientID != userID {
+		return model.Message{}, ErrMessageNotFound
+	}
+
+	return message, nil
+}
+
+// sentWithin tells if the message was sent less than window ago, one without a known sending time never was.
+func sentWithin(message model.Message, window time.Duration) bool {
+	sent, ok := message.CreationTime.(time.Time)
+	return ok && time.Since(sent) <= window
+}
+
+func (s *ChatService) getOwnMessage(ctx context.Context, userID int, messageID int) (model.Message, error) {
+	message, err := s.getMessage(ctx, userID, messageID)
+	if err != nil {
+		return model.Message{}, err
+	}
+
+	if message.SenderID != userID {
+		return model.Message{}, ErrNotMessageSender
+	}
+
+	if message.DeletedAt != nil {
+		return model.Message{}, ErrMessageDeleted
+	}
+
+	return message, nil
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+// fakeMessages stores messages in memory, users above 2 don't exist.
+type fakeMessages struct {
+	repository.Message
+	messages []model.Message
+}
+
+func (f *fakeMessages) Create(ctx context.Context, message model.Message) (int, error) {
+	if message.RecipientID > 2 {
+		return 0, repository.ErrForeignKeyConstraint
+	}
+
+	f.messages = append(f.messages, message)
+	return len(f.messages), nil
+}
+
+func (f *fakeMessages) GetByID(ctx context.Context, messageID int) (model.Message, error) {
+	if messageID < 1 || messageID > len(f.messages) {
+		return model.Message{}, repository.ErrNoRows
+	}
+
+	message := f.messages[messageID-1]
+	message.ID = messageID
+	return message, nil
+}
+
+// MarkRead reads the unread messages of the sender, Readed is kept on the stored copy.
+func (f *fakeMessages) MarkRead(ctx context.Context, recipientID int, senderID int, lastMessageID int) (int, error) {
+	read := 0
+	for i := range f.messages[:lastMessageID] {
+		message := &f.messages[i]
+		if message.RecipientID == recipientID && message.SenderID == senderID && !message.Readed {
+			message.Readed = true
+			read++
+		}
+	}
+	return read, nil
+}
+
+func (f *fakeMessages) GetUnreadCount(ctx context.Context, recipientID int, senderID int) (int, error) {
+	unread := 0
+	for _, message := range f.messages {
+		if message.RecipientID == recipientID && message.SenderID == senderID && !message.Readed {
+			unread++
+		}
+	}
+	return unread, nil
+}
+
+func (f *fakeMessages) Update(ctx context.Context, messageID int, text string, editedAt time.Time) error {
+	f.messages[messageID-1].Message = text
+	f.messages[messageID-1].EditedAt = editedAt
+	return nil
+}
+
+func (f *fakeMessages) Delete(ctx context.Context, messageID int, deletedAt time.Time) error {
+	f.messages[messageID-1].Message = ""
+	f.messages[messageID-1].DeletedAt = deletedAt
+	return nil
+}
+
+func TestSendMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  model.Message
+		wantText string
+		wantErr  error
+	}{
+		{"sent", model.Message{SenderID: 1, RecipientID: 2, Message: "hi bob"}, "hi bob", nil},
+		{"control characters stripped", model.Message{SenderID: 1, RecipientID: 2, Message: " \x01hi\x02 bob\n"}, "hi bob", nil},
+		{"empty", model.Message{SenderID: 1, RecipientID: 2, Message: " \n\t"}, "", ErrEmptyMessage},
+		{"only control characters", model.Message{SenderID: 1, RecipientID: 2, Message: "\x01\x02"}, "", ErrEmptyMessage},
+		{"to yourself", model.Message{SenderID: 1, RecipientID: 1, Message: "hi me"}, "", ErrSelfRecipient},
+		{"unknown recipient", model.Message{SenderID: 1, RecipientID: 3, Message: "hi?"}, "", ErrUnknownRecipient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeMessages{}
+			s := NewChat(repo)
+
+			message, err := s.SendMessage(context.Background(), tt.message)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SendMessage() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(repo.messages) != 0 {
+					t.Errorf("stored %v, want nothing", repo.messages)
+				}
+				return
+			}
+
+			if message.ID != 1 || message.CreationTime == nil || message.Readed {
+				t.Errorf("SendMessage() = %+v, want a stored unread message with a time", message)
+			}
+			if message.Message != tt.wantText || repo.messages[0].Message != tt.wantText {
+				t.Errorf("sent %q and stored %q, want %q", message.Message, repo.messages[0].Message, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestReadMessages(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    int
+		messageID int
+		want      model.ReadReceipt
+		wantErr   error
+	}{
+		{"read up to the message", 1, 2, model.ReadReceipt{MessageID: 2, SenderID: 2, RecipientID: 1, ReadCount: 2, UnreadMessagesCount: 1}, nil},
+		{"own message", 2, 2, model.ReadReceipt{}, ErrMessageNotFound},
+		{"message of others", 3, 2, model.ReadReceipt{}, ErrMessageNotFound},
+		{"missing message", 1, 9, model.ReadReceipt{}, ErrMessageNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// bob sent three messages to alice
+			repo := &fakeMessages{messages: []model.Message{
+				{SenderID: 2, RecipientID: 1, Message: "1"},
+				{SenderID: 2, RecipientID: 1, Message: "2"},
+				{SenderID: 2, RecipientID: 1, Message: "3"},
+			}}
+
+			receipt, err := NewChat(repo).ReadMessages(context.Background(), tt.userID, tt.messageID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadMessages() error = %v, want %v", err, tt.wantErr)
+			}
+			if receipt != tt.want {
+				t.Errorf("ReadMessages() = %+v, want %+v", receipt, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeMessage(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    int
+		messageID int
+		delete    bool
+		text      string
+		wantText  string
+		wantErr   error
+	}{
+		{"edited", 1, 1, false, "hi bob!", "hi bob!", nil},
+		{"edited by the recipient", 2, 1, false, "hi alice", "hi bob", ErrNotMessageSender},
+		{"edited by others", 3, 1, false, "hi", "hi bob", ErrMessageNotFound},
+		{"edited with control characters", 1, 1, false, "\x01hi bob!\x02 ", "hi bob!", nil},
+		{"edited to nothing", 1, 1, false, " ", "hi bob", ErrEmptyMessage},
+		{"edited to control characters", 1, 1, false, "\x01", "hi bob", ErrEmptyMessage},
+		{"edited to the same text", 1, 1, false, " hi bob\x01", "hi bob", ErrMessageUnchanged},
+		{"edited too late", 1, 3, false, "new news", "old news", ErrEditExpired},
+		{"edited without a sending time", 1, 4, false, "now", "when?", ErrEditExpired},
+		{"deleted message edited", 1, 2, false, "back", "", ErrMessageDeleted},
+		{"missing message edited", 1, 9, false, "hi", "", ErrMessageNotFound},
+		{"deleted", 1, 1, true, "", "", nil},
+		{"deleted by the recipient", 2, 1, true, "", "hi bob", ErrNotMessageSender},
+		{"deleted twice", 1, 2, true, "", "", ErrMessageDeleted},
+		{"deleted too late", 1, 3, true, "", "old news", ErrDeleteExpired},
+		{"deleted without a sending time", 1, 4, true, "", "when?", ErrDeleteExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			repo := &fakeMessages{messages: []model.Message{
+				{SenderID: 1, RecipientID: 2, Message: "hi bob", CreationTime: now},
+				{SenderID: 1, RecipientID: 2, Message: "", CreationTime: now, DeletedAt: now},
+				{SenderID: 1, RecipientID: 2, Message: "old news", CreationTime: now.Add(-messageChangeWindow - time.Minute)},
+				{SenderID: 1, RecipientID: 2, Message: "when?"},
+			}}
+			s := NewChat(repo)
+
+			var err error
+			if tt.delete {
+				_, err = s.DeleteMessage(context.Background(), tt.userID, tt.messageID)
+			} else {
+				_, err = s.EditMessage(context.Background(), tt.userID, tt.messageID, tt.text)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.messageID <= len(repo.messages) && repo.messages[tt.messageID-1].Message != tt.wantText {
+				t.Errorf("stored text = %q, want %q", repo.messages[tt.messageID-1].Message, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"real-time-forum/internal/config"
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/validator"
+)
+
+type Comment interface {
+	Create(ctx context.Context, input CommentInput) (model.Comment, error)
+	GetByPostID(ctx context.Context, postID int, userID int, token string) ([]model.Comment, Cursors, error)
+	GetReplies(ctx context.Context, commentID int, userID int, token string) ([]model.Comment, Cursors, error)
+}
+
+type CommentService struct {
+	repo      repository.Comment
+	publisher Publisher
+	maxDepth  int
+}
+
+// NewComment takes the depth of replies from the config, without one replies go defaultMaxDepth levels deep.
+func NewComment(repo repository.Comment, publisher Publisher, cfg *config.Config) *CommentService {
+	maxDepth := cfg.Comments.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxDepth
+	}
+
+	return &CommentService{
+		repo:      repo,
+		publisher: publisher,
+		maxDepth:  maxDepth,
+	}
+}
+
+type CommentInput struct {
+	UserID   int
+	PostID   int
+	ParentID int // 0 for a top-level comment
+	Content  string
+}
+
+const (
+	commentsLimit   = 10
+	repliesLimit    = 10
+	defaultMaxDepth = 6
+
+	// every comment of a page comes with its first replies, a few levels deep,
+	// the rest of a branch is loaded from its MoreReplies cursor
+	repliesPreviewLimit = 3
+	repliesPreviewDepth = 2
+)
+
+var (
+	ErrEmptyComment    = errors.New("comment must not be empty")
+	ErrCommentNotFound = errors.New("comment doesn't exists")
+	ErrReplyTooDeep    = errors.New("comment is nested too deep to be replied to")
+)
+
+func (s *CommentService) Create(ctx context.Context, input CommentInput) (model.Comment, error) {
+	comment := model.Comment{
+		PostID:       input.PostID,
+		ParentID:     input.ParentID,
+		Author:       model.User{ID: input.UserID},
+		Content:      strings.TrimSpace(validator.StripControl(input.Content)),
+		CreationTime: time.Now(),
+	}
+
+	if comment.Content == "" {
+		return model.Comment{}, ErrEmptyComment
+	}
+
+	if comment.ParentID != 0 {
+		if err := s.checkParent(ctx, comment); err != nil {
+			return model.Comment{}, err
+		}
+	}
+
+	id, err := s.repo.Create(ctx, comment)
+	if err != nil {
+		if errors.Is(err, repository.ErrForeignKeyConstraint) {
+			return model.Comment{}, ErrPostNotFound
+		}
+		return model.Comment{}, err
+	}
+
+	comment, err = s.repo.GetByID(ctx, id, input.UserID)
+	if err != nil {
+		return model.Comment{}, err
+	}
+
+	s.publisher.Publish([]string{PostTopic(comment.PostID)}, CommentCreatedEvent, comment)
+
+	return comment, nil
+}
+
+// checkParent checks the comment replied to is under the same post and isn't at the maximum depth.
+func (s *CommentService) checkParent(ctx context.Context, comment model.Comment) error {
+	parent, err := s.repo.GetByID(ctx, comment.ParentID, comment.Author.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		return err
+	}
+
+	if parent.PostID != comment.PostID {
+		return ErrCommentNotFound
+	}
+
+	if parent.Depth >= s.maxDepth {
+		return ErrReplyTooDeep
+	}
+
+	return nil
+}
+
+// GetByPostID returns a page of top-level comments, newest first, each with its first replies.
+func (s *CommentService) GetByPostID(ctx context.Context, postID int, userID int, token string) ([]model.Comment, Cursors, error) {
+	c, err := decodeCursor(token)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	comments, err := s.repo.GetByPostID(ctx, postID, userID, c.position(), commentsLimit+1)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	comments, cursors := paginate(comments, commentsLimit, c, commentPosition)
+
+	comments, err = s.withReplies(ctx, comments, userID)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	return comments, cursors, nil
+}
+
+// GetReplies returns a page of the direct replies to the comment, oldest first, each with its first replies.
+func (s *CommentService) GetReplies(ctx context.Context, commentID int, userID int, token string) ([]model.Comment, Cursors, error) {
+	c, err := decodeCursor(token)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	if _, err := s.repo.GetByID(ctx, commentID, userID); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return nil, Cursors{}, ErrCommentNotFound
+		}
+		return nil, Cursors{}, err
+	}
+
+	replies, err := s.repo.GetReplies(ctx, commentID, userID, c.position(), repliesLimit+1)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	replies, cursors := paginate(replies, repliesLimit, c, commentPosition)
+
+	replies, err = s.withReplies(ctx, replies, userID)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	return replies, cursors, nil
+}
+
+func commentPosition(comment model.Comment) (float64, int) {
+	return 0, comment.ID
+}
+
+// withReplies puts the first replies under the comments.
+func (s *CommentService) withReplies(ctx context.Context, comments []model.Comment, userID int) ([]model.Comment, error) {
+	ids := make([]int, 0, len(comments))
+	for _, comment := range comments {
+		if comment.RepliesCount > 0 {
+			ids = append(ids, comment.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return comments, nil
+	}
+
+	replies, err := s.repo.GetRepliesPreview(ctx, ids, userID, repliesPreviewDepth, repliesPreviewLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	// replies come in reading order, so the replies to a comment are grouped oldest first
+	byParent := make(map[int][]model.Comment)
+	for _, reply := range replies {
+		byParent[reply.ParentID] = append(byParent[reply.ParentID], reply)
+	}
+
+	for i := range comments {
+		attachReplies(&comments[i], byParent)
+	}
+
+	return comments, nil
+}
+
+func attachReplies(comment *model.Comment, byParent map[int][]model.Comment) {
+	comment.Replies = byParent[comment.ID]
+
+	for i := range comment.Replies {
+		attachReplies(&comment.Replies[i], byParent)
+	}
+
+	if n := len(comment.Replies); n > 0 && n < comment.RepliesCount {
+		comment.MoreReplies = cursor{ID: comment.Replies[n-1].ID}.encode()
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"real-time-forum/internal/config"
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+// commentsConfig allows replies two levels below a top-level comment.
+var commentsConfig = &config.Config{Comments: config.Comments{MaxDepth: 2}}
+
+// fakeComments stores the comments of the post 1 only, like the foreign key of the table.
+type fakeComments struct {
+	repository.Comment
+	created []model.Comment
+}
+
+func (f *fakeComments) Create(ctx context.Context, comment model.Comment) (int, error) {
+	if comment.PostID != 1 {
+		return 0, repository.ErrForeignKeyConstraint
+	}
+
+	f.created = append(f.created, comment)
+	return len(f.created), nil
+}
+
+func (f *fakeComments) GetByID(ctx context.Context, commentID int, userID int) (model.Comment, error) {
+	if commentID < 1 || commentID > len(f.created) {
+		return model.Comment{}, repository.ErrNoRows
+	}
+
+	comment := f.created[commentID-1]
+	comment.ID = commentID
+	return comment, nil
+}
+
+func TestCreateComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       CommentInput
+		wantContent string
+		wantErr     error
+	}{
+		{"trimmed", CommentInput{UserID: 1, PostID: 1, Content: "  nice post \n"}, "nice post", nil},
+		{"search markers stripped", CommentInput{UserID: 1, PostID: 1, Content: "\x02nice\x03 post"}, "nice post", nil},
+		{"blank", CommentInput{UserID: 1, PostID: 1, Content: " \t"}, "", ErrEmptyComment},
+		{"missing post", CommentInput{UserID: 1, PostID: 2, Content: "hello?"}, "", ErrPostNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewComment(&fakeComments{}, &fakePublisher{}, commentsConfig)
+
+			comment, err := s.Create(context.Background(), tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if comment.Content != tt.wantContent {
+				t.Errorf("content = %q, want %q", comment.Content, tt.wantContent)
+			}
+			if tt.wantErr == nil && comment.Author.ID != tt.input.UserID {
+				t.Errorf("author = %d, want %d", comment.Author.ID, tt.input.UserID)
+			}
+		})
+	}
+}
+
+func TestGetCommentsRejectsInvalidCursor(t *testing.T) {
+	_, _, err := NewComment(&fakeComments{}, &fakePublisher{}, commentsConfig).GetByPostID(context.Background(), 1, 1, "page=2")
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("GetByPostID() error = %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func TestReplyDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDepth int
+		parentID int
+		wantErr  error
+	}{
+		{"reply to a top-level comment", 2, 1, nil},
+		{"reply to a reply", 2, 2, nil},
+		{"reply at the maximum depth", 2, 3, ErrReplyTooDeep},
+		{"reply to a comment of another post", 2, 4, ErrCommentNotFound},
+		{"reply to a missing comment", 2, 9, ErrCommentNotFound},
+		{"maximum depth of one level", 1, 2, ErrReplyTooDeep},
+		{"default maximum depth", 0, 3, nil},
+		{"default maximum depth reached", 0, 5, ErrReplyTooDeep},
+		{"negative maximum depth", -1, 3, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeComments{created: []model.Comment{
+				{PostID: 1, Depth: 0},
+				{PostID: 1, ParentID: 1, Depth: 1},
+				{PostID: 1, ParentID: 2, Depth: 2},
+				{PostID: 2, Depth: 0},
+				{PostID: 1, Depth: defaultMaxDepth},
+			}}
+			s := NewComment(repo, &fakePublisher{}, &config.Config{Comments: config.Comments{MaxDepth: tt.maxDepth}})
+
+			comment, err := s.Create(context.Background(), CommentInput{UserID: 1, PostID: 1, ParentID: tt.parentID, Content: "reply"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil && (len(repo.created) != 6 || comment.ParentID != tt.parentID) {
+				t.Errorf("created %+v, want a reply to %d", comment, tt.parentID)
+			}
+			if tt.wantErr != nil && len(repo.created) != 5 {
+				t.Errorf("created %d comments, want none", len(repo.created)-5)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/validator"
+)
+
+type Conversation interface {
+	Create(ctx context.Context, userID int, title string, memberIDs []int) (model.Conversation, error)
+	GetAll(ctx context.Context, userID int) ([]model.Conversation, error)
+	GetByID(ctx context.Context, userID int, conversationID int) (model.Conversation, error)
+	Rename(ctx context.Context, userID int, conversationID int, title string) (model.Conversation, error)
+	AddMember(ctx context.Context, userID int, conversationID int, memberID int) (model.Conversation, error)
+	RemoveMember(ctx context.Context, userID int, conversationID int, memberID int) error
+	SendMessage(ctx context.Context, userID int, conversationID int, text string) (model.ConversationMessage, error)
+	GetMessages(ctx context.Context, userID int, conversationID int, token string) ([]model.ConversationMessage, Cursors, error)
+	ReadMessages(ctx context.Context, userID int, conversationID int, messageID int) (model.ConversationRead, error)
+}
+
+type ConversationService struct {
+	repo      repository.Conversation
+	publisher Publisher
+}
+
+func NewConversation(repo repository.Conversation, publisher Publisher) *ConversationService {
+	return &ConversationService{
+		repo:      repo,
+		publisher: publisher,
+	}
+}
+
+// conversation events, pushed to every member
+const (
+	ConversationUpdatedEvent = "conversationUpdated"
+	ConversationRemovedEvent = "conversationRemoved"
+	ConversationMessageEvent = "conversationMessage"
+	ConversationReadEvent    = "conversationRead"
+)
+
+const maxConversationTitleLength = 64
+
+var (
+	ErrConversationNotFound  = errors.New("conversation doesn't exists")
+	ErrNotConversationOwner  = errors.New("only owner can manage the conversation")
+	ErrInvalidTitle          = errors.New("title must be from 1 to 64 characters")
+	ErrNoMembers             = errors.New("at least one member must be invited")
+	ErrAlreadyMember         = errors.New("user is already a member")
+	ErrNotConversationMember = errors.New("user is not a member")
+)
+
+func (s *ConversationService) Create(ctx context.Context, userID int, title string, memberIDs []int) (model.Conversation, error) {
+	title, err := conversationTitle(title)
+	if err != nil {
+		return model.Conversation{}, err
+	}
+
+	members := []int{userID}
+	seen := map[int]bool{userID: true}
+
+	for _, id := range memberIDs {
+		if seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		members = append(members, id)
+	}
+
+	if len(members) == 1 {
+		return model.Conversation{}, ErrNoMembers
+	}
+
+	id, err := s.repo.Create(ctx, model.Conversation{
+		Title:        title,
+		OwnerID:      userID,
+		CreationTime: time.Now(),
+	}, members)
+	if err != nil {
+		if errors.Is(err, repository.ErrForeignKeyConstraint) {
+			return model.Conversation{}, ErrUserDoesNotExists
+		}
+		return model.Conversation{}, err
+	}
+
+	return s.publishUpdate(ctx, id)
+}
+
+func (s *ConversationService) GetAll(ctx context.Context, userID int) ([]model.Conversation, error) {
+	return s.repo.GetByUserID(ctx, userID)
+}
+
+// GetByID returns the conversation to its member, others must not learn it exists.
+func (s *ConversationService) GetByID(ctx context.Context, userID int, conversationID int) (model.Conversation, error) {
+	conversation, err := s.repo.GetByID(ctx, conversationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.Conversation{}, ErrConversationNotFound
+		}
+		return model.Conversation{}, err
+	}
+
+	if !isMember(conversation, userID) {
+		return model.Conversation{}, ErrConversationNotFound
+	}
+
+	return conversation, nil
+}
+
+func (s *ConversationService) Rename(ctx context.Context, userID int, conversationID int, title string) (model.Conversation, error) {
+	conversation, err := s.GetByID(ctx, userID, conversationID)
+	if err != nil {
+		return model.Conversation{}, err
+	}
+
+	if conversation.OwnerID != userID {
+		return model.Conversation{}, ErrNotConversationOwner
+	}
+
+	title, err = conversationTitle(title)
+	if err != nil {
+		return model.Conversation{}, err
+	}
+
+	if err := s.repo.Rename(ctx, conversationID, title); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.Conversation{}, ErrConversationNotFound
+		}
+		return model.Conversation{}, err
+	}
+
+	return s.publishUpdate(ctx, conversationID)
+}
+
+// AddMember invites the user, any member can invite.
+func (s *ConversationService) AddMember(ctx context.Context, userID int, conversationID int, memberID int) (model.Conversation, error) {
+	if _, err := s.GetByID(ctx, userID, conversationID); err != nil {
+		return model.Conversation{}, err
+	}
+
+	if err := s.repo.AddMember(ctx, conversationID, memberID, time.Now()); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrMemberExists):
+			return model.Conversation{}, ErrAlreadyMember
+		case errors.Is(err, repository.ErrForeignKeyConstraint):
+			return model.Conversation{}, ErrUserDoesNotExists
+		}
+		return model.Conversation{}, err
+	}
+
+	return s.publishUpdate(ctx, conversationID)
+}
+
+// RemoveMember removes the member by the owner or lets the member leave.
+// The longest member becomes the owner when the owner leaves, the last one to leave deletes the conversation.
+func (s *ConversationService) RemoveMember(ctx context.Context, userID int, conversationID int, memberID int) error {
+	conversation, err := s.GetByID(ctx, userID, conversationID)
+	if err != nil {
+		return err
+	}
+
+	if memberID != userID && conversation.OwnerID != userID {
+		return ErrNotConversationOwner
+	}
+
+	if err := s.repo.RemoveMember(ctx, conversationID, memberID); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return ErrNotConversationMember
+		}
+		return err
+	}
+
+	s.publisher.PublishToUsers([]int{memberID}, ConversationRemovedEvent, conversationID)
+
+	var rest []model.ConversationMember
+	for _, member := range conversation.Members {
+		if member.User.ID != memberID {
+			rest = append(rest, member)
+		}
+	}
+
+	if len(rest) == 0 {
+		return s.repo.Delete(ctx, conversationID)
+	}
+
+	if conversation.OwnerID == memberID {
+		// members are ordered by the time they joined
+		if err := s.repo.SetOwner(ctx, conversationID, rest[0].User.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.publishUpdate(ctx, conversationID)
+
+	return err
+}
+
+func (s *ConversationService) SendMessage(ctx context.Context, userID int, conversationID int, text string) (model.ConversationMessage, error) {
+	text = strings.TrimSpace(validator.StripControl(text))
+	if text == "" {
+		return model.ConversationMessage{}, ErrEmptyMessage
+	}
+
+	conversation, err := s.GetByID(ctx, userID, conversationID)
+	if err != nil {
+		return model.ConversationMessage{}, err
+	}
+
+	message := model.ConversationMessage{
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Message:        text,
+		CreationTime:   time.Now(),
+	}
+
+	message.ID, err = s.repo.CreateMessage(ctx, message)
+	if err != nil {
+		if errors.Is(err, repository.ErrForeignKeyConstraint) {
+			return model.ConversationMessage{}, ErrConversationNotFound
+		}
+		return model.ConversationMessage{}, err
+	}
+
+	// the sender has read everything up to the own message
+	if _, err := s.repo.MarkRead(ctx, conversationID, userID, message.ID); err != nil {
+		return model.ConversationMessage{}, err
+	}
+
+	s.publisher.PublishToUsers(memberIDs(conversation), ConversationMessageEvent, message)
+
+	return message, nil
+}
+
+// GetMessages returns a page of the history, Next goes to older messages.
+func (s *ConversationService) GetMessages(ctx context.Context, userID int, conversationID int, token string) ([]model.ConversationMessage, Cursors, error) {
+	c, err := decodeCursor(token)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	if _, err := s.GetByID(ctx, userID, conversationID); err != nil {
+		return nil, Cursors{}, err
+	}
+
+	messages, err := s.repo.GetMessages(ctx, conversationID, c.position(), messagesLimit+1)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	messages, cursors := paginate(messages, messagesLimit, c, func(message model.ConversationMessage) (float64, int) {
+		return 0, message.ID
+	})
+
+	return messages, cursors, nil
+}
+
+// ReadMessages moves the read state of the member up to the message and tells the other members.
+func (s *ConversationService) ReadMessages(ctx context.Context, userID int, conversationID int, messageID int) (model.ConversationRead, error) {
+	conversation, err := s.GetByID(ctx, userID, conversationID)
+	if err != nil {
+		return model.ConversationRead{}, err
+	}
+
+	if messageID <= 0 {
+		return model.ConversationRead{}, ErrMessageNotFound
+	}
+
+	changed, err := s.repo.MarkRead(ctx, conversationID, userID, messageID)
+	if err != nil {
+		return model.ConversationRead{}, err
+	}
+
+	read := model.ConversationRead{
+		ConversationID:    conversationID,
+		UserID:            userID,
+		LastReadMessageID: messageID,
+	}
+
+	if changed {
+		s.publisher.PublishToUsers(memberIDs(conversation), ConversationReadEvent, read)
+	}
+
+	return read, nil
+}
+
+// publishUpdate sends the current state of the conversation to its members.
+func (s *ConversationService) publishUpdate(ctx context.Context, conversationID int) (model.Conversation, error) {
+	conversation, err := s.repo.GetByID(ctx, conversationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.Conversation{}, ErrConversationNotFound
+		}
+		return model.Conversation{}, err
+	}
+
+	s.publisher.PublishToUsers(memberIDs(conversation), ConversationUpdatedEvent, conversation)
+
+	return conversation, nil
+}
+
+func isMember(conversation model.Conversation, userID int) bool {
+	for _, member := range conversation.Members {
+		if member.User.ID == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func memberIDs(conversation model.Conversation) []int {
+	ids := make([]int, 0, len(conversation.Members))
+	for _, member := range conversation.Members {
+		ids = append(ids, member.User.ID)
+	}
+
+	return ids
+}
+
+func conversationTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+
+	if title == "" || utf8.RuneCountInString(title) > maxConversationTitleLength {
+		return "", ErrInvalidTitle
+	}
+
+	return title, nil
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+// fakeConversations keeps the conversations in memory, users above 4 don't exist.
+type fakeConversations struct {
+	repository.Conversation
+	conversations map[int]*model.Conversation
+	messages      []model.ConversationMessage
+}
+
+func (f *fakeConversations) Create(ctx context.Context, conversation model.Conversation, memberIDs []int) (int, error) {
+	for _, id := range memberIDs {
+		if id > 4 {
+			return 0, repository.ErrForeignKeyConstraint
+		}
+	}
+
+	conversation.ID = len(f.conversations) + 1
+	for _, id := range memberIDs {
+		conversation.Members = append(conversation.Members, model.ConversationMember{User: model.User{ID: id}})
+	}
+
+	f.conversations[conversation.ID] = &conversation
+	return conversation.ID, nil
+}
+
+func (f *fakeConversations) GetByID(ctx context.Context, conversationID int) (model.Conversation, error) {
+	conversation, ok := f.conversations[conversationID]
+	if !ok {
+		return model.Conversation{}, repository.ErrNoRows
+	}
+	return *conversation, nil
+}
+
+func (f *fakeConversations) Rename(ctx context.Context, conversationID int, title string) error {
+	f.conversations[conversationID].Title = title
+	return nil
+}
+
+func (f *fakeConversations) SetOwner(ctx context.Context, conversationID int, ownerID int) error {
+	f.conversations[conversationID].OwnerID = ownerID
+	return nil
+}
+
+func (f *fakeConversations) Delete(ctx context.Context, conversationID int) error {
+	delete(f.conversations, conversationID)
+	return nil
+}
+
+func (f *fakeConversations) AddMember(ctx context.Context, conversationID int, userID int, joinedAt time.Time) error {
+	conversation := f.conversations[conversationID]
+	if isMember(*conversation, userID) {
+		return repository.ErrMemberExists
+	}
+	if userID > 4 {
+		return repository.ErrForeignKeyConstraint
+	}
+
+	conversation.Members = append(conversation.Members, model.ConversationMember{User: model.User{ID: userID}})
+	return nil
+}
+
+func (f *fakeConversations) RemoveMember(ctx context.Context, conversationID int, userID int) error {
+	conversation := f.conversations[conversationID]
+	for i, member := range conversation.Members {
+		if member.User.ID == userID {
+			conversation.Members = append(conversation.Members[:i:i], conversation.Members[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNoRows
+}
+
+func (f *fakeConversations) CreateMessage(ctx context.Context, message model.ConversationMessage) (int, error) {
+	f.messages = append(f.messages, message)
+	return len(f.messages), nil
+}
+
+func (f *fakeConversations) MarkRead(ctx context.Context, conversationID int, userID int, messageID int) (bool, error) {
+	conversation := f.conversations[conversationID]
+	for i := range conversation.Members {
+		member := &conversation.Members[i]
+		if member.User.ID == userID && member.LastReadMessageID < messageID {
+			member.LastReadMessageID = messageID
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestCreateConversation(t *testing.T) {
+	tests := []struct {
+		name        string
+		title       string
+		memberIDs   []int
+		wantMembers []int
+		wantErr     error
+	}{
+		{"created", " Friends ", []int{2, 3}, []int{1, 2, 3}, nil},
+		{"repeated members", "Friends", []int{2, 1, 2}, []int{1, 2}, nil},
+		{"blank title", " ", []int{2}, nil, ErrInvalidTitle},
+		{"only the owner", "Friends", []int{1}, nil, ErrNoMembers},
+		{"unknown member", "Friends", []int{2, 5}, nil, ErrUserDoesNotExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			s := NewConversation(&fakeConversations{conversations: map[int]*model.Conversation{}}, publisher)
+
+			conversation, err := s.Create(context.Background(), 1, tt.title, tt.memberIDs)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got := memberIDs(conversation); !reflect.DeepEqual(got, tt.wantMembers) || conversation.OwnerID != 1 || conversation.Title != "Friends" {
+				t.Errorf("Create() = %+v, want %q owned by 1 with members %v", conversation, "Friends", tt.wantMembers)
+			}
+			if len(publisher.events) != 1 || !reflect.DeepEqual(publisher.events[0].userIDs, tt.wantMembers) {
+				t.Errorf("published %v, want the conversation to its members", publisher.events)
+			}
+		})
+	}
+}
+
+func TestConversationMembership(t *testing.T) {
+	const (
+		owner    = 1
+		member   = 2
+		latest   = 3
+		outsider = 4
+	)
+
+	tests := []struct {
+		name        string
+		change      func(s *ConversationService) error
+		wantErr     error
+		wantOwner   int
+		wantMembers []int
+	}{
+		{
+			name: "outsider reads",
+			change: func(s *ConversationService) error {
+				_, _, err := s.GetMessages(context.Background(), outsider, 1, "")
+				return err
+			},
+			wantErr:     ErrConversationNotFound,
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest},
+		},
+		{
+			name: "outsider writes",
+			change: func(s *ConversationService) error {
+				_, err := s.SendMessage(context.Background(), outsider, 1, "hi")
+				return err
+			},
+			wantErr:     ErrConversationNotFound,
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest},
+		},
+		{
+			name:        "missing conversation",
+			change:      func(s *ConversationService) error { _, err := s.GetByID(context.Background(), owner, 2); return err },
+			wantErr:     ErrConversationNotFound,
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest},
+		},
+		{
+			name: "member renames",
+			change: func(s *ConversationService) error {
+				_, err := s.Rename(context.Background(), member, 1, "Mine")
+				return err
+			},
+			wantErr:     ErrNotConversationOwner,
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest},
+		},
+		{
+			name: "member invites",
+			change: func(s *ConversationService) error {
+				_, err := s.AddMember(context.Background(), member, 1, outsider)
+				return err
+			},
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest, outsider},
+		},
+		{
+			name: "member invited again",
+			change: func(s *ConversationService) error {
+				_, err := s.AddMember(context.Background(), member, 1, latest)
+				return err
+			},
+			wantErr:     ErrAlreadyMember,
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest},
+		},
+		{
+			name: "outsider invites",
+			change: func(s *ConversationService) error {
+				_, err := s.AddMember(context.Background(), outsider, 1, outsider)
+				return err
+			},
+			wantErr:     ErrConversationNotFound,
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest},
+		},
+		{
+			name:        "member removes another",
+			change:      func(s *ConversationService) error { return s.RemoveMember(context.Background(), member, 1, latest) },
+			wantErr:     ErrNotConversationOwner,
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest},
+		},
+		{
+			name:        "owner removes a member",
+			change:      func(s *ConversationService) error { return s.RemoveMember(context.Background(), owner, 1, latest) },
+			wantOwner:   owner,
+			wantMembers: []int{owner, member},
+		},
+		{
+			name:        "owner removes an outsider",
+			change:      func(s *ConversationService) error { return s.RemoveMember(context.Background(), owner, 1, outsider) },
+			wantErr:     ErrNotConversationMember,
+			wantOwner:   owner,
+			wantMembers: []int{owner, member, latest},
+		},
+		{
+			name:        "member leaves",
+			change:      func(s *ConversationService) error { return s.RemoveMember(context.Background(), latest, 1, latest) },
+			wantOwner:   owner,
+			wantMembers: []int{owner, member},
+		},
+		{
+			name:        "owner leaves",
+			change:      func(s *ConversationService) error { return s.RemoveMember(context.Background(), owner, 1, owner) },
+			wantOwner:   member,
+			wantMembers: []int{member, latest},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeConversations{conversations: map[int]*model.Conversation{}}
+			s := NewConversation(repo, &fakePublisher{})
+
+			// the members joined in order
+			if _, err := s.Create(context.Background(), owner, "Friends", []int{member, latest}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tt.change(s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			conversation := repo.conversations[1]
+			if conversation.OwnerID != tt.wantOwner || !reflect.DeepEqual(memberIDs(*conversation), tt.wantMembers) {
+				t.Errorf("owner %d, members %v, want %d, %v", conversation.OwnerID, memberIDs(*conversation), tt.wantOwner, tt.wantMembers)
+			}
+		})
+	}
+}
+
+func TestLastMemberLeaves(t *testing.T) {
+	repo := &fakeConversations{conversations: map[int]*model.Conversation{}}
+	publisher := &fakePublisher{}
+	s := NewConversation(repo, publisher)
+	ctx := context.Background()
+
+	if _, err := s.Create(ctx, 1, "Pair", []int{2}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, userID := range []int{1, 2} {
+		if err := s.RemoveMember(ctx, userID, 1, userID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, ok := repo.conversations[1]; ok {
+		t.Error("conversation without members is kept")
+	}
+
+	last := publisher.events[len(publisher.events)-1]
+	if last.eventType != ConversationRemovedEvent || !reflect.DeepEqual(last.userIDs, []int{2}) {
+		t.Errorf("last event = %+v, want the conversation removed for the last member", last)
+	}
+}
+
+func TestConversationMessages(t *testing.T) {
+	repo := &fakeConversations{conversations: map[int]*model.Conversation{}}
+	publisher := &fakePublisher{}
+	s := NewConversation(repo, publisher)
+	ctx := context.Background()
+
+	if _, err := s.Create(ctx, 1, "Friends", []int{2, 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, text := range []string{" \n", "\x01\x02"} {
+		if _, err := s.SendMessage(ctx, 1, 1, text); !errors.Is(err, ErrEmptyMessage) {
+			t.Errorf("SendMessage(%q) error = %v, want %v", text, err, ErrEmptyMessage)
+		}
+	}
+
+	publisher.events = nil
+
+	message, err := s.SendMessage(ctx, 1, 1, "\x01hi all\x02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Message != "hi all" {
+		t.Errorf("SendMessage() stored %q, want the control characters stripped", message.Message)
+	}
+
+	// the sender has read the own message, the others are told about it
+	if got := repo.conversations[1].Members[0].LastReadMessageID; got != message.ID {
+		t.Errorf("last read message of the sender = %d, want %d", got, message.ID)
+	}
+	if len(publisher.events) != 1 || !reflect.DeepEqual(publisher.events[0].userIDs, []int{1, 2, 3}) {
+		t.Errorf("published %v, want the message to every member", publisher.events)
+	}
+
+	tests := []struct {
+		name        string
+		userID      int
+		messageID   int
+		wantErr     error
+		wantPublish bool
+	}{
+		{"read", 2, message.ID, nil, true},
+		{"read again", 2, message.ID, nil, false},
+		{"no message", 3, 0, ErrMessageNotFound, false},
+		{"outsider", 4, message.ID, ErrConversationNotFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher.events = nil
+
+			_, err := s.ReadMessages(ctx, tt.userID, 1, tt.messageID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadMessages() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if published := len(publisher.events) == 1; published != tt.wantPublish {
+				t.Errorf("published %v, want a read event: %v", publisher.events, tt.wantPublish)
+			}
+		})
+	}
+}
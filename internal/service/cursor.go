@@ -0,0 +1,116 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"real-time-forum/internal/repository"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursors are the tokens of the pages next to the returned one: Next goes on along the list,
+// Prev goes back towards its beginning. A token is empty when there's nothing that way.
+type Cursors struct {
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// cursor is the content of a page token. Besides the position a feed keeps its order, period
+// and the time its first page was read at, so all of its pages are ranked alike.
+type cursor struct {
+	Key    float64 `json:"k,omitempty"`
+	ID     int     `json:"i"`
+	Before bool    `json:"b,omitempty"`
+	Sort   string  `json:"s,omitempty"`
+	Period string  `json:"p,omitempty"`
+	Time   int64   `json:"t,omitempty"` // unix nanoseconds
+}
+
+// decodeCursor reads a token given by a client, an empty token is the first page.
+func decodeCursor(token string) (cursor, error) {
+	if token == "" {
+		return cursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	var c cursor
+
+	if err := json.Unmarshal(data, &c); err != nil || c.ID <= 0 {
+		return cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (c cursor) isFirstPage() bool {
+	return c.ID == 0
+}
+
+// position is where the page is read from in the repository.
+func (c cursor) position() repository.Cursor {
+	if c.isFirstPage() {
+		return repository.FirstPage
+	}
+
+	return repository.Cursor{Key: c.Key, ID: c.ID, Before: c.Before}
+}
+
+// paginate cuts the page read with one item over the limit, the extra item tells the list goes on.
+// position returns the sort key and the id of an item, the cursors around the page point at them.
+func paginate[T any](items []T, limit int, c cursor, position func(T) (float64, int)) ([]T, Cursors) {
+	more := len(items) > limit
+	if more && c.Before {
+		items = items[len(items)-limit:]
+	} else if more {
+		items = items[:limit]
+	}
+
+	var cursors Cursors
+
+	if len(items) == 0 {
+		// nothing is left past the cursor, the way back starts from it
+		if !c.isFirstPage() {
+			back := c
+			back.Before = !c.Before
+			if c.Before {
+				cursors.Next = back.encode()
+			} else {
+				cursors.Prev = back.encode()
+			}
+		}
+		return items, cursors
+	}
+
+	first, last := c, c
+	first.Key, first.ID = position(items[0])
+	first.Before = true
+	last.Key, last.ID = position(items[len(items)-1])
+	last.Before = false
+
+	if c.Before {
+		cursors.Next = last.encode()
+		if more {
+			cursors.Prev = first.encode()
+		}
+	} else {
+		if more {
+			cursors.Next = last.encode()
+		}
+		if !c.isFirstPage() {
+			cursors.Prev = first.encode()
+		}
+	}
+
+	return items, cursors
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	saved := cursor{Key: 4.5, ID: 7, Before: true, Sort: "top", Period: "week", Time: 1700000000000000000}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    cursor
+		wantErr error
+	}{
+		{"empty is the first page", "", cursor{}, nil},
+		{"round trip", saved.encode(), saved, nil},
+		{"id only", cursor{ID: 1}.encode(), cursor{ID: 1}, nil},
+		{"not base64", "not a cursor!", cursor{}, ErrInvalidCursor},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"i":1}`)), cursor{}, ErrInvalidCursor},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("page 2")), cursor{}, ErrInvalidCursor},
+		{"wrong type", base64.RawURLEncoding.EncodeToString([]byte(`{"i":"1"}`)), cursor{}, ErrInvalidCursor},
+		{"no id", base64.RawURLEncoding.EncodeToString([]byte(`{"k":1}`)), cursor{}, ErrInvalidCursor},
+		{"negative id", cursor{ID: -3}.encode(), cursor{}, ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decodeCursor(%q) error = %v, want %v", tt.token, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("decodeCursor(%q) = %+v, want %+v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	// items are ids, an item is sorted by ten times its id
+	position := func(id int) (float64, int) { return float64(id * 10), id }
+	at := func(id int, before bool) string {
+		return cursor{Key: float64(id * 10), ID: id, Before: before, Sort: "new"}.encode()
+	}
+	from := cursor{Key: 50, ID: 5, Sort: "new"}
+	back := cursor{Key: 50, ID: 5, Before: true, Sort: "new"}
+
+	tests := []struct {
+		name      string
+		items     []int
+		c         cursor
+		wantItems []int
+		want      Cursors
+	}{
+		{
+			name:      "first page with more",
+			items:     []int{1, 2, 3, 4},
+			c:         cursor{Sort: "new"},
+			wantItems: []int{1, 2, 3},
+			want:      Cursors{Next: at(3, false)},
+		},
+		{
+			name:      "only page",
+			items:     []int{1, 2},
+			c:         cursor{Sort: "new"},
+			wantItems: []int{1, 2},
+			want:      Cursors{},
+		},
+		{
+			name:      "empty first page",
+			items:     []int{},
+			c:         cursor{Sort: "new"},
+			wantItems: []int{},
+			want:      Cursors{},
+		},
+		{
+			name:      "forward with more",
+			items:     []int{6, 7, 8, 9},
+			c:         from,
+			wantItems: []int{6, 7, 8},
+			want:      Cursors{Next: at(8, false), Prev: at(6, true)},
+		},
+		{
+			name:      "forward to the last page",
+			items:     []int{6, 7},
+			c:         from,
+			wantItems: []int{6, 7},
+			want:      Cursors{Prev: at(6, true)},
+		},
+		{
+			name:      "forward past the end",
+			items:     []int{},
+			c:         from,
+			wantItems: []int{},
+			want:      Cursors{Prev: back.encode()},
+		},
+		{
+			name:      "backward with more",
+			items:     []int{1, 2, 3, 4},
+			c:         back,
+			wantItems: []int{2, 3, 4},
+			want:      Cursors{Next: at(4, false), Prev: at(2, true)},
+		},
+		{
+			name:      "backward to the first page",
+			items:     []int{3, 4},
+			c:         back,
+			wantItems: []int{3, 4},
+			want:      Cursors{Next: at(4, false)},
+		},
+		{
+			name:      "backward past the beginning",
+			items:     []int{},
+			c:         back,
+			wantItems: []int{},
+			want:      Cursors{Next: from.encode()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, cursors := paginate(tt.items, 3, tt.c, position)
+			if !reflect.DeepEqual(items, tt.wantItems) {
+				t.Errorf("items = %v, want %v", items, tt.wantItems)
+			}
+			if cursors != tt.want {
+				t.Errorf("cursors = %+v, want %+v", cursors, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"fmt"
+
+	"real-time-forum/internal/model"
+)
+
+// Publisher delivers events of the services to connected clients.
+type Publisher interface {
+	// Publish delivers the event to clients subscribed to any of the topics.
+	Publish(topics []string, eventType string, body interface{})
+	// PublishToUsers delivers the event to every connection of the users.
+	PublishToUsers(userIDs []int, eventType string, body interface{})
+}
+
+// feed event types, published after the write is committed
+const (
+	PostCreatedEvent      = "postCreated"
+	CommentCreatedEvent   = "commentCreated"
+	ScoreChangedEvent     = "scoreChanged"
+	ReactionsChangedEvent = "reactionsChanged"
+)
+
+// targets of a score change or reactions, messages have reactions only
+const (
+	PostTarget    = "post"
+	CommentTarget = "comment"
+	MessageTarget = "message"
+)
+
+// CategoryTopic is the feed of new posts and their scores in the category.
+func CategoryTopic(categoryID int) string {
+	return fmt.Sprintf("category:%d", categoryID)
+}
+
+// PostTopic is the thread of the post: its comments and scores.
+func PostTopic(postID int) string {
+	return fmt.Sprintf("post:%d", postID)
+}
+
+// postTopics are the topics where changes of the post are visible.
+func postTopics(post model.Post) []string {
+	topics := []string{PostTopic(post.ID)}
+	for _, category := range post.Categories {
+		topics = append(topics, CategoryTopic(category.ID))
+	}
+
+	return topics
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"real-time-forum/internal/model"
+)
+
+// published is an event sent to the topics or to the users.
+type published struct {
+	topics    []string
+	userIDs   []int
+	eventType string
+	body      interface{}
+}
+
+// fakePublisher records the feed events instead of delivering them.
+type fakePublisher struct {
+	events []published
+}
+
+func (f *fakePublisher) Publish(topics []string, eventType string, body interface{}) {
+	f.events = append(f.events, published{topics: topics, eventType: eventType, body: body})
+}
+
+func (f *fakePublisher) PublishToUsers(userIDs []int, eventType string, body interface{}) {
+	f.events = append(f.events, published{userIDs: userIDs, eventType: eventType, body: body})
+}
+
+func TestFeedEvents(t *testing.T) {
+	// the post 1 is in the categories "All" and 2, the comment 1 is under it
+	post := model.Post{ID: 1, Author: model.User{ID: 1}, Categories: []model.Category{{ID: 1}, {ID: 2}}}
+	comment := model.Comment{PostID: 1, Author: model.User{ID: 1}, Content: "first"}
+
+	tests := []struct {
+		name       string
+		write      func(publisher Publisher) error
+		wantTopics []string
+		wantType   string
+		wantBody   interface{}
+	}{
+		{
+			name: "post created",
+			write: func(publisher Publisher) error {
+				s := NewPost(&fakePosts{posts: map[int]model.Post{}}, &fakeCategories{}, newFakeImages(), &fakeStorage{}, publisher)
+				_, err := s.Create(context.Background(), PostInput{UserID: 1, Title: "Go", Content: "channels", CategoryIDs: []int{2}})
+				return err
+			},
+			wantTopics: []string{"category:1", "category:2"},
+			wantType:   PostCreatedEvent,
+		},
+		{
+			name: "comment created",
+			write: func(publisher Publisher) error {
+				_, err := NewComment(&fakeComments{}, publisher, commentsConfig).Create(context.Background(), CommentInput{UserID: 1, PostID: 1, Content: "first"})
+				return err
+			},
+			wantTopics: []string{"post:1"},
+			wantType:   CommentCreatedEvent,
+		},
+		{
+			name: "post voted",
+			write: func(publisher Publisher) error {
+				s := NewVote(&fakeVotes{}, &fakePosts{posts: map[int]model.Post{1: post}}, &fakeComments{}, publisher)
+				_, err := s.VotePost(context.Background(), 2, 1, Like)
+				return err
+			},
+			wantTopics: []string{"post:1", "category:1", "category:2"},
+			wantType:   ScoreChangedEvent,
+			wantBody:   model.ScoreChange{Target: PostTarget, TargetID: 1, PostID: 1, Rating: 1},
+		},
+		{
+			name: "comment voted",
+			write: func(publisher Publisher) error {
+				s := NewVote(&fakeVotes{}, &fakePosts{}, &fakeComments{created: []model.Comment{comment}}, publisher)
+				_, err := s.VoteComment(context.Background(), 2, 1, Dislike)
+				return err
+			},
+			wantTopics: []string{"post:1"},
+			wantType:   ScoreChangedEvent,
+			wantBody:   model.ScoreChange{Target: CommentTarget, TargetID: 1, PostID: 1, Rating: -1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+
+			if err := tt.write(publisher); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(publisher.events) != 1 {
+				t.Fatalf("published %v, want one event", publisher.events)
+			}
+
+			e := publisher.events[0]
+			if !reflect.DeepEqual(e.topics, tt.wantTopics) || e.eventType != tt.wantType {
+				t.Errorf("published %s to %v, want %s to %v", e.eventType, e.topics, tt.wantType, tt.wantTopics)
+			}
+			if tt.wantBody != nil && e.body != tt.wantBody {
+				t.Errorf("published %+v, want %+v", e.body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/images"
+)
+
+type Image interface {
+	UploadPostImage(ctx context.Context, userID int, postID int, r io.Reader) (images.Image, error)
+	UploadCommentImage(ctx context.Context, userID int, commentID int, r io.Reader) (images.Image, error)
+	UploadAvatar(ctx context.Context, userID int, r io.Reader) (images.Image, error)
+	FilePath(name string) (string, error)
+	RemoveOrphans(ctx context.Context) (int, error)
+}
+
+type ImageService struct {
+	repo        repository.Image
+	postRepo    repository.Post
+	commentRepo repository.Comment
+	storage     images.Storage
+}
+
+func NewImage(repo repository.Image, postRepo repository.Post, commentRepo repository.Comment, storage images.Storage) *ImageService {
+	return &ImageService{
+		repo:        repo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		storage:     storage,
+	}
+}
+
+var ErrNotCommentAuthor = errors.New("only author can modify the comment")
+
+func (s *ImageService) UploadPostImage(ctx context.Context, userID int, postID int, r io.Reader) (images.Image, error) {
+	post, err := s.postRepo.GetByID(ctx, postID, 0)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return images.Image{}, ErrPostNotFound
+		}
+		return images.Image{}, err
+	}
+
+	if post.Author.ID != userID {
+		return images.Image{}, ErrNotPostAuthor
+	}
+
+	img, err := s.storage.Save(r)
+	if err != nil {
+		return images.Image{}, err
+	}
+
+	if err := s.repo.AddPostImage(ctx, postID, img.Name); err != nil {
+		// the post was deleted while uploading
+		removeUnusedImages(ctx, s.repo, s.storage, img.Name)
+		if errors.Is(err, repository.ErrForeignKeyConstraint) {
+			return images.Image{}, ErrPostNotFound
+		}
+		return images.Image{}, err
+	}
+
+	return img, nil
+}
+
+func (s *ImageService) UploadCommentImage(ctx context.Context, userID int, commentID int, r io.Reader) (images.Image, error) {
+	comment, err := s.commentRepo.GetByID(ctx, commentID, 0)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return images.Image{}, ErrCommentNotFound
+		}
+		return images.Image{}, err
+	}
+
+	if comment.Author.ID != userID {
+		return images.Image{}, ErrNotCommentAuthor
+	}
+
+	img, err := s.storage.Save(r)
+	if err != nil {
+		return images.Image{}, err
+	}
+
+	old, err := s.repo.SetCommentImage(ctx, commentID, img.Name)
+	if err != nil {
+		removeUnusedImages(ctx, s.repo, s.storage, img.Name)
+		if errors.Is(err, repository.ErrNoRows) {
+			return images.Image{}, ErrCommentNotFound
+		}
+		return images.Image{}, err
+	}
+
+	removeUnusedImages(ctx, s.repo, s.storage, old)
+
+	return img, nil
+}
+
+func (s *ImageService) UploadAvatar(ctx context.Context, userID int, r io.Reader) (images.Image, error) {
+	img, err := s.storage.Save(r)
+	if err != nil {
+		return images.Image{}, err
+	}
+
+	old, err := s.repo.SetAvatar(ctx, userID, img.Name)
+	if err != nil {
+		removeUnusedImages(ctx, s.repo, s.storage, img.Name)
+		if errors.Is(err, repository.ErrNoRows) {
+			return images.Image{}, ErrUserDoesNotExists
+		}
+		return images.Image{}, err
+	}
+
+	removeUnusedImages(ctx, s.repo, s.storage, old)
+
+	return img, nil
+}
+
+func (s *ImageService) FilePath(name string) (string, error) {
+	return s.storage.Path(name)
+}
+
+// RemoveOrphans removes stored images nothing refers to, the ones a failed cleanup left behind.
+func (s *ImageService) RemoveOrphans(ctx context.Context) (int, error) {
+	names, err := s.storage.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+
+	for _, name := range names {
+		referenced, err := s.repo.IsReferenced(ctx, name)
+		if err != nil {
+			return removed, err
+		}
+
+		if referenced {
+			continue
+		}
+
+		if err := s.storage.Remove(name); err != nil {
+			return removed, err
+		}
+
+		removed++
+	}
+
+	return removed, nil
+}
+
+// removeUnusedImages removes the uploaded images nothing refers to anymore. The change that
+// left them unused is already committed, a file that failed to go is removed by RemoveOrphans.
+func removeUnusedImages(ctx context.Context, repo repository.Image, storage images.Storage, names ...string) {
+	for _, name := range names {
+		// default avatars and legacy paths are not uploads
+		if !images.IsStored(name) {
+			continue
+		}
+
+		if referenced, err := repo.IsReferenced(ctx, name); err != nil || referenced {
+			continue
+		}
+
+		storage.Remove(name)
+	}
+}
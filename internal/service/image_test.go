@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/images"
+)
+
+// fakeImages counts the references to every image, the post 1 and the users up to 4 exist.
+type fakeImages struct {
+	references    map[string]int
+	postFiles     map[int][]string
+	commentImages map[int]string
+	avatars       map[int]string
+}
+
+func newFakeImages() *fakeImages {
+	return &fakeImages{
+		references:    map[string]int{},
+		postFiles:     map[int][]string{},
+		commentImages: map[int]string{},
+		avatars:       map[int]string{},
+	}
+}
+
+func (f *fakeImages) AddPostImage(ctx context.Context, postID int, image string) error {
+	if postID != 1 {
+		return repository.ErrForeignKeyConstraint
+	}
+
+	f.postFiles[postID] = append(f.postFiles[postID], image)
+	f.references[image]++
+	return nil
+}
+
+func (f *fakeImages) SetCommentImage(ctx context.Context, commentID int, image string) (string, error) {
+	old := f.commentImages[commentID]
+	f.commentImages[commentID] = image
+	f.references[old]--
+	f.references[image]++
+	return old, nil
+}
+
+func (f *fakeImages) SetAvatar(ctx context.Context, userID int, image string) (string, error) {
+	if userID > 4 {
+		return "", repository.ErrNoRows
+	}
+
+	old, ok := f.avatars[userID]
+	if !ok {
+		old = "default.jpg"
+	}
+	f.avatars[userID] = image
+	f.references[old]--
+	f.references[image]++
+	return old, nil
+}
+
+func (f *fakeImages) GetPostFiles(ctx context.Context, postID int) ([]string, error) {
+	return f.postFiles[postID], nil
+}
+
+func (f *fakeImages) IsReferenced(ctx context.Context, image string) (bool, error) {
+	return f.references[image] > 0, nil
+}
+
+// fakeStorage stores every upload under the next content addressed name.
+type fakeStorage struct {
+	stored  []string
+	removed []string
+}
+
+func storedName(n int) string {
+	return fmt.Sprintf("%064x.jpg", n)
+}
+
+func (f *fakeStorage) Save(r io.Reader) (images.Image, error) {
+	name := storedName(len(f.stored) + 1)
+	f.stored = append(f.stored, name)
+	return images.Image{Name: name}, nil
+}
+
+func (f *fakeStorage) Remove(name string) error {
+	f.removed = append(f.removed, name)
+	return nil
+}
+
+func (f *fakeStorage) Path(name string) (string, error) {
+	return name, nil
+}
+
+func (f *fakeStorage) List() ([]string, error) {
+	return f.stored, nil
+}
+
+func TestUploadImages(t *testing.T) {
+	post := model.Post{ID: 1, Author: model.User{ID: 1}}
+	comment := model.Comment{PostID: 1, Author: model.User{ID: 2}}
+
+	tests := []struct {
+		name        string
+		upload      func(s *ImageService) error
+		wantErr     error
+		wantRemoved []string
+	}{
+		{"post image by the author", func(s *ImageService) error {
+			_, err := s.UploadPostImage(context.Background(), 1, 1, strings.NewReader("image"))
+			return err
+		}, nil, nil},
+		{"post image by others", func(s *ImageService) error {
+			_, err := s.UploadPostImage(context.Background(), 2, 1, strings.NewReader("image"))
+			return err
+		}, ErrNotPostAuthor, nil},
+		{"image of a missing post", func(s *ImageService) error {
+			_, err := s.UploadPostImage(context.Background(), 1, 2, strings.NewReader("image"))
+			return err
+		}, ErrPostNotFound, nil},
+		{"comment image replaces the old one", func(s *ImageService) error {
+			if _, err := s.UploadCommentImage(context.Background(), 2, 1, strings.NewReader("first")); err != nil {
+				return err
+			}
+			_, err := s.UploadCommentImage(context.Background(), 2, 1, strings.NewReader("second"))
+			return err
+		}, nil, []string{storedName(1)}},
+		{"comment image by others", func(s *ImageService) error {
+			_, err := s.UploadCommentImage(context.Background(), 1, 1, strings.NewReader("image"))
+			return err
+		}, ErrNotCommentAuthor, nil},
+		{"first avatar keeps the default", func(s *ImageService) error {
+			_, err := s.UploadAvatar(context.Background(), 1, strings.NewReader("avatar"))
+			return err
+		}, nil, nil},
+		{"avatar of a missing user", func(s *ImageService) error {
+			_, err := s.UploadAvatar(context.Background(), 5, strings.NewReader("avatar"))
+			return err
+		}, ErrUserDoesNotExists, []string{storedName(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &fakeStorage{}
+			s := NewImage(
+				newFakeImages(),
+				&fakePosts{posts: map[int]model.Post{1: post}},
+				&fakeComments{created: []model.Comment{comment}},
+				storage,
+			)
+
+			if err := tt.upload(s); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("upload error = %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(storage.removed, tt.wantRemoved) {
+				t.Errorf("removed %v, want %v", storage.removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestRemoveOrphans(t *testing.T) {
+	repo := newFakeImages()
+	repo.references[storedName(2)] = 1
+	storage := &fakeStorage{stored: []string{storedName(1), storedName(2), storedName(3)}}
+
+	removed, err := NewImage(repo, &fakePosts{}, &fakeComments{}, storage).RemoveOrphans(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if removed != 2 || !reflect.DeepEqual(storage.removed, []string{storedName(1), storedName(3)}) {
+		t.Errorf("removed %d %v, want the images 1 and 3", removed, storage.removed)
+	}
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/images"
+	"real-time-forum/pkg/validator"
+)
+
+type Post interface {
+	Create(ctx context.Context, input PostInput) (int, error)
+	GetByID(ctx context.Context, postID int, userID int) (model.Post, error)
+	Update(ctx context.Context, postID int, input PostInput) error
+	Delete(ctx context.Context, userID int, postID int) error
+	GetPostsByCategoryID(ctx context.Context, categoryID int, feed Feed, token string) ([]model.Post, Cursors, error)
+}
+
+type PostService struct {
+	repo         repository.Post
+	categoryRepo repository.Category
+	imageRepo    repository.Image
+	storage      images.Storage
+	publisher    Publisher
+}
+
+func NewPost(
+	repo repository.Post,
+	categoryRepo repository.Category,
+	imageRepo repository.Image,
+	storage images.Storage,
+	publisher Publisher) *PostService {
+	return &PostService{
+		repo:         repo,
+		categoryRepo: categoryRepo,
+		imageRepo:    imageRepo,
+		storage:      storage,
+		publisher:    publisher,
+	}
+}
+
+type PostInput struct {
+	UserID      int
+	Title       string
+	Content     string
+	CategoryIDs []int
+}
+
+const (
+	postsLimit = 10
+	// every post belongs to the "All" category, the feed of all posts
+	allCategoryID = 1
+)
+
+var (
+	ErrPostNotFound    = errors.New("post doesn't exists")
+	ErrNotPostAuthor   = errors.New("only author can modify the post")
+	ErrEmptyPost       = errors.New("title and content must not be empty")
+	ErrNoCategories    = errors.New("at least one category must be selected")
+	ErrUnknownCategory = errors.New("category doesn't exists")
+	ErrInvalidPage     = errors.New("page must be positive")
+	ErrUnknownSort     = errors.New("sort must be new, top, hot or discussed")
+	ErrUnknownPeriod   = errors.New("period must be day, week, month or all")
+)
+
+// Feed is the order of a posts feed and the age of the posts in it, empty values are the defaults.
+type Feed struct {
+	Sort   string
+	Period string
+}
+
+const (
+	SortNew       = "new"
+	SortTop       = "top"
+	SortHot       = "hot"
+	SortDiscussed = "discussed"
+)
+
+var feedOrders = map[string]repository.FeedOrder{
+	SortNew:       repository.NewestPosts,
+	SortTop:       repository.TopPosts,
+	SortHot:       repository.HotPosts,
+	SortDiscussed: repository.DiscussedPosts,
+}
+
+// feedPeriods are the ages of posts in a feed, 0 doesn't limit it.
+var feedPeriods = map[string]time.Duration{
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+func (s *PostService) Create(ctx context.Context, input PostInput) (int, error) {
+	post, err := newPost(input)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.checkCategories(ctx, post.Categories, nil); err != nil {
+		return 0, err
+	}
+
+	post.Author.ID = input.UserID
+	post.CreationTime = time.Now()
+
+	id, err := s.repo.Create(ctx, post)
+	if err != nil {
+		if errors.Is(err, repository.ErrForeignKeyConstraint) {
+			return 0, ErrUnknownCategory
+		}
+		return 0, err
+	}
+
+	if post, err := s.repo.GetByID(ctx, id, 0); err == nil {
+		topics := make([]string, 0, len(post.Categories))
+		for _, category := range post.Categories {
+			topics = append(topics, CategoryTopic(category.ID))
+		}
+
+		s.publisher.Publish(topics, PostCreatedEvent, post)
+	}
+
+	return id, nil
+}
+
+func (s *PostService) GetByID(ctx context.Context, postID int, userID int) (model.Post, error) {
+	post, err := s.repo.GetByID(ctx, postID, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return model.Post{}, ErrPostNotFound
+		}
+		return model.Post{}, err
+	}
+
+	return post, nil
+}
+
+func (s *PostService) Update(ctx context.Context, postID int, input PostInput) error {
+	old, err := s.checkAuthor(ctx, postID, input.UserID)
+	if err != nil {
+		return err
+	}
+
+	post, err := newPost(input)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkCategories(ctx, post.Categories, old.Categories); err != nil {
+		return err
+	}
+
+	post.ID = postID
+
+	if err := s.repo.Update(ctx, post); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if errors.Is(err, repository.ErrForeignKeyConstraint) {
+			return ErrUnknownCategory
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *PostService) Delete(ctx context.Context, userID int, postID int) error {
+	if _, err := s.checkAuthor(ctx, postID, userID); err != nil {
+		return err
+	}
+
+	// images of the post and its comments are gone with the rows
+	files, err := s.imageRepo.GetPostFiles(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, userID, postID); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		return err
+	}
+
+	removeUnusedImages(ctx, s.imageRepo, s.storage, files...)
+
+	return nil
+}
+
+// GetPostsByCategoryID returns a page of the feed, a cursor keeps the order and the period of the feed it came from.
+func (s *PostService) GetPostsByCategoryID(ctx context.Context, categoryID int, feed Feed, token string) ([]model.Post, Cursors, error) {
+	c, err := decodeCursor(token)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	now := time.Now()
+	if !c.isFirstPage() {
+		feed = Feed{Sort: c.Sort, Period: c.Period}
+		now = time.Unix(0, c.Time)
+	}
+
+	if feed.Sort == "" {
+		feed.Sort = SortNew
+	}
+
+	order, ok := feedOrders[feed.Sort]
+	if !ok {
+		return nil, Cursors{}, ErrUnknownSort
+	}
+
+	// hot posts are recent anyway, the window keeps the ranking off the old ones
+	if feed.Period == "" && feed.Sort == SortHot {
+		feed.Period = "week"
+	} else if feed.Period == "" {
+		feed.Period = "all"
+	}
+
+	period, ok := feedPeriods[feed.Period]
+	if !ok {
+		return nil, Cursors{}, ErrUnknownPeriod
+	}
+
+	var since time.Time
+	if period > 0 {
+		since = now.Add(-period)
+	}
+
+	c.Sort, c.Period, c.Time = feed.Sort, feed.Period, now.UnixNano()
+
+	posts, err := s.repo.GetPostsByCategoryID(ctx, repository.Feed{
+		CategoryID: categoryID,
+		Order:      order,
+		Since:      since,
+		Now:        now,
+	}, c.position(), postsLimit+1)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	posts, cursors := paginate(posts, postsLimit, c, func(post model.Post) (float64, int) {
+		return post.SortKey, post.ID
+	})
+
+	return posts, cursors, nil
+}
+
+func (s *PostService) checkAuthor(ctx context.Context, postID int, userID int) (model.Post, error) {
+	post, err := s.GetByID(ctx, postID, userID)
+	if err != nil {
+		return model.Post{}, err
+	}
+
+	if post.Author.ID != userID {
+		return model.Post{}, ErrNotPostAuthor
+	}
+
+	return post, nil
+}
+
+// checkCategories rejects unknown and archived categories,
+// archived ones the post already belongs to are kept.
+func (s *PostService) checkCategories(ctx context.Context, categories []model.Category, current []model.Category) error {
+	kept := make(map[int]bool, len(current))
+	for _, category := range current {
+		kept[category.ID] = true
+	}
+
+	for _, category := range categories {
+		if category.ID == allCategoryID || kept[category.ID] {
+			continue
+		}
+
+		category, err := s.categoryRepo.GetByID(ctx, category.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNoRows) {
+				return ErrUnknownCategory
+			}
+			return err
+		}
+
+		if category.Archived {
+			return ErrArchivedCategory
+		}
+	}
+
+	return nil
+}
+
+// newPost validates the input and attaches the post to the "All" category.
+func newPost(input PostInput) (model.Post, error) {
+	post := model.Post{
+		Title:   strings.TrimSpace(validator.StripControl(input.Title)),
+		Content: strings.TrimSpace(validator.StripControl(input.Content)),
+	}
+
+	if post.Title == "" || post.Content == "" {
+		return model.Post{}, ErrEmptyPost
+	}
+
+	post.Categories = []model.Category{{ID: allCategoryID}}
+	seen := map[int]bool{allCategoryID: true}
+
+	for _, id := range input.CategoryIDs {
+		if seen[id] {
+			continue
+		}
+
+		seen[id] = true
+		post.Categories = append(post.Categories, model.Category{ID: id})
+	}
+
+	if len(post.Categories) == 1 {
+		return model.Post{}, ErrNoCategories
+	}
+
+	return post, nil
+}
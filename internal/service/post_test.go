@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+// fakePosts keeps the posts by id and records the ones updated and deleted.
+type fakePosts struct {
+	repository.Post
+	posts   map[int]model.Post
+	updated []int
+	deleted []int
+
+	// the last feed read
+	feed repository.Feed
+}
+
+func (f *fakePosts) GetByID(ctx context.Context, postID int, userID int) (model.Post, error) {
+	post, ok := f.posts[postID]
+	if !ok {
+		return model.Post{}, repository.ErrNoRows
+	}
+	return post, nil
+}
+
+func (f *fakePosts) Create(ctx context.Context, post model.Post) (int, error) {
+	post.ID = len(f.posts) + 1
+	f.posts[post.ID] = post
+	return post.ID, nil
+}
+
+func (f *fakePosts) Update(ctx context.Context, post model.Post) error {
+	f.updated = append(f.updated, post.ID)
+	return nil
+}
+
+func (f *fakePosts) Delete(ctx context.Context, userID int, postID int) error {
+	f.deleted = append(f.deleted, postID)
+	return nil
+}
+
+func (f *fakePosts) GetPostsByCategoryID(ctx context.Context, feed repository.Feed, cursor repository.Cursor, limit int) ([]model.Post, error) {
+	f.feed = feed
+	return nil, nil
+}
+
+func categoryIDs(categories []model.Category) []int {
+	ids := []int{}
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	return ids
+}
+
+func TestNewPost(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          PostInput
+		wantTitle      string
+		wantCategories []int
+		wantErr        error
+	}{
+		{"trimmed", PostInput{Title: "  Go  ", Content: " channels\n", CategoryIDs: []int{2}}, "Go", []int{1, 2}, nil},
+		{"duplicate categories", PostInput{Title: "Go", Content: "c", CategoryIDs: []int{3, 2, 3, 1}}, "Go", []int{1, 3, 2}, nil},
+		{"search markers stripped", PostInput{Title: "\x02Go\x03", Content: "c", CategoryIDs: []int{2}}, "Go", []int{1, 2}, nil},
+		{"blank title", PostInput{Title: " ", Content: "c", CategoryIDs: []int{2}}, "", nil, ErrEmptyPost},
+		{"only control characters", PostInput{Title: "\x02\x03", Content: "c", CategoryIDs: []int{2}}, "", nil, ErrEmptyPost},
+		{"blank content", PostInput{Title: "Go", Content: "\n", CategoryIDs: []int{2}}, "", nil, ErrEmptyPost},
+		{"no categories", PostInput{Title: "Go", Content: "c"}, "", nil, ErrNoCategories},
+		{"only All", PostInput{Title: "Go", Content: "c", CategoryIDs: []int{1}}, "", nil, ErrNoCategories},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post, err := newPost(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("newPost() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if post.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", post.Title, tt.wantTitle)
+			}
+			if got := categoryIDs(post.Categories); !reflect.DeepEqual(got, tt.wantCategories) {
+				t.Errorf("categories = %v, want %v", got, tt.wantCategories)
+			}
+		})
+	}
+}
+
+func TestPostAuthorChecks(t *testing.T) {
+	input := PostInput{Title: "Go", Content: "channels", CategoryIDs: []int{2}}
+
+	tests := []struct {
+		name    string
+		userID  int
+		postID  int
+		wantErr error
+	}{
+		{"author", 1, 10, nil},
+		{"other user", 2, 10, ErrNotPostAuthor},
+		{"missing post", 1, 11, ErrPostNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, action := range []string{"update", "delete"} {
+				repo := &fakePosts{posts: map[int]model.Post{10: {ID: 10, Author: model.User{ID: 1}}}}
+				s := NewPost(repo, &fakeCategories{}, newFakeImages(), &fakeStorage{}, &fakePublisher{})
+
+				var err error
+				if action == "update" {
+					input.UserID = tt.userID
+					err = s.Update(context.Background(), tt.postID, input)
+				} else {
+					err = s.Delete(context.Background(), tt.userID, tt.postID)
+				}
+
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s error = %v, want %v", action, err, tt.wantErr)
+				}
+
+				changed := len(repo.updated) + len(repo.deleted)
+				if changed != 0 && tt.wantErr != nil {
+					t.Errorf("%s changed the post of another user", action)
+				}
+			}
+		})
+	}
+}
+
+func TestPostCategories(t *testing.T) {
+	// the categories 3 and 4 are archived, the post 10 is in the category 3
+	tests := []struct {
+		name        string
+		postID      int
+		categoryIDs []int
+		wantErr     error
+	}{
+		{"create", 0, []int{2}, nil},
+		{"create in an archived category", 0, []int{2, 3}, ErrArchivedCategory},
+		{"create in an unknown category", 0, []int{9}, ErrUnknownCategory},
+		{"update keeps the archived category", 10, []int{3, 2}, nil},
+		{"update adds an archived category", 10, []int{3, 4}, ErrArchivedCategory},
+		{"update adds an unknown category", 10, []int{3, 9}, ErrUnknownCategory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePosts{posts: map[int]model.Post{10: {
+				ID:         10,
+				Author:     model.User{ID: 1},
+				Categories: []model.Category{{ID: 1}, {ID: 3}},
+			}}}
+			s := NewPost(repo, &fakeCategories{archived: map[int]bool{3: true, 4: true}}, newFakeImages(), &fakeStorage{}, &fakePublisher{})
+
+			input := PostInput{UserID: 1, Title: "Go", Content: "channels", CategoryIDs: tt.categoryIDs}
+
+			var err error
+			if tt.postID == 0 {
+				_, err = s.Create(context.Background(), input)
+			} else {
+				err = s.Update(context.Background(), tt.postID, input)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFeed(t *testing.T) {
+	start := time.Now()
+
+	tests := []struct {
+		name       string
+		feed       Feed
+		token      string
+		wantOrder  repository.FeedOrder
+		wantPeriod time.Duration
+		wantErr    error
+	}{
+		{"defaults", Feed{}, "", repository.NewestPosts, 0, nil},
+		{"top of the day", Feed{Sort: SortTop, Period: "day"}, "", repository.TopPosts, 24 * time.Hour, nil},
+		{"hot is weekly by default", Feed{Sort: SortHot}, "", repository.HotPosts, 7 * 24 * time.Hour, nil},
+		{"hot of all time", Feed{Sort: SortHot, Period: "all"}, "", repository.HotPosts, 0, nil},
+		{"discussed of the month", Feed{Sort: SortDiscussed, Period: "month"}, "", repository.DiscussedPosts, 30 * 24 * time.Hour, nil},
+		{"cursor keeps its feed", Feed{Sort: SortNew}, cursor{ID: 5, Sort: SortTop, Period: "week", Time: time.Now().UnixNano()}.encode(),
+			repository.TopPosts, 7 * 24 * time.Hour, nil},
+
+		{"unknown sort", Feed{Sort: "random"}, "", repository.FeedOrder{}, 0, ErrUnknownSort},
+		{"unknown period", Feed{Period: "year"}, "", repository.FeedOrder{}, 0, ErrUnknownPeriod},
+		{"invalid cursor", Feed{}, "page=2", repository.FeedOrder{}, 0, ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePosts{}
+			s := NewPost(repo, &fakeCategories{}, newFakeImages(), &fakeStorage{}, &fakePublisher{})
+
+			if _, _, err := s.GetPostsByCategoryID(context.Background(), 1, tt.feed, tt.token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetPostsByCategoryID() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if repo.feed.Order != tt.wantOrder {
+				t.Errorf("order = %v, want %v", repo.feed.Order, tt.wantOrder)
+			}
+
+			since := repo.feed.Since
+			switch {
+			case tt.wantPeriod == 0 && !since.IsZero():
+				t.Errorf("since = %v, want no window", since)
+			case tt.wantPeriod > 0 && (since.Before(start.Add(-tt.wantPeriod)) || since.After(time.Now().Add(-tt.wantPeriod))):
+				t.Errorf("since = %v, want %v ago", since, tt.wantPeriod)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+type Presence interface {
+	Connect(ctx context.Context, userID int) (cameOnline bool, err error)
+	Disconnect(ctx context.Context, userID int) (wentOffline bool, err error)
+	IsOnline(userID int) bool
+	OnlineUserIDs() []int
+	GetOnlineUsers(ctx context.Context) ([]model.PublicUser, error)
+}
+
+// PresenceService counts open connections of every user, a user with several tabs
+// stays online until the last one is closed.
+type PresenceService struct {
+	repo repository.User
+
+	mu          sync.Mutex
+	connections map[int]int
+}
+
+func NewPresence(repo repository.User) *PresenceService {
+	return &PresenceService{
+		repo:        repo,
+		connections: make(map[int]int),
+	}
+}
+
+func (s *PresenceService) Connect(ctx context.Context, userID int) (bool, error) {
+	s.mu.Lock()
+	s.connections[userID]++
+	cameOnline := s.connections[userID] == 1
+	s.mu.Unlock()
+
+	if err := s.repo.UpdateLastSeen(ctx, userID, time.Now()); err != nil {
+		return cameOnline, err
+	}
+
+	return cameOnline, nil
+}
+
+// Disconnect stores the time the user was last seen when the connection is closed.
+func (s *PresenceService) Disconnect(ctx context.Context, userID int) (bool, error) {
+	s.mu.Lock()
+	s.connections[userID]--
+	wentOffline := s.connections[userID] <= 0
+	if wentOffline {
+		delete(s.connections, userID)
+	}
+	s.mu.Unlock()
+
+	if err := s.repo.UpdateLastSeen(ctx, userID, time.Now()); err != nil {
+		return wentOffline, err
+	}
+
+	return wentOffline, nil
+}
+
+func (s *PresenceService) IsOnline(userID int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connections[userID] > 0
+}
+
+func (s *PresenceService) OnlineUserIDs() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int, 0, len(s.connections))
+	for id := range s.connections {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
+
+func (s *PresenceService) GetOnlineUsers(ctx context.Context) ([]model.PublicUser, error) {
+	users := make([]model.PublicUser, 0)
+
+	for _, id := range s.OnlineUserIDs() {
+		user, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			// deleted while connected
+			if errors.Is(err, repository.ErrNoRows) {
+				continue
+			}
+			return nil, err
+		}
+
+		user.Online = true
+		users = append(users, user.Public())
+	}
+
+	return users, nil
+}
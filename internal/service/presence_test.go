@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+)
+
+func TestPresence(t *testing.T) {
+	repo := fakeUserRepo{
+		users: map[int]model.User{
+			1: {ID: 1, Username: "alice", Email: "alice@example.com"},
+			2: {ID: 2, Username: "bob", Email: "bob@example.com"},
+		},
+		lastSeen: map[int]time.Time{},
+	}
+	s := NewPresence(repo)
+	ctx := context.Background()
+
+	// the steps are applied in order, each one sees the connections before it
+	tests := []struct {
+		name        string
+		connect     bool
+		userID      int
+		wantChanged bool
+		wantOnline  []int
+	}{
+		{"first connection", true, 1, true, []int{1}},
+		{"second tab", true, 1, false, []int{1}},
+		{"another user", true, 2, true, []int{1, 2}},
+		{"one of two tabs closed", false, 1, false, []int{1, 2}},
+		{"last tab closed", false, 1, true, []int{2}},
+		{"reconnected", true, 1, true, []int{1, 2}},
+		{"deleted user", true, 3, true, []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delete(repo.lastSeen, tt.userID)
+
+			var (
+				changed bool
+				err     error
+			)
+			if tt.connect {
+				changed, err = s.Connect(ctx, tt.userID)
+			} else {
+				changed, err = s.Disconnect(ctx, tt.userID)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if changed != tt.wantChanged {
+				t.Errorf("online changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if _, ok := repo.lastSeen[tt.userID]; !ok {
+				t.Error("last seen time isn't stored")
+			}
+			if online := s.OnlineUserIDs(); !reflect.DeepEqual(online, tt.wantOnline) {
+				t.Errorf("OnlineUserIDs() = %v, want %v", online, tt.wantOnline)
+			}
+			if s.IsOnline(tt.userID) != (tt.connect || !tt.wantChanged) {
+				t.Errorf("IsOnline() = %v after the step", s.IsOnline(tt.userID))
+			}
+		})
+	}
+
+	// the deleted user is left out, the rest are public and online
+	users, err := s.GetOnlineUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []model.PublicUser{
+		{ID: 1, Username: "alice", Online: true},
+		{ID: 2, Username: "bob", Online: true},
+	}
+	if !reflect.DeepEqual(users, want) {
+		t.Errorf("GetOnlineUsers() = %+v, want %+v", users, want)
+	}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+type Reaction interface {
+	React(ctx context.Context, userID int, target string, targetID int, emoji string) (model.Reactions, error)
+	Unreact(ctx context.Context, userID int, target string, targetID int, emoji string) (model.Reactions, error)
+	GetReactions(ctx context.Context, userID int, target string, targetID int) (model.Reactions, error)
+	GetReactionUsers(ctx context.Context, userID int, target string, targetID int, emoji string) ([]model.User, error)
+}
+
+type ReactionService struct {
+	repo        repository.Reaction
+	postRepo    repository.Post
+	commentRepo repository.Comment
+	messageRepo repository.Message
+	publisher   Publisher
+}
+
+func NewReaction(
+	repo repository.Reaction,
+	postRepo repository.Post,
+	commentRepo repository.Comment,
+	messageRepo repository.Message,
+	publisher Publisher) *ReactionService {
+	return &ReactionService{
+		repo:        repo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		messageRepo: messageRepo,
+		publisher:   publisher,
+	}
+}
+
+// an emoji with modifiers and joiners, like a family or a flag, takes several code points
+const maxEmojiLength = 32
+
+var (
+	ErrInvalidEmoji          = errors.New("invalid emoji")
+	ErrUnknownReactionTarget = errors.New("unknown reaction target")
+)
+
+// reactionScope is the reacted target and the audience of its reactions:
+// subscribers of the topics for posts and comments, the parties for messages.
+type reactionScope struct {
+	target    repository.ReactionTarget
+	reactions model.Reactions
+	topics    []string
+	userIDs   []int
+}
+
+func (s *ReactionService) React(ctx context.Context, userID int, target string, targetID int, emoji string) (model.Reactions, error) {
+	if !isEmoji(emoji) {
+		return model.Reactions{}, ErrInvalidEmoji
+	}
+
+	scope, err := s.scope(ctx, userID, target, targetID)
+	if err != nil {
+		return model.Reactions{}, err
+	}
+
+	reaction := model.Reaction{UserID: userID, TargetID: targetID, Emoji: emoji}
+
+	if err := s.repo.Add(ctx, scope.target, reaction, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrForeignKeyConstraint) {
+			return model.Reactions{}, notFound(target)
+		}
+		return model.Reactions{}, err
+	}
+
+	return s.publish(ctx, userID, scope)
+}
+
+func (s *ReactionService) Unreact(ctx context.Context, userID int, target string, targetID int, emoji string) (model.Reactions, error) {
+	if !isEmoji(emoji) {
+		return model.Reactions{}, ErrInvalidEmoji
+	}
+
+	scope, err := s.scope(ctx, userID, target, targetID)
+	if err != nil {
+		return model.Reactions{}, err
+	}
+
+	reaction := model.Reaction{UserID: userID, TargetID: targetID, Emoji: emoji}
+
+	if err := s.repo.Remove(ctx, scope.target, reaction); err != nil {
+		return model.Reactions{}, err
+	}
+
+	return s.publish(ctx, userID, scope)
+}
+
+// GetReactions returns the counts of the target, userID = 0 is a guest without own reactions.
+func (s *ReactionService) GetReactions(ctx context.Context, userID int, target string, targetID int) (model.Reactions, error) {
+	scope, err := s.scope(ctx, userID, target, targetID)
+	if err != nil {
+		return model.Reactions{}, err
+	}
+
+	return s.get(ctx, userID, scope)
+}
+
+func (s *ReactionService) GetReactionUsers(ctx context.Context, userID int, target string, targetID int, emoji string) ([]model.User, error) {
+	if !isEmoji(emoji) {
+		return nil, ErrInvalidEmoji
+	}
+
+	scope, err := s.scope(ctx, userID, target, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetUsers(ctx, scope.target, targetID, emoji)
+}
+
+// publish sends the new counts to everyone who sees the target and returns them with the reactions of the user.
+func (s *ReactionService) publish(ctx context.Context, userID int, scope reactionScope) (model.Reactions, error) {
+	reactions, err := s.get(ctx, 0, scope)
+	if err != nil {
+		return model.Reactions{}, err
+	}
+
+	if scope.userIDs != nil {
+		s.publisher.PublishToUsers(scope.userIDs, ReactionsChangedEvent, reactions)
+	} else {
+		s.publisher.Publish(scope.topics, ReactionsChangedEvent, reactions)
+	}
+
+	reactions.UserReactions, err = s.repo.GetUserReactions(ctx, scope.target, reactions.TargetID, userID)
+	if err != nil {
+		return model.Reactions{}, err
+	}
+
+	return reactions, nil
+}
+
+func (s *ReactionService) get(ctx context.Context, userID int, scope reactionScope) (model.Reactions, error) {
+	reactions := scope.reactions
+
+	var err error
+
+	reactions.Reactions, err = s.repo.GetCounts(ctx, scope.target, reactions.TargetID)
+	if err != nil {
+		return model.Reactions{}, err
+	}
+
+	if userID != 0 {
+		reactions.UserReactions, err = s.repo.GetUserReactions(ctx, scope.target, reactions.TargetID, userID)
+		if err != nil {
+			return model.Reactions{}, err
+		}
+	}
+
+	return reactions, nil
+}
+
+func (s *ReactionService) scope(ctx context.Context, userID int, target string, targetID int) (reactionScope, error) {
+	scope := reactionScope{
+		reactions: model.Reactions{Target: target, TargetID: targetID},
+	}
+
+	switch target {
+	case PostTarget:
+		post, err := s.postRepo.GetByID(ctx, targetID, 0)
+		if err != nil {
+			if errors.Is(err, repository.ErrNoRows) {
+				return reactionScope{}, ErrPostNotFound
+			}
+			return reactionScope{}, err
+		}
+
+		scope.target = repository.PostReactions
+		scope.reactions.PostID = post.ID
+		scope.topics = postTopics(post)
+	case CommentTarget:
+		comment, err := s.commentRepo.GetByID(ctx, targetID, 0)
+		if err != nil {
+			if errors.Is(err, repository.ErrNoRows) {
+				return reactionScope{}, ErrCommentNotFound
+			}
+			return reactionScope{}, err
+		}
+
+		scope.target = repository.CommentReactions
+		scope.reactions.PostID = comment.PostID
+		scope.topics = []string{PostTopic(comment.PostID)}
+	case MessageTarget:
+		message, err := s.messageRepo.GetByID(ctx, targetID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNoRows) {
+				return reactionScope{}, ErrMessageNotFound
+			}
+			return reactionScope{}, err
+		}
+
+		// only the parties see the message
+		if message.SenderID != userID && message.RecipientID != userID {
+			return reactionScope{}, ErrMessageNotFound
+		}
+
+		if message.DeletedAt != nil {
+			return reactionScope{}, ErrMessageDeleted
+		}
+
+		scope.target = repository.MessageReactions
+		scope.userIDs = []int{message.SenderID, message.RecipientID}
+	default:
+		return reactionScope{}, ErrUnknownReactionTarget
+	}
+
+	return scope, nil
+}
+
+// notFound is the error of a target deleted while reacting.
+func notFound(target string) error {
+	switch target {
+	case PostTarget:
+		return ErrPostNotFound
+	case CommentTarget:
+		return ErrCommentNotFound
+	default:
+		return ErrMessageNotFound
+	}
+}
+
+// isEmoji accepts exactly one emoji: a pictograph with an optional variation selector and
+// skin tone, a keycap, a flag or a subdivision flag. Such emojis joined by zero width joiners
+// are one emoji too, like 👨‍👩‍👧, while emojis put side by side like 🎉🎉 are not.
+func isEmoji(s string) bool {
+	if s == "" || len(s) > maxEmojiLength {
+		return false
+	}
+
+	runes := []rune(s)
+
+	for i := 0; ; i++ {
+		n := emojiElement(runes[i:])
+		if n == 0 {
+			return false
+		}
+
+		i += n
+		if i == len(runes) {
+			return true
+		}
+
+		// the next emoji must be joined to this one, a joiner must be followed by an emoji
+		if runes[i] != zeroWidthJoiner || i == len(runes)-1 {
+			return false
+		}
+	}
+}
+
+const (
+	zeroWidthJoiner   = 0x200D
+	emojiPresentation = 0xFE0F
+	combiningKeycap   = 0x20E3
+	blackFlag         = 0x1F3F4
+	cancelTag         = 0xE007F
+)
+
+// emojiElement returns the number of runes of the emoji the runes start with, 0 when they don't start with one.
+func emojiElement(runes []rune) int {
+	if len(runes) == 0 {
+		return 0
+	}
+
+	r := runes[0]
+
+	switch {
+	case r == '#' || r == '*' || r >= '0' && r <= '9':
+		n := 1
+		if n < len(runes) && runes[n] == emojiPresentation {
+			n++
+		}
+		if n < len(runes) && runes[n] == combiningKeycap {
+			return n + 1
+		}
+		return 0
+	case isRegionalIndicator(r):
+		// a flag is a pair of letters of the country code
+		if len(runes) > 1 && isRegionalIndicator(runes[1]) {
+			return 2
+		}
+		return 0
+	case r == blackFlag && len(runes) > 1 && isTag(runes[1]):
+		// subdivision flags spell the region in tags ended by the cancel tag
+		n := 1
+		for n < len(runes) && isTag(runes[n]) {
+			n++
+			if runes[n-1] == cancelTag {
+				return n
+			}
+		}
+		return 0
+	case isPictograph(r):
+		n := 1
+		if n < len(runes) && runes[n] == emojiPresentation {
+			n++
+		}
+		if n < len(runes) && isSkinTone(runes[n]) {
+			n++
+		}
+		return n
+	}
+
+	return 0
+}
+
+func isPictograph(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF, // pictographs and emoticons
+		r >= 0x2600 && r <= 0x27BF, // misc symbols and dingbats
+		r >= 0x2300 && r <= 0x23FF, // misc technical
+		r >= 0x2B00 && r <= 0x2BFF, // arrows and stars
+		r >= 0x2190 && r <= 0x21FF, // arrows
+		r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139,
+		r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return !isRegionalIndicator(r) && !isSkinTone(r)
+	}
+
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func isTag(r rune) bool {
+	return r >= 0xE0020 && r <= cancelTag
+}
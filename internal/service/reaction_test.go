@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+func TestIsEmoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		emoji string
+		want  bool
+	}{
+		{"pictograph", "🎉", true},
+		{"emoticon", "😀", true},
+		{"symbol with variation selector", "❤️", true},
+		{"symbol without variation selector", "❤", true},
+		{"skin tone", "👍🏽", true},
+		{"skin tone after variation selector", "✌️🏻", true},
+		{"keycap", "1️⃣", true},
+		{"keycap without variation selector", "#⃣", true},
+		{"flag", "🇰🇿", true},
+		{"subdivision flag", "🏴󠁧󠁢󠁳󠁣󠁴󠁿", true},
+		{"zwj family", "👨‍👩‍👧", true},
+		{"zwj with skin tones", "👩🏽‍💻", true},
+		{"zwj with variation selector", "🏳️‍🌈", true},
+
+		{"empty", "", false},
+		{"two pictographs", "🎉🎉", false},
+		{"pictograph and text", "🎉a", false},
+		{"text", "ok", false},
+		{"digit", "1", false},
+		{"keycap mark alone", "⃣", false},
+		{"single regional indicator", "🇰", false},
+		{"three regional indicators", "🇰🇿🇰", false},
+		{"two flags", "🇰🇿🇰🇿", false},
+		{"leading joiner", "‍🎉", false},
+		{"trailing joiner", "🎉‍", false},
+		{"double joiner", "👨‍‍👩", false},
+		{"subdivision flag without cancel tag", "🏴\U000E0067\U000E0062", false},
+		{"variation selector alone", "️", false},
+		{"too long", "👨‍👩‍👧‍👦‍👨‍👩‍👧‍👦", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEmoji(tt.emoji); got != tt.want {
+				t.Errorf("isEmoji(%q) = %v, want %v", tt.emoji, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeReactions keeps the reactions of every target in the order they were added.
+type fakeReactions struct {
+	repository.Reaction
+	reactions map[repository.ReactionTarget][]model.Reaction
+}
+
+func (f *fakeReactions) Add(ctx context.Context, target repository.ReactionTarget, reaction model.Reaction, creationTime time.Time) error {
+	f.reactions[target] = append(f.reactions[target], reaction)
+	return nil
+}
+
+func (f *fakeReactions) GetCounts(ctx context.Context, target repository.ReactionTarget, targetID int) ([]model.ReactionCount, error) {
+	counts := make([]model.ReactionCount, 0)
+	for _, reaction := range f.reactions[target] {
+		if reaction.TargetID == targetID {
+			counts = append(counts, model.ReactionCount{Emoji: reaction.Emoji, Count: 1})
+		}
+	}
+	return counts, nil
+}
+
+func (f *fakeReactions) GetUserReactions(ctx context.Context, target repository.ReactionTarget, targetID int, userID int) ([]string, error) {
+	var emoji []string
+	for _, reaction := range f.reactions[target] {
+		if reaction.TargetID == targetID && reaction.UserID == userID {
+			emoji = append(emoji, reaction.Emoji)
+		}
+	}
+	return emoji, nil
+}
+
+func TestReact(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		userID      int
+		target      string
+		targetID    int
+		emoji       string
+		wantErr     error
+		wantTopics  []string
+		wantUserIDs []int
+	}{
+		{"post", 3, PostTarget, 1, "🎉", nil, []string{"post:1", "category:1", "category:2"}, nil},
+		{"comment", 3, CommentTarget, 1, "🎉", nil, []string{"post:1"}, nil},
+		{"message by the recipient", 2, MessageTarget, 1, "❤️", nil, nil, []int{1, 2}},
+		{"message by others", 3, MessageTarget, 1, "❤️", ErrMessageNotFound, nil, nil},
+		{"deleted message", 1, MessageTarget, 2, "❤️", ErrMessageDeleted, nil, nil},
+		{"missing post", 3, PostTarget, 2, "🎉", ErrPostNotFound, nil, nil},
+		{"missing comment", 3, CommentTarget, 2, "🎉", ErrCommentNotFound, nil, nil},
+		{"unknown target", 3, "user", 1, "🎉", ErrUnknownReactionTarget, nil, nil},
+		{"not an emoji", 3, PostTarget, 1, "+1", ErrInvalidEmoji, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			s := NewReaction(
+				&fakeReactions{reactions: map[repository.ReactionTarget][]model.Reaction{}},
+				&fakePosts{posts: map[int]model.Post{1: {ID: 1, Categories: []model.Category{{ID: 1}, {ID: 2}}}}},
+				&fakeComments{created: []model.Comment{{PostID: 1}}},
+				&fakeMessages{messages: []model.Message{
+					{SenderID: 1, RecipientID: 2, Message: "hi bob", CreationTime: now},
+					{SenderID: 1, RecipientID: 2, CreationTime: now, DeletedAt: now},
+				}},
+				publisher,
+			)
+
+			reactions, err := s.React(context.Background(), tt.userID, tt.target, tt.targetID, tt.emoji)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("React() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(publisher.events) != 0 {
+					t.Errorf("published %v, want nothing", publisher.events)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(reactions.UserReactions, []string{tt.emoji}) {
+				t.Errorf("user reactions = %v, want %v", reactions.UserReactions, []string{tt.emoji})
+			}
+
+			// the broadcast leaves the reactions of the user out
+			if len(publisher.events) != 1 {
+				t.Fatalf("published %v, want one event", publisher.events)
+			}
+			e := publisher.events[0]
+			if !reflect.DeepEqual(e.topics, tt.wantTopics) || !reflect.DeepEqual(e.userIDs, tt.wantUserIDs) {
+				t.Errorf("published to %v and %v, want %v and %v", e.topics, e.userIDs, tt.wantTopics, tt.wantUserIDs)
+			}
+			if published := e.body.(model.Reactions); published.UserReactions != nil || len(published.Reactions) != 1 {
+				t.Errorf("published %+v, want the counts only", published)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"html"
+	"strings"
+	"unicode"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+type Search interface {
+	Search(ctx context.Context, input SearchInput) ([]model.SearchResult, error)
+	SearchUsers(ctx context.Context, userID int, prefix string) ([]model.User, error)
+}
+
+type SearchService struct {
+	repo     repository.Search
+	presence Presence
+}
+
+func NewSearch(repo repository.Search, presence Presence) *SearchService {
+	return &SearchService{
+		repo:     repo,
+		presence: presence,
+	}
+}
+
+type SearchInput struct {
+	Query      string
+	CategoryID int
+	Author     string
+	Page       int
+}
+
+const (
+	searchLimit      = 10
+	usersSearchLimit = 10
+	maxSearchLength  = 200
+)
+
+var ErrInvalidSearch = errors.New("search query must have a word and be at most 200 characters")
+
+// Search finds posts and comments. Words of the query must all be found, "quoted words"
+// are found as a phrase and a word ending with * matches by prefix.
+func (s *SearchService) Search(ctx context.Context, input SearchInput) ([]model.SearchResult, error) {
+	if input.Page < 1 {
+		return nil, ErrInvalidPage
+	}
+
+	match, err := matchExpression(input.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := repository.SearchFilter{
+		Match:      match,
+		CategoryID: input.CategoryID,
+		Author:     strings.TrimSpace(input.Author),
+	}
+
+	results, err := s.repo.Search(ctx, filter, searchLimit, (input.Page-1)*searchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Title = highlighted(results[i].Title)
+		results[i].Snippet = highlighted(results[i].Snippet)
+	}
+
+	return results, nil
+}
+
+// SearchUsers finds users to start a conversation with by the beginning of their username or name.
+func (s *SearchService) SearchUsers(ctx context.Context, userID int, prefix string) ([]model.User, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" || len(prefix) > maxSearchLength {
+		return nil, ErrInvalidSearch
+	}
+
+	users, err := s.repo.SearchUsers(ctx, escapeLike(prefix)+"%", userID, usersSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		users[i].Online = s.presence.IsOnline(users[i].ID)
+	}
+
+	return users, nil
+}
+
+// matchExpression turns the query typed by a user into an FTS5 expression. Every word
+// and phrase is quoted, so the FTS5 syntax can't be injected and never fails to parse.
+func matchExpression(query string) (string, error) {
+	if len(query) > maxSearchLength {
+		return "", ErrInvalidSearch
+	}
+
+	var terms []string
+
+	for i, part := range strings.Split(query, `"`) {
+		// odd parts are inside quotes, an unclosed quote runs to the end
+		if i%2 == 1 {
+			if hasWord(part) {
+				terms = append(terms, quoteTerm(part))
+			}
+			continue
+		}
+
+		for _, word := range strings.Fields(part) {
+			prefix := strings.HasSuffix(word, "*")
+			word = strings.TrimRight(word, "*")
+
+			if !hasWord(word) {
+				continue
+			}
+
+			if prefix {
+				terms = append(terms, quoteTerm(word)+"*")
+			} else {
+				terms = append(terms, quoteTerm(word))
+			}
+		}
+	}
+
+	if len(terms) == 0 {
+		return "", ErrInvalidSearch
+	}
+
+	return strings.Join(terms, " "), nil
+}
+
+func quoteTerm(term string) string {
+	return `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+}
+
+// hasWord tells if the tokenizer finds anything to search in s, punctuation is skipped.
+func hasWord(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+// highlighted escapes the text and marks the matched terms.
+func highlighted(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, repository.MatchStart, "<mark>")
+	return strings.ReplaceAll(s, repository.MatchEnd, "</mark>")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMatchExpression(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr error
+	}{
+		{"word", "golang", `"golang"`, nil},
+		{"words", "  go   channels ", `"go" "channels"`, nil},
+		{"prefix", "chan*", `"chan"*`, nil},
+		{"several stars", "chan**", `"chan"*`, nil},
+		{"phrase", `"real time" forum`, `"real time" "forum"`, nil},
+		{"unclosed phrase", `forum "real time`, `"forum" "real time"`, nil},
+		{"star inside a phrase", `"chan*"`, `"chan*"`, nil},
+		{"operators are words", "go OR NOT rust", `"go" "OR" "NOT" "rust"`, nil},
+		{"column filter", "title:go", `"title:go"`, nil},
+		{"syntax characters", "(go) -rust ^x", `"(go)" "-rust" "^x"`, nil},
+		{"punctuation is skipped", "go ... !", `"go"`, nil},
+		{"empty phrase is skipped", `go ""`, `"go"`, nil},
+		{"non latin", "привет мир", `"привет" "мир"`, nil},
+		{"max length", strings.Repeat("a", maxSearchLength), `"` + strings.Repeat("a", maxSearchLength) + `"`, nil},
+
+		{"empty", "", "", ErrInvalidSearch},
+		{"spaces", "   ", "", ErrInvalidSearch},
+		{"only punctuation", `*** "!?" -`, "", ErrInvalidSearch},
+		{"too long", strings.Repeat("a", maxSearchLength+1), "", ErrInvalidSearch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchExpression(tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("matchExpression(%q) error = %v, want %v", tt.query, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("matchExpression(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlighted(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"plain", "no match", "no match"},
+		{"match", "a \x02go\x03 post", "a <mark>go</mark> post"},
+		{"html is escaped", "<b>\x02go\x03</b>", "&lt;b&gt;<mark>go</mark>&lt;/b&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlighted(tt.s); got != tt.want {
+				t.Errorf("highlighted(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,20 +3,58 @@ package service
 import (
 	"real-time-forum/internal/config"
 	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/auth"
 	hash "real-time-forum/pkg/hasher"
+	"real-time-forum/pkg/images"
+	"real-time-forum/pkg/mailer"
 )
 
 type Service struct {
-	User User
+	User         User
+	Post         Post
+	Comment      Comment
+	Vote         Vote
+	Chat         Chat
+	Category     Category
+	Presence     Presence
+	Conversation Conversation
+	Reaction     Reaction
+	Image        Image
+	Search       Search
 }
 
 func NewService(
 	repo *repository.Repository,
-	h *hash.HasherService,
+	h hash.Hasher,
+	tokenManager auth.TokenManager,
+	publisher Publisher,
+	connections Connections,
+	storage images.Storage,
+	mailer mailer.Mailer,
 	cfg *config.Config) *Service {
-	userService := NewUser(repo.User, h, cfg)
+	userService := NewUser(repo.User, h, tokenManager, mailer, connections, cfg)
+	postService := NewPost(repo.Post, repo.Category, repo.Image, storage, publisher)
+	commentService := NewComment(repo.Comment, publisher, cfg)
+	voteService := NewVote(repo.Vote, repo.Post, repo.Comment, publisher)
+	chatService := NewChat(repo.Message)
+	categoryService := NewCategory(repo.Category, repo.User)
+	presenceService := NewPresence(repo.User)
+	conversationService := NewConversation(repo.Conversation, publisher)
+	imageService := NewImage(repo.Image, repo.Post, repo.Comment, storage)
+	reactionService := NewReaction(repo.Reaction, repo.Post, repo.Comment, repo.Message, publisher)
+	searchService := NewSearch(repo.Search, presenceService)
 
 	return &Service{
-		User: userService,
+		User:         userService,
+		Post:         postService,
+		Comment:      commentService,
+		Vote:         voteService,
+		Chat:         chatService,
+		Category:     categoryService,
+		Presence:     presenceService,
+		Conversation: conversationService,
+		Reaction:     reactionService,
+		Image:        imageService,
+		Search:       searchService,
 	}
 }
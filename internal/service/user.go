@@ -7,7 +7,9 @@ import (
 	"real-time-forum/internal/config"
 	"real-time-forum/internal/model"
 	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/auth"
 	"real-time-forum/pkg/hasher"
+	"real-time-forum/pkg/mailer"
 	"strings"
 	"time"
 
@@ -16,25 +18,48 @@ import (
 
 type User interface {
 	SignUp(ctx context.Context, input UserSignUpInput) error
-	SignIn(ctx context.Context, input UserSignInInput) (string, error)
+	SignIn(ctx context.Context, input UserSignInInput) (Tokens, error)
 	GetByID(ctx context.Context, userID int) (model.User, error)
-	GetUsersPosts(ctx context.Context, userID int) ([]model.Post, error)
-	GetUsersVotedPosts(ctx context.Context, userID int) ([]model.Post, error)
-	SetToken(ctx context.Context, userID int) (string, error)
-	DeleteToken(ctx context.Context, userID int) error
+	GetUsersPosts(ctx context.Context, userID int, token string) ([]model.Post, Cursors, error)
+	GetUsersVotedPosts(ctx context.Context, userID int, token string) ([]model.Post, Cursors, error)
+	SetToken(ctx context.Context, userID int) (Tokens, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (Tokens, error)
+	Identify(ctx context.Context, token string) (Identity, error)
+	IdentifyConnection(ctx context.Context, token string) (Identity, error)
+	SignOut(ctx context.Context, userID int, refreshToken string) error
+	GetAccount(ctx context.Context, userID int) (model.User, error)
+	UpdateProfile(ctx context.Context, userID int, input ProfileInput) (model.User, error)
+	ChangeUsername(ctx context.Context, userID int, username string) (model.User, error)
+	ChangeEmail(ctx context.Context, userID int, input EmailInput) (model.User, error)
+	VerifyEmail(ctx context.Context, token string) (string, error)
+	ChangePassword(ctx context.Context, userID int, sessionID string, input PasswordInput) (Tokens, error)
+}
+
+// Connections ends the live connections opened in sessions the user was signed out of.
+type Connections interface {
+	// CloseSession closes the connections of the user opened in the session.
+	CloseSession(userID int, sessionID string)
+	// CloseOtherSessions closes the connections of the user opened in any session but the one given.
+	CloseOtherSessions(userID int, sessionID string)
 }
 
 type UserService struct {
-	repo   repository.User
-	hasher *hasher.HasherService
-	cfg    *config.Config
+	repo         repository.User
+	hasher       hasher.Hasher
+	tokenManager auth.TokenManager
+	mailer       mailer.Mailer
+	connections  Connections
+	cfg          *config.Config
 }
 
-func NewUser(repo repository.User, hasher *hasher.HasherService, cfg *config.Config) *UserService {
+func NewUser(repo repository.User, hasher hasher.Hasher, tokenManager auth.TokenManager, mailer mailer.Mailer, connections Connections, cfg *config.Config) *UserService {
 	return &UserService{
-		repo:   repo,
-		hasher: hasher,
-		cfg:    cfg,
+		repo:         repo,
+		hasher:       hasher,
+		tokenManager: tokenManager,
+		mailer:       mailer,
+		connections:  connections,
+		cfg:          cfg,
 	}
 }
 
@@ -50,7 +75,7 @@ type UserSignUpInput struct {
 
 const (
 	femaleAva = "female_default.jpg"
-	maleAva   = "female_default.jpg"
+	maleAva   = "male_default.jpg"
 )
 
 func (s *UserService) SignUp(ctx context.Context, input UserSignUpInput) error {
@@ -58,28 +83,37 @@ func (s *UserService) SignUp(ctx context.Context, input UserSignUpInput) error {
 
 	switch input.Gender {
 	case "Male":
-		avatar = s.cfg.Sqlite.ImagesPath + maleAva
+		avatar = maleAva
 	case "Female":
-		avatar = s.cfg.Sqlite.ImagesPath + femaleAva
+		avatar = femaleAva
 	default:
-		return fmt.Errorf("unknown gender")
+		return ErrUnknownGender
 	}
 
-	input.Password = s.hasher.HashPassword(input.Password)
+	password, err := s.hasher.HashPassword(input.Password)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
 
 	user := model.User{
-		Username:     input.Username,
-		FirstName:    input.FirstName,
-		LastName:     input.LastName,
+		Username:     strings.TrimSpace(input.Username),
+		FirstName:    strings.TrimSpace(input.FirstName),
+		LastName:     strings.TrimSpace(input.LastName),
 		Age:          input.Age,
 		Gender:       input.Gender,
-		Email:        strings.ToLower(input.Email),
-		Password:     input.Password,
+		Email:        strings.ToLower(strings.TrimSpace(input.Email)),
+		Password:     password,
 		CreationTime: time.Now(),
 		Avatar:       avatar,
 	}
 
 	if err := s.repo.Create(ctx, user); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrEmailExists):
+			return ErrEmailTaken
+		case errors.Is(err, repository.ErrUserExists):
+			return ErrUsernameTaken
+		}
 		return err
 	}
 
@@ -91,12 +125,40 @@ type UserSignInInput struct {
 	Password        string
 }
 
-func (s *UserService) SignIn(ctx context.Context, input UserSignInInput) (string, error) {
-	input.Password = s.hasher.HashPassword(input.Password)
+var ErrInvalidCredentials = errors.New("invalid username, email or password")
+
+func (s *UserService) SignIn(ctx context.Context, input UserSignInInput) (Tokens, error) {
+	user, err := s.repo.GetByCredentials(ctx, input.UsernameOrEmail)
+	if err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			return Tokens{}, ErrInvalidCredentials
+		}
+		return Tokens{}, fmt.Errorf("get by credentials: %w", err)
+	}
 
-	user, err := s.repo.GetByCredentials(ctx, input.UsernameOrEmail, input.Password)
+	ok, err := s.hasher.Verify(input.Password, user.Password)
 	if err != nil {
-		return "", fmt.Errorf("get by credentials: %w", err)
+		return Tokens{}, fmt.Errorf("verify password: %w", err)
+	}
+
+	if !ok {
+		return Tokens{}, ErrInvalidCredentials
+	}
+
+	// hashes made by an outdated algorithm are replaced while the plain password is at hand
+	if s.hasher.NeedsRehash(user.Password) {
+		password, err := s.hasher.HashPassword(input.Password)
+		if err != nil {
+			return Tokens{}, fmt.Errorf("hash password: %w", err)
+		}
+
+		if err := s.repo.UpdatePassword(ctx, user.ID, password); err != nil {
+			return Tokens{}, err
+		}
+	}
+
+	if err := s.repo.DeleteExpiredSessions(ctx, user.ID); err != nil {
+		return Tokens{}, err
 	}
 
 	return s.SetToken(ctx, user.ID)
@@ -116,50 +178,176 @@ func (s *UserService) GetByID(ctx context.Context, userID int) (model.User, erro
 	return user, nil
 }
 
-func (s *UserService) GetUsersPosts(ctx context.Context, userID int) ([]model.Post, error) {
-	posts, err := s.repo.GetUsersPosts(ctx, userID)
+func (s *UserService) GetUsersPosts(ctx context.Context, userID int, token string) ([]model.Post, Cursors, error) {
+	return s.getPostsPage(ctx, userID, token, s.repo.GetUsersPosts)
+}
+
+func (s *UserService) GetUsersVotedPosts(ctx context.Context, userID int, token string) ([]model.Post, Cursors, error) {
+	return s.getPostsPage(ctx, userID, token, s.repo.GetUsersVotedPosts)
+}
+
+// getPostsPage reads a page of posts of the user, newest first.
+func (s *UserService) getPostsPage(
+	ctx context.Context,
+	userID int,
+	token string,
+	get func(ctx context.Context, userID int, cursor repository.Cursor, limit int) ([]model.Post, error)) ([]model.Post, Cursors, error) {
+	c, err := decodeCursor(token)
+	if err != nil {
+		return nil, Cursors{}, err
+	}
+
+	posts, err := get(ctx, userID, c.position(), postsLimit+1)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoRows) {
-			return nil, ErrUserDoesNotExists
+			return nil, Cursors{}, ErrUserDoesNotExists
 		}
-		return nil, err
+		return nil, Cursors{}, err
 	}
-	return posts, nil
+
+	posts, cursors := paginate(posts, postsLimit, c, func(post model.Post) (float64, int) {
+		return 0, post.ID
+	})
+
+	return posts, cursors, nil
 }
 
-func (s *UserService) GetUsersVotedPosts(ctx context.Context, userID int) ([]model.Post, error) {
-	likedPosts, err := s.repo.GetUsersVotedPosts(ctx, userID)
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token has expired")
+)
+
+// SetToken starts a new session, the refresh token begins a new token family.
+func (s *UserService) SetToken(ctx context.Context, userID int) (Tokens, error) {
+	family, err := uuid.NewV4()
+	if err != nil {
+		return Tokens{}, fmt.Errorf("generate token family: %w", err)
+	}
+
+	return s.setSession(ctx, userID, family.String())
+}
+
+// RefreshTokens rotates the refresh token. A refresh token can be used only once,
+// presenting an already used one means it has leaked, so the whole family is revoked.
+func (s *UserService) RefreshTokens(ctx context.Context, refreshToken string) (Tokens, error) {
+	session, err := s.repo.GetSession(ctx, refreshToken)
 	if err != nil {
 		if errors.Is(err, repository.ErrNoRows) {
-			return nil, ErrUserDoesNotExists
+			return Tokens{}, ErrInvalidToken
 		}
-		return nil, err
+		return Tokens{}, err
 	}
-	return likedPosts, nil
+
+	if time.Now().After(session.ExpiresAt) {
+		return Tokens{}, ErrInvalidToken
+	}
+
+	if err := s.repo.UseSession(ctx, refreshToken); err != nil {
+		if errors.Is(err, repository.ErrNoRows) {
+			if err := s.repo.DeleteSessionFamily(ctx, session.Family); err != nil {
+				return Tokens{}, err
+			}
+			return Tokens{}, ErrInvalidToken
+		}
+		return Tokens{}, err
+	}
+
+	return s.setSession(ctx, session.UserID, session.Family)
 }
 
-func (s *UserService) SetToken(ctx context.Context, userID int) (string, error) {
-	tokenUUID, err := uuid.NewV4()
+func (s *UserService) setSession(ctx context.Context, userID int, family string) (Tokens, error) {
+	var (
+		tokens Tokens
+		err    error
+	)
+
+	tokens.AccessToken, err = s.tokenManager.NewJWT(userID, family, time.Duration(s.cfg.Auth.AccessTokenTTL)*time.Minute)
+	if err != nil {
+		return Tokens{}, fmt.Errorf("generate access token: %w", err)
+	}
+
+	tokens.RefreshToken, err = s.tokenManager.NewRefreshToken()
 	if err != nil {
-		return "", fmt.Errorf("generate token: %w", err)
+		return Tokens{}, fmt.Errorf("generate refresh token: %w", err)
 	}
 
 	session := model.Session{
 		UserID:    userID,
-		Token:     tokenUUID.String(),
-		ExpiresAt: time.Now().Add(12 * time.Hour),
+		Token:     tokens.RefreshToken,
+		Family:    family,
+		ExpiresAt: time.Now().Add(time.Duration(s.cfg.Auth.RefreshTokenTTL) * time.Hour),
 	}
 
-	return session.Token, s.repo.SetSession(ctx, session)
+	if err := s.repo.SetSession(ctx, session); err != nil {
+		return Tokens{}, err
+	}
+
+	return tokens, nil
 }
 
-func (s *UserService) DeleteToken(ctx context.Context, userID int) error {
-	if err := s.repo.DeleteSession(ctx, userID); err != nil {
+// Identity is the user an access token was issued to and the session it was issued in.
+type Identity struct {
+	UserID    int
+	SessionID string
+}
+
+func (s *UserService) Identify(ctx context.Context, token string) (Identity, error) {
+	claims, err := s.tokenManager.Parse(token)
+	if err != nil {
+		if errors.Is(err, auth.ErrTokenExpired) {
+			return Identity{}, ErrTokenExpired
+		}
+		return Identity{}, ErrInvalidToken
+	}
+
+	return Identity{UserID: claims.UserID, SessionID: claims.SessionID}, nil
+}
+
+// IdentifyConnection is Identify for connections that outlive a request. Unlike a request the
+// connection would keep the user signed in after the session ended, so the token of one is refused.
+func (s *UserService) IdentifyConnection(ctx context.Context, token string) (Identity, error) {
+	identity, err := s.Identify(ctx, token)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	live, err := s.repo.HasLiveSession(ctx, identity.SessionID)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if !live {
+		return Identity{}, ErrInvalidToken
+	}
+
+	return identity, nil
+}
+
+// SignOut ends the session the refresh token belongs to and closes the connections opened in it,
+// the user stays signed in on other devices. Access tokens already issued in it stay valid until they expire.
+func (s *UserService) SignOut(ctx context.Context, userID int, refreshToken string) error {
+	session, err := s.repo.GetSession(ctx, refreshToken)
+	if err != nil {
 		if errors.Is(err, repository.ErrNoRows) {
-			return ErrUserDoesNotExists
+			return ErrInvalidToken
 		}
 		return err
 	}
 
+	if session.UserID != userID {
+		return ErrInvalidToken
+	}
+
+	if err := s.repo.DeleteSessionFamily(ctx, session.Family); err != nil {
+		return err
+	}
+
+	s.connections.CloseSession(userID, session.Family)
+
 	return nil
 }
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+	"real-time-forum/pkg/auth"
+)
+
+// fakeSessions has the sessions by refresh token and records the families signed out.
+type fakeSessions struct {
+	fakeUserRepo
+	sessions  map[string]model.Session
+	signedOut []string
+}
+
+func (f *fakeSessions) GetSession(ctx context.Context, token string) (model.Session, error) {
+	session, ok := f.sessions[token]
+	if !ok {
+		return model.Session{}, repository.ErrNoRows
+	}
+	return session, nil
+}
+
+func (f *fakeSessions) DeleteSessionFamily(ctx context.Context, family string) error {
+	f.signedOut = append(f.signedOut, family)
+	return nil
+}
+
+// HasLiveSession reports a session as live while a session of its family is stored.
+func (f *fakeSessions) HasLiveSession(ctx context.Context, family string) (bool, error) {
+	for _, session := range f.sessions {
+		if session.Family == family {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeSignUps records the users created.
+type fakeSignUps struct {
+	fakeUserRepo
+	created []model.User
+}
+
+func (f *fakeSignUps) Create(ctx context.Context, user model.User) error {
+	f.created = append(f.created, user)
+	return nil
+}
+
+// fakeConnections records the connections closed as "<user> <session>" or "<user> other than <session>".
+type fakeConnections struct {
+	closed []string
+}
+
+func (f *fakeConnections) CloseSession(userID int, sessionID string) {
+	f.closed = append(f.closed, fmt.Sprintf("%d %s", userID, sessionID))
+}
+
+func (f *fakeConnections) CloseOtherSessions(userID int, sessionID string) {
+	f.closed = append(f.closed, fmt.Sprintf("%d other than %s", userID, sessionID))
+}
+
+func TestSignUpAvatar(t *testing.T) {
+	tests := []struct {
+		gender     string
+		wantAvatar string
+		wantErr    error
+	}{
+		{"Male", "male_default.jpg", nil},
+		{"Female", "female_default.jpg", nil},
+		{"Other", "", ErrUnknownGender},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.gender, func(t *testing.T) {
+			repo := &fakeSignUps{}
+			s := NewUser(repo, fakeHasher{}, nil, nil, nil, nil)
+
+			err := s.SignUp(context.Background(), UserSignUpInput{
+				Username: "alice",
+				Gender:   tt.gender,
+				Email:    "alice@example.com",
+				Password: "secret-password",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SignUp() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				if len(repo.created) != 0 {
+					t.Errorf("created %+v, want nothing", repo.created)
+				}
+				return
+			}
+
+			if len(repo.created) != 1 || repo.created[0].Avatar != tt.wantAvatar {
+				t.Errorf("created %+v, want a user with the avatar %s", repo.created, tt.wantAvatar)
+			}
+		})
+	}
+}
+
+func TestSignOut(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        int
+		token         string
+		wantErr       error
+		wantSignedOut []string
+		wantClosed    []string
+	}{
+		{"own session", 1, "laptop", nil, []string{"laptop family"}, []string{"1 laptop family"}},
+		{"session of another user", 2, "laptop", ErrInvalidToken, nil, nil},
+		{"unknown token", 1, "forged", ErrInvalidToken, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSessions{sessions: map[string]model.Session{
+				"laptop": {UserID: 1, Token: "laptop", Family: "laptop family"},
+				"phone":  {UserID: 1, Token: "phone", Family: "phone family"},
+			}}
+			connections := &fakeConnections{}
+			s := NewUser(repo, nil, nil, nil, connections, nil)
+
+			if err := s.SignOut(context.Background(), tt.userID, tt.token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SignOut() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// the other devices stay signed in
+			if !reflect.DeepEqual(repo.signedOut, tt.wantSignedOut) {
+				t.Errorf("signed out %v, want %v", repo.signedOut, tt.wantSignedOut)
+			}
+			if !reflect.DeepEqual(connections.closed, tt.wantClosed) {
+				t.Errorf("closed %v, want %v", connections.closed, tt.wantClosed)
+			}
+		})
+	}
+}
+
+func TestIdentifyConnection(t *testing.T) {
+	tokenManager, err := auth.NewManager("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := func(family string, ttl time.Duration) string {
+		t.Helper()
+		token, err := tokenManager.NewJWT(1, family, ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    Identity
+		wantErr error
+	}{
+		{"live session", token("laptop family", time.Minute), Identity{UserID: 1, SessionID: "laptop family"}, nil},
+		{"ended session", token("phone family", time.Minute), Identity{}, ErrInvalidToken},
+		{"expired token", token("laptop family", -time.Minute), Identity{}, ErrTokenExpired},
+		{"invalid token", "forged", Identity{}, ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSessions{sessions: map[string]model.Session{
+				"laptop": {UserID: 1, Token: "laptop", Family: "laptop family"},
+			}}
+			s := NewUser(repo, nil, tokenManager, nil, nil, nil)
+
+			got, err := s.IdentifyConnection(context.Background(), tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("IdentifyConnection() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("IdentifyConnection() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
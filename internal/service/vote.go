@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+type Vote interface {
+	VotePost(ctx context.Context, userID int, postID int, likeType int) (model.VoteResult, error)
+	VoteComment(ctx context.Context, userID int, commentID int, likeType int) (model.VoteResult, error)
+}
+
+type VoteService struct {
+	repo        repository.Vote
+	postRepo    repository.Post
+	commentRepo repository.Comment
+	publisher   Publisher
+}
+
+func NewVote(repo repository.Vote, postRepo repository.Post, commentRepo repository.Comment, publisher Publisher) *VoteService {
+	return &VoteService{
+		repo:        repo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		publisher:   publisher,
+	}
+}
+
+// like types sent by the client, voting with the current like type again retracts the vote
+const (
+	Retract = 0
+	Like    = 1
+	Dislike = 2
+)
+
+var ErrInvalidLikeType = errors.New("invalid like type")
+
+func (s *VoteService) VotePost(ctx context.Context, userID int, postID int, likeType int) (model.VoteResult, error) {
+	result, err := s.vote(ctx, repository.PostVotes, userID, postID, likeType)
+	if errors.Is(err, repository.ErrNoRows) || errors.Is(err, repository.ErrForeignKeyConstraint) {
+		return model.VoteResult{}, ErrPostNotFound
+	}
+	if err != nil {
+		return model.VoteResult{}, err
+	}
+
+	// the vote is already committed, the feed just misses the change if the post is gone
+	if post, err := s.postRepo.GetByID(ctx, postID, 0); err == nil {
+		s.publisher.Publish(postTopics(post), ScoreChangedEvent, model.ScoreChange{
+			Target:   PostTarget,
+			TargetID: postID,
+			PostID:   postID,
+			Rating:   result.Rating,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *VoteService) VoteComment(ctx context.Context, userID int, commentID int, likeType int) (model.VoteResult, error) {
+	result, err := s.vote(ctx, repository.CommentVotes, userID, commentID, likeType)
+	if errors.Is(err, repository.ErrNoRows) || errors.Is(err, repository.ErrForeignKeyConstraint) {
+		return model.VoteResult{}, ErrCommentNotFound
+	}
+	if err != nil {
+		return model.VoteResult{}, err
+	}
+
+	if comment, err := s.commentRepo.GetByID(ctx, commentID, 0); err == nil {
+		s.publisher.Publish([]string{PostTopic(comment.PostID)}, ScoreChangedEvent, model.ScoreChange{
+			Target:   CommentTarget,
+			TargetID: commentID,
+			PostID:   comment.PostID,
+			Rating:   result.Rating,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *VoteService) vote(ctx context.Context, target repository.VoteTarget, userID int, targetID int, likeType int) (model.VoteResult, error) {
+	vote := model.Vote{
+		UserID:   userID,
+		TargetID: targetID,
+	}
+
+	switch likeType {
+	case Retract:
+		vote.Vote = 0
+	case Like:
+		vote.Vote = 1
+	case Dislike:
+		vote.Vote = -1
+	default:
+		return model.VoteResult{}, ErrInvalidLikeType
+	}
+
+	return s.repo.Vote(ctx, target, vote)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"real-time-forum/internal/model"
+	"real-time-forum/internal/repository"
+)
+
+// fakeVotes knows the post 1 and the comment 1, it records the votes given to it.
+type fakeVotes struct {
+	votes []model.Vote
+}
+
+func (f *fakeVotes) Vote(ctx context.Context, target repository.VoteTarget, vote model.Vote) (model.VoteResult, error) {
+	if vote.TargetID != 1 {
+		return model.VoteResult{}, repository.ErrForeignKeyConstraint
+	}
+
+	f.votes = append(f.votes, vote)
+	return model.VoteResult{Rating: vote.Vote}, nil
+}
+
+func TestVote(t *testing.T) {
+	tests := []struct {
+		name     string
+		targetID int
+		likeType int
+		wantVote int
+		wantErr  map[string]error
+	}{
+		{"like", 1, Like, 1, nil},
+		{"dislike", 1, Dislike, -1, nil},
+		{"retract", 1, Retract, 0, nil},
+		{"unknown like type", 1, 3, 0, map[string]error{"post": ErrInvalidLikeType, "comment": ErrInvalidLikeType}},
+		{"missing target", 2, Like, 0, map[string]error{"post": ErrPostNotFound, "comment": ErrCommentNotFound}},
+	}
+
+	for _, tt := range tests {
+		for _, target := range []string{"post", "comment"} {
+			t.Run(target+"/"+tt.name, func(t *testing.T) {
+				repo := &fakeVotes{}
+				s := NewVote(repo, &fakePosts{}, &fakeComments{}, &fakePublisher{})
+
+				vote := s.VotePost
+				if target == "comment" {
+					vote = s.VoteComment
+				}
+
+				_, err := vote(context.Background(), 1, tt.targetID, tt.likeType)
+				if !errors.Is(err, tt.wantErr[target]) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr[target])
+				}
+
+				if tt.wantErr == nil && (len(repo.votes) != 1 || repo.votes[0].Vote != tt.wantVote) {
+					t.Errorf("stored votes = %v, want the vote %d", repo.votes, tt.wantVote)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken   = errors.New("invalid token")
+	ErrTokenExpired   = errors.New("token has expired")
+	ErrWeakSigningKey = errors.New("signing key must be at least 32 bytes")
+)
+
+// MinSigningKeyLength is the size of the HS256 hash, a shorter key makes the signature easier to brute force.
+const MinSigningKeyLength = 32
+
+type TokenManager interface {
+	NewJWT(userID int, sessionID string, ttl time.Duration) (string, error)
+	Parse(accessToken string) (Claims, error)
+	NewRefreshToken() (string, error)
+}
+
+type Manager struct {
+	signingKey []byte
+}
+
+func NewManager(signingKey string) (*Manager, error) {
+	if len(signingKey) < MinSigningKeyLength {
+		return nil, ErrWeakSigningKey
+	}
+
+	return &Manager{signingKey: []byte(signingKey)}, nil
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// Claims tell who a token was issued to and in which session.
+type Claims struct {
+	UserID    int
+	SessionID string
+}
+
+type claims struct {
+	Subject   string `json:"sub"`
+	Session   string `json:"sid"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// NewJWT returns HS256 signed token with the user id as subject, the session id lets
+// connections opened with the token be found when the session ends.
+func (m *Manager) NewJWT(userID int, sessionID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	h, err := json.Marshal(header{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", fmt.Errorf("marshal header: %w", err)
+	}
+
+	c, err := json.Marshal(claims{
+		Subject:   strconv.Itoa(userID),
+		Session:   sessionID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("marshal claims: %w", err)
+	}
+
+	unsigned := encoding.EncodeToString(h) + "." + encoding.EncodeToString(c)
+
+	return unsigned + "." + encoding.EncodeToString(m.sign(unsigned)), nil
+}
+
+// Parse verifies the token and returns the claims it was issued with.
+func (m *Manager) Parse(accessToken string) (Claims, error) {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if !hmac.Equal(signature, m.sign(parts[0]+"."+parts[1])) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil || h.Alg != "HS256" {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= c.ExpiresAt {
+		return Claims{}, ErrTokenExpired
+	}
+
+	userID, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return Claims{UserID: userID, SessionID: c.Session}, nil
+}
+
+// NewRefreshToken returns random opaque token.
+func (m *Manager) NewRefreshToken() (string, error) {
+	b := make([]byte, 32)
+
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("read random bytes: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+func (m *Manager) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, m.signingKey)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := encoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef"
+
+func newTestManager(t *testing.T, key string) *Manager {
+	t.Helper()
+
+	m, err := NewManager(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+// forge signs any header and claims with the key of the manager.
+func forge(m *Manager, header string, claims string) string {
+	unsigned := encoding.EncodeToString([]byte(header)) + "." + encoding.EncodeToString([]byte(claims))
+	return unsigned + "." + encoding.EncodeToString(m.sign(unsigned))
+}
+
+func TestNewManager(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{"empty", "", ErrWeakSigningKey},
+		{"31 bytes", testKey[:31], ErrWeakSigningKey},
+		{"32 bytes", testKey, nil},
+		{"longer", testKey + testKey, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewManager(tt.key); !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewManager() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	m := newTestManager(t, testKey)
+	other := newTestManager(t, strings.ToUpper(testKey))
+
+	valid, err := m.NewJWT(42, "session", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expired, err := m.NewJWT(42, "session", -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := strings.Split(valid, ".")
+	future := time.Now().Add(time.Hour).Unix()
+	elevated, _ := json.Marshal(claims{Subject: "1", Session: "session", ExpiresAt: future})
+
+	tests := []struct {
+		name    string
+		token   string
+		want    Claims
+		wantErr error
+	}{
+		{"valid", valid, Claims{UserID: 42, SessionID: "session"}, nil},
+		{"expired", expired, Claims{}, ErrTokenExpired},
+		{"empty", "", Claims{}, ErrInvalidToken},
+		{"two parts", parts[0] + "." + parts[1], Claims{}, ErrInvalidToken},
+		{"no signature", parts[0] + "." + parts[1] + ".", Claims{}, ErrInvalidToken},
+		{"signature not base64", parts[0] + "." + parts[1] + ".!!", Claims{}, ErrInvalidToken},
+		{"claims changed", parts[0] + "." + encoding.EncodeToString(elevated) + "." + parts[2], Claims{}, ErrInvalidToken},
+		{"signed with another key", forge(other, `{"alg":"HS256","typ":"JWT"}`, string(elevated)), Claims{}, ErrInvalidToken},
+		{"alg none", forge(m, `{"alg":"none","typ":"JWT"}`, string(elevated)), Claims{}, ErrInvalidToken},
+		{"claims not json", forge(m, `{"alg":"HS256","typ":"JWT"}`, "user 1"), Claims{}, ErrInvalidToken},
+		{"subject not a number", forge(m, `{"alg":"HS256","typ":"JWT"}`, `{"sub":"admin","exp":9999999999}`), Claims{}, ErrInvalidToken},
+		{"no expiry", forge(m, `{"alg":"HS256","typ":"JWT"}`, `{"sub":"1"}`), Claims{}, ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Parse(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRefreshToken(t *testing.T) {
+	m := newTestManager(t, testKey)
+
+	first, err := m.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := m.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first) != 64 {
+		t.Errorf("NewRefreshToken() = %q, want 64 hex characters", first)
+	}
+
+	if first == second {
+		t.Error("NewRefreshToken() gave the same token twice")
+	}
+}
@@ -1,29 +1,147 @@
 package hasher
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Hasher interface {
-	HashPassword(password string) string
+	HashPassword(password string) (string, error)
+	Verify(password, hash string) (bool, error)
+	NeedsRehash(hash string) bool
 }
 
+// argon2id parameters recommended by RFC 9106
+const (
+	argonTime    uint32 = 1
+	argonMemory  uint32 = 64 * 1024
+	argonThreads uint8  = 4
+	argonKeyLen  uint32 = 32
+	saltLen             = 16
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// HasherService hashes passwords with argon2id, the hash string holds the parameters and the per-user salt:
+//
+//	$argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+//
+// bcrypt hashes and legacy salted sha256 hashes are still verified, so they can be upgraded on sign in.
 type HasherService struct {
-	salt string
+	legacySalt string
 }
 
-func NewHasher(salt string) (*HasherService, error) {
-	if salt == "" {
+func NewHasher(legacySalt string) (*HasherService, error) {
+	if legacySalt == "" {
 		return nil, errors.New("password salt is empty")
 	}
 
-	return &HasherService{salt: salt}, nil
+	return &HasherService{legacySalt: legacySalt}, nil
+}
+
+func (h *HasherService) HashPassword(password string) (string, error) {
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		argonMemory,
+		argonTime,
+		argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
-func (h *HasherService) HashPassword(password string) string {
+func (h *HasherService) Verify(password, hash string) (bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return verifyArgon2id(password, hash)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case h.isLegacy(hash):
+		return subtle.ConstantTimeCompare([]byte(h.legacyHash(password)), []byte(hash)) == 1, nil
+	default:
+		return false, ErrUnknownHashFormat
+	}
+}
+
+// NeedsRehash reports whether the hash was made by another algorithm or with outdated parameters.
+func (h *HasherService) NeedsRehash(hash string) bool {
+	memory, time, threads, _, _, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return memory != argonMemory || time != argonTime || threads != argonThreads
+}
+
+func verifyArgon2id(password, hash string) (bool, error) {
+	memory, time, threads, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+}
+
+func decodeArgon2id(hash string) (memory uint32, time uint32, threads uint8, salt []byte, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return 0, 0, 0, nil, nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return 0, 0, 0, nil, nil, ErrUnknownHashFormat
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return 0, 0, 0, nil, nil, ErrUnknownHashFormat
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return 0, 0, 0, nil, nil, ErrUnknownHashFormat
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return 0, 0, 0, nil, nil, ErrUnknownHashFormat
+	}
+
+	return memory, time, threads, salt, key, nil
+}
+
+// legacyHash is the former salted sha256 hash: hex of salt followed by the digest.
+func (h *HasherService) legacyHash(password string) string {
 	hash := sha256.New()
 	hash.Write([]byte(password))
-	return fmt.Sprintf("%x", hash.Sum([]byte(h.salt)))
+	return fmt.Sprintf("%x", hash.Sum([]byte(h.legacySalt)))
+}
+
+func (h *HasherService) isLegacy(hash string) bool {
+	if len(hash) != (len(h.legacySalt)+sha256.Size)*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(hash)
+	return err == nil
 }
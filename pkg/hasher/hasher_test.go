@@ -0,0 +1,155 @@
+package hasher
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const legacySalt = "pepper"
+
+func newTestHasher(t *testing.T) *HasherService {
+	t.Helper()
+
+	h, err := NewHasher(legacySalt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return h
+}
+
+// argon2idHash makes a hash of the password with the given cost parameters.
+func argon2idHash(password string, memory uint32, time uint32, threads uint8) string {
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte(password), salt, time, memory, threads, argonKeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, time, threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func bcryptHash(t *testing.T, password string) string {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(hash)
+}
+
+// legacyHash is the salted sha256 hash of the former hasher: hex of the salt followed by the digest.
+func legacyHash(password string) string {
+	digest := sha256.Sum256([]byte(password))
+	return hex.EncodeToString([]byte(legacySalt)) + hex.EncodeToString(digest[:])
+}
+
+func TestNewHasher(t *testing.T) {
+	if _, err := NewHasher(""); err == nil {
+		t.Error("NewHasher(\"\") error = nil, want an error")
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	h := newTestHasher(t)
+
+	hash, err := h.HashPassword("secret123")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=1,p=4$") {
+		t.Errorf("HashPassword() = %q, want an argon2id hash with the current parameters", hash)
+	}
+
+	again, err := h.HashPassword("secret123")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	if hash == again {
+		t.Error("HashPassword() gave the same hash twice, want a random salt")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	h := newTestHasher(t)
+
+	current, err := h.HashPassword("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		want     bool
+		wantErr  error
+	}{
+		{"argon2id", "secret123", current, true, nil},
+		{"argon2id wrong password", "secret124", current, false, nil},
+		{"argon2id other parameters", "secret123", argon2idHash("secret123", 1024, 2, 1), true, nil},
+		{"bcrypt", "secret123", bcryptHash(t, "secret123"), true, nil},
+		{"bcrypt wrong password", "secret124", bcryptHash(t, "secret123"), false, nil},
+		{"legacy sha256", "secret123", legacyHash("secret123"), true, nil},
+		{"legacy sha256 wrong password", "secret124", legacyHash("secret123"), false, nil},
+		{"unknown format", "secret123", "secret123", false, ErrUnknownHashFormat},
+		{"empty hash", "secret123", "", false, ErrUnknownHashFormat},
+		{"argon2id missing key", "secret123", "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA", false, ErrUnknownHashFormat},
+		{"argon2id other version", "secret123", strings.Replace(current, "v=19", "v=16", 1), false, ErrUnknownHashFormat},
+		{"argon2id bad parameters", "secret123", strings.Replace(current, "m=65536", "m=lots", 1), false, ErrUnknownHashFormat},
+		{"argon2id bad salt", "secret123", "$argon2id$v=19$m=65536,t=1,p=4$!!$c2FsdA", false, ErrUnknownHashFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.Verify(tt.password, tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	h := newTestHasher(t)
+
+	current, err := h.HashPassword("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"current argon2id", current, false},
+		{"argon2id less memory", argon2idHash("secret123", 1024, argonTime, argonThreads), true},
+		{"argon2id more passes", argon2idHash("secret123", argonMemory, 2, argonThreads), true},
+		{"argon2id fewer threads", argon2idHash("secret123", argonMemory, argonTime, 1), true},
+		{"bcrypt", bcryptHash(t, "secret123"), true},
+		{"legacy sha256", legacyHash("secret123"), true},
+		{"unknown format", "secret123", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash(%q) = %v, want %v", tt.hash, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package images
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrTooLarge          = errors.New("image is too large")
+	ErrUnsupportedFormat = errors.New("only jpeg, png, gif and webp images are supported")
+	ErrInvalidImage      = errors.New("invalid image")
+	ErrInvalidName       = errors.New("invalid image name")
+)
+
+// a small file can still decode into a huge bitmap
+const maxPixels = 40_000_000
+
+// formats by the sniffed content type, the extension of the stored file
+var formats = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+const thumbnailSuffix = "_thumb"
+
+// names of stored files, the content never changes under the same name
+var storedNameRe = regexp.MustCompile(`^[0-9a-f]{64}(` + thumbnailSuffix + `)?\.(jpg|png|gif|webp)$`)
+
+type Image struct {
+	Name      string `json:"image"`
+	Thumbnail string `json:"thumbnail"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+}
+
+type Storage interface {
+	Save(r io.Reader) (Image, error)
+	Remove(name string) error
+	Path(name string) (string, error)
+	List() ([]string, error)
+}
+
+// Store keeps images on disk under the sha256 of their content, so the same upload is stored once.
+// Metadata is stripped before hashing and a thumbnail is stored next to every image.
+type Store struct {
+	dir           string
+	maxSize       int64
+	thumbnailSize int
+}
+
+func NewStore(dir string, maxSize int64, thumbnailSize int) (*Store, error) {
+	if maxSize <= 0 || thumbnailSize <= 0 {
+		return nil, errors.New("image size limits must be positive")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create images directory: %w", err)
+	}
+
+	return &Store{
+		dir:           dir,
+		maxSize:       maxSize,
+		thumbnailSize: thumbnailSize,
+	}, nil
+}
+
+func (s *Store) Save(r io.Reader) (Image, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return Image{}, fmt.Errorf("read image: %w", err)
+	}
+
+	if int64(len(data)) > s.maxSize {
+		return Image{}, ErrTooLarge
+	}
+
+	// the declared content type of the upload is not trusted
+	ext, ok := formats[http.DetectContentType(data)]
+	if !ok {
+		return Image{}, ErrUnsupportedFormat
+	}
+
+	if err := checkDimensions(data); err != nil {
+		return Image{}, err
+	}
+
+	data, err = stripMetadata(ext, data)
+	if err != nil {
+		return Image{}, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrInvalidImage
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	img := Image{
+		Name:   hash + "." + ext,
+		Width:  src.Bounds().Dx(),
+		Height: src.Bounds().Dy(),
+	}
+
+	thumbnail, thumbnailExt, err := s.thumbnail(src, ext)
+	if err != nil {
+		return Image{}, err
+	}
+
+	img.Thumbnail = hash + thumbnailSuffix + "." + thumbnailExt
+
+	if err := s.write(img.Name, data); err != nil {
+		return Image{}, err
+	}
+
+	if err := s.write(img.Thumbnail, thumbnail); err != nil {
+		return Image{}, err
+	}
+
+	return img, nil
+}
+
+// Remove removes the image with its thumbnail, removing a missing image is not an error.
+func (s *Store) Remove(name string) error {
+	if !storedNameRe.MatchString(name) || strings.Contains(name, thumbnailSuffix) {
+		return ErrInvalidName
+	}
+
+	hash := strings.TrimSuffix(name, filepath.Ext(name))
+
+	for _, ext := range []string{"jpg", "png"} {
+		if err := os.Remove(filepath.Join(s.dir, hash+thumbnailSuffix+"."+ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove thumbnail: %w", err)
+		}
+	}
+
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove image: %w", err)
+	}
+
+	return nil
+}
+
+// Path returns the file of the image, names can't point outside of the store.
+func (s *Store) Path(name string) (string, error) {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", ErrInvalidName
+	}
+
+	return filepath.Join(s.dir, name), nil
+}
+
+// List returns names of the stored images, thumbnails are left out.
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("list images: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && storedNameRe.MatchString(name) && !strings.Contains(name, thumbnailSuffix) {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// IsStored tells the name is a content addressed image, so it can be cached forever.
+func IsStored(name string) bool {
+	return storedNameRe.MatchString(name)
+}
+
+// write stores the file unless the same content is already there, a partly written file is never visible.
+func (s *Store) write(name string, data []byte) error {
+	path := filepath.Join(s.dir, name)
+
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("store image: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("store image: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("store image: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("store image: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("store image: %w", err)
+	}
+
+	return nil
+}
+
+// thumbnail scales the image down to fit a thumbnailSize square, jpeg stays jpeg and
+// the rest becomes png to keep transparency. Animated gifs keep the first frame.
+func (s *Store) thumbnail(src image.Image, ext string) ([]byte, string, error) {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > s.thumbnailSize || height > s.thumbnailSize {
+		if width >= height {
+			height = atLeastOne(height * s.thumbnailSize / width)
+			width = s.thumbnailSize
+		} else {
+			width = atLeastOne(width * s.thumbnailSize / height)
+			height = s.thumbnailSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+
+	if ext == "jpg" {
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", fmt.Errorf("encode thumbnail: %w", err)
+		}
+		return buf.Bytes(), "jpg", nil
+	}
+
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, "", fmt.Errorf("encode thumbnail: %w", err)
+	}
+
+	return buf.Bytes(), "png", nil
+}
+
+func checkDimensions(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ErrInvalidImage
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return ErrInvalidImage
+	}
+
+	return nil
+}
+
+func atLeastOne(n int) int {
+	if n < 1 {
+		return 1
+	}
+
+	return n
+}
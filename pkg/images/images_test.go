@@ -0,0 +1,161 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var stored = strings.Repeat("ab", 32) + ".png"
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	s, err := NewStore(t.TempDir(), 1<<20, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestPath(t *testing.T) {
+	s := newTestStore(t)
+
+	tests := []struct {
+		name    string
+		image   string
+		wantErr bool
+	}{
+		{"stored image", stored, false},
+		{"thumbnail", strings.Repeat("ab", 32) + "_thumb.jpg", false},
+		{"default avatar", "default.jpg", false},
+		{"empty", "", true},
+		{"dot", ".", true},
+		{"parent", "..", true},
+		{"hidden file", ".env", true},
+		{"parent directory", "../forum.db", true},
+		{"nested parent", "avatars/../../forum.db", true},
+		{"subdirectory", "avatars/default.jpg", true},
+		{"absolute", "/etc/passwd", true},
+		{"trailing slash", "default.jpg/", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := s.Path(tt.image)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidName) {
+					t.Errorf("Path(%q) = %q, %v, want %v", tt.image, path, err, ErrInvalidName)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Path(%q) error = %v", tt.image, err)
+			}
+			if want := filepath.Join(s.dir, tt.image); path != want {
+				t.Errorf("Path(%q) = %q, want %q", tt.image, path, want)
+			}
+		})
+	}
+}
+
+func TestIsStored(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+		want  bool
+	}{
+		{"image", stored, true},
+		{"thumbnail", strings.Repeat("ab", 32) + "_thumb.png", true},
+		{"default avatar", "default.jpg", false},
+		{"upper case hash", strings.Repeat("AB", 32) + ".png", false},
+		{"short hash", strings.Repeat("ab", 31) + ".png", false},
+		{"other extension", strings.Repeat("ab", 32) + ".svg", false},
+		{"path", "../" + stored, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsStored(tt.image); got != tt.want {
+				t.Errorf("IsStored(%q) = %v, want %v", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveRejectsNames(t *testing.T) {
+	s := newTestStore(t)
+
+	for _, name := range []string{"default.jpg", "../" + stored, strings.Repeat("ab", 32) + "_thumb.png", ""} {
+		if err := s.Remove(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Remove(%q) error = %v, want %v", name, err, ErrInvalidName)
+		}
+	}
+}
+
+func TestSaveAndRemove(t *testing.T) {
+	s := newTestStore(t)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := s.Save(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if !IsStored(img.Name) || !IsStored(img.Thumbnail) || img.Width != 40 || img.Height != 20 {
+		t.Errorf("Save() = %+v, want a stored 40x20 image with a thumbnail", img)
+	}
+
+	again, err := s.Save(bytes.NewReader(buf.Bytes()))
+	if err != nil || again != img {
+		t.Errorf("Save() of the same image = %+v, %v, want %+v", again, err, img)
+	}
+
+	names, err := s.List()
+	if err != nil || len(names) != 1 || names[0] != img.Name {
+		t.Errorf("List() = %v, %v, want [%s]", names, err, img.Name)
+	}
+
+	if err := s.Remove(img.Name); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	for _, name := range []string{img.Name, img.Thumbnail} {
+		if _, err := os.Stat(filepath.Join(s.dir, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s is left after Remove(), stat error = %v", name, err)
+		}
+	}
+}
+
+func TestSaveRejects(t *testing.T) {
+	s := newTestStore(t)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{"text", []byte("hello, this is not an image"), ErrUnsupportedFormat},
+		{"too large", bytes.Repeat([]byte{0}, 1<<20+1), ErrTooLarge},
+		{"broken png", []byte("\x89PNG\r\n\x1a\n broken"), ErrInvalidImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Save(bytes.NewReader(tt.data)); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Save() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/gif"
+)
+
+// stripMetadata drops camera, location and text metadata without touching the pixels:
+// jpeg and png are filtered by segments, webp by chunks and gif is reencoded losslessly.
+func stripMetadata(ext string, data []byte) ([]byte, error) {
+	switch ext {
+	case "jpg":
+		return stripJPEG(data)
+	case "png":
+		return stripPNG(data)
+	case "webp":
+		return stripWebP(data)
+	case "gif":
+		return stripGIF(data)
+	}
+
+	return nil, ErrUnsupportedFormat
+}
+
+// jpeg segments that are kept: JFIF, ICC profile and Adobe color transform
+var jpegKeptApps = map[byte]bool{0xE0: true, 0xE2: true, 0xEE: true}
+
+const (
+	jpegSOS = 0xDA
+	jpegCOM = 0xFE
+)
+
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrInvalidImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	for i := 2; ; {
+		if i+4 > len(data) || data[i] != 0xFF {
+			return nil, ErrInvalidImage
+		}
+
+		marker := data[i+1]
+		// fill bytes before a marker
+		if marker == 0xFF {
+			i++
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		// the compressed scan runs to the end of the image
+		if marker == jpegSOS {
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+
+		isApp := marker >= 0xE1 && marker <= 0xEF
+		if !(isApp && !jpegKeptApps[marker]) && marker != jpegCOM {
+			out.Write(data[i:end])
+		}
+
+		i = end
+	}
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// png chunks with metadata, the rest is needed to render the image
+var pngDroppedChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrInvalidImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	for i := len(pngSignature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		chunk := string(data[i+4 : i+8])
+		// length, type, data and crc
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		if !pngDroppedChunks[chunk] {
+			out.Write(data[i:end])
+		}
+
+		i = end
+
+		if chunk == "IEND" {
+			break
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// VP8X flags of the dropped chunks
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrInvalidImage
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		chunk := string(data[i : i+4])
+		length := int(binary.LittleEndian.Uint32(data[i+4:]))
+		// chunks are padded to an even size
+		end := i + 8 + length + length%2
+		if length < 0 || end > len(data) {
+			return nil, ErrInvalidImage
+		}
+
+		switch chunk {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := out.Len()
+			out.Write(data[i:end])
+			if length > 0 {
+				out.Bytes()[start+8] &^= webpFlagEXIF | webpFlagXMP
+			}
+		default:
+			out.Write(data[i:end])
+		}
+
+		i = end
+	}
+
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+
+	return stripped, nil
+}
+
+// gif has no pixel preserving filter for its extension blocks, decoding and encoding
+// all frames keeps the palettes and timings and drops comments and application data.
+func stripGIF(data []byte) ([]byte, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	return buf.Bytes(), nil
+}
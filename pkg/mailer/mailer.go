@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"real-time-forum/pkg/logger"
+)
+
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// SMTP sends plain text mails through an SMTP server.
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTP(host, port, username, password, from string) *SMTP {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTP{
+		addr: host + ":" + port,
+		auth: auth,
+		from: from,
+	}
+}
+
+func (m *SMTP) Send(to, subject, body string) error {
+	var msg strings.Builder
+
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(body)
+
+	if err := smtp.SendMail(m.addr, m.auth, m.from, []string{to}, []byte(msg.String())); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+
+	return nil
+}
+
+// Log writes mails to the log instead of sending them, for running without an SMTP server.
+type Log struct {
+	log *logger.Logger
+}
+
+func NewLog(log *logger.Logger) *Log {
+	return &Log{log: log}
+}
+
+func (m *Log) Send(to, subject, body string) error {
+	m.log.Info("mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
@@ -0,0 +1,337 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var ErrNoMigrations = errors.New("no applied migrations")
+
+// Migration is a pair of up and down scripts named NNNN_name.up.sql and NNNN_name.down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a known migration and the time it was applied, nil if it is pending.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+var fileNameRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+func New(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := readMigrations(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+func readMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+
+	for _, file := range files {
+		match := fileNameRe.FindStringSubmatch(file.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse version of %s: %w", file.Name(), err)
+		}
+
+		data, err := fs.ReadFile(fsys, file.Name())
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("version %d is used by %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both up and down scripts", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at DATETIME NOT NULL
+		);`)
+	if err != nil {
+		return fmt.Errorf("migrate: create schema_migrations: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT
+			version, applied_at
+		FROM
+			schema_migrations;`)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: get applied: %w", err)
+	}
+
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("migrate: get applied: %w", err)
+		}
+
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// Up applies all pending migrations in order and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := m.apply(ctx, migration, true); err != nil {
+			return done, err
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down rolls back the latest applied migration.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		return migration, m.apply(ctx, migration, false)
+	}
+
+	return Migration{}, ErrNoMigrations
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// apply runs the script in a transaction together with the schema_migrations record.
+// Foreign keys are disabled while tables are rebuilt and checked before the commit,
+// the pragma has no effect inside a transaction so a single connection is pinned.
+func (m *Migrator) apply(ctx context.Context, migration Migration, up bool) (err error) {
+	direction, script := "up", migration.Up
+	if !up {
+		direction, script = "down", migration.Down
+	}
+
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("migrate: %s %04d_%s: %w", direction, migration.Version, migration.Name, err)
+		}
+	}()
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF;`); err != nil {
+		return err
+	}
+
+	defer conn.ExecContext(context.Background(), `PRAGMA foreign_keys = ON;`)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	violations, err := foreignKeyViolations(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if up {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO
+				schema_migrations (version, name, applied_at)
+			VALUES
+				($1, $2, $3);`,
+			migration.Version, migration.Name, time.Now(),
+		)
+	} else {
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM
+				schema_migrations
+			WHERE
+				version = $1;`,
+			migration.Version,
+		)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// the migration must not break references, old broken ones are left as they are
+	after, err := foreignKeyViolations(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for violation := range after {
+		if !violations[violation] {
+			tx.Rollback()
+			return fmt.Errorf("foreign key violation: %s row %d references %s", violation.table, violation.rowID, violation.parent)
+		}
+	}
+
+	return tx.Commit()
+}
+
+type fkViolation struct {
+	table  string
+	rowID  int64
+	parent string
+}
+
+func foreignKeyViolations(ctx context.Context, tx *sql.Tx) (map[fkViolation]bool, error) {
+	rows, err := tx.QueryContext(ctx, `PRAGMA foreign_key_check;`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	violations := make(map[fkViolation]bool)
+
+	for rows.Next() {
+		var (
+			violation fkViolation
+			rowID     sql.NullInt64
+			fkID      int
+		)
+
+		if err := rows.Scan(&violation.table, &rowID, &violation.parent, &fkID); err != nil {
+			return nil, err
+		}
+
+		violation.rowID = rowID.Int64
+		violations[violation] = true
+	}
+
+	return violations, rows.Err()
+}
@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func file(data string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(data)}
+}
+
+// scripts create a table each, 10 sorts before 2 by name but runs after it
+var scripts = fstest.MapFS{
+	"1_users.up.sql":    file(`CREATE TABLE users (id INTEGER PRIMARY KEY);`),
+	"1_users.down.sql":  file(`DROP TABLE users;`),
+	"2_posts.up.sql":    file(`CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id));`),
+	"2_posts.down.sql":  file(`DROP TABLE posts;`),
+	"10_likes.up.sql":   file(`CREATE TABLE likes (post_id INTEGER REFERENCES posts (id));`),
+	"10_likes.down.sql": file(`DROP TABLE likes;`),
+	"README.md":         file(`not a migration`),
+}
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func newMigrator(t *testing.T, fsys fstest.MapFS) *Migrator {
+	t.Helper()
+
+	m, err := New(openDB(t), fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func versions(migrations []Migration) []int {
+	list := []int{}
+	for _, m := range migrations {
+		list = append(list, m.Version)
+	}
+	return list
+}
+
+// applied lists the versions the status reports as applied.
+func applied(t *testing.T, m *Migrator) []int {
+	t.Helper()
+
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := []int{}
+	for _, s := range statuses {
+		if s.AppliedAt != nil {
+			list = append(list, s.Version)
+		}
+	}
+	return list
+}
+
+func TestReadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []int
+		wantErr bool
+	}{
+		{"ordered by version", scripts, []int{1, 2, 10}, false},
+		{"empty", fstest.MapFS{}, []int{}, false},
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{
+				"1_users.up.sql": file(`SELECT 1;`),
+			},
+			wantErr: true,
+		},
+		{
+			name: "version used twice",
+			fsys: fstest.MapFS{
+				"1_users.up.sql":   file(`SELECT 1;`),
+				"1_users.down.sql": file(`SELECT 1;`),
+				"1_posts.up.sql":   file(`SELECT 1;`),
+				"1_posts.down.sql": file(`SELECT 1;`),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := readMigrations(tt.fsys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readMigrations() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := versions(migrations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("versions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t, scripts)
+
+	if got := applied(t, m); len(got) != 0 {
+		t.Fatalf("applied before up = %v, want none", got)
+	}
+
+	done, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if got, want := versions(done), []int{1, 2, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Up() applied %v, want %v", got, want)
+	}
+
+	done, err = m.Up(ctx)
+	if err != nil || len(done) != 0 {
+		t.Errorf("second Up() = %v, %v, want nothing applied", versions(done), err)
+	}
+
+	steps := []struct {
+		wantVersion int
+		wantApplied []int
+	}{
+		{10, []int{1, 2}},
+		{2, []int{1}},
+		{1, []int{}},
+	}
+
+	for _, step := range steps {
+		migration, err := m.Down(ctx)
+		if err != nil {
+			t.Fatalf("Down() error = %v", err)
+		}
+		if migration.Version != step.wantVersion {
+			t.Errorf("Down() rolled back %d, want %d", migration.Version, step.wantVersion)
+		}
+		if got := applied(t, m); !reflect.DeepEqual(got, step.wantApplied) {
+			t.Errorf("applied after down of %d = %v, want %v", step.wantVersion, got, step.wantApplied)
+		}
+	}
+
+	if _, err := m.Down(ctx); !errors.Is(err, ErrNoMigrations) {
+		t.Errorf("Down() with nothing applied error = %v, want %v", err, ErrNoMigrations)
+	}
+
+	// the tables were dropped, so up runs all of the scripts again
+	done, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() after down error = %v", err)
+	}
+	if got, want := versions(done), []int{1, 2, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Up() after down applied %v, want %v", got, want)
+	}
+}
+
+func TestUpStopsAtFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"invalid script", `CREATE TABLE broken (;`},
+		{"foreign key violation", `INSERT INTO posts (id, user_id) VALUES (1, 42);`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"11_broken.up.sql":   file(tt.script),
+				"11_broken.down.sql": file(`SELECT 1;`),
+				"12_tags.up.sql":     file(`CREATE TABLE tags (id INTEGER);`),
+				"12_tags.down.sql":   file(`DROP TABLE tags;`),
+			}
+			for name, f := range scripts {
+				fsys[name] = f
+			}
+
+			m := newMigrator(t, fsys)
+
+			done, err := m.Up(context.Background())
+			if err == nil {
+				t.Fatal("Up() error = nil, want the error of 11_broken")
+			}
+			if got, want := versions(done), []int{1, 2, 10}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Up() applied %v, want %v", got, want)
+			}
+			if got, want := applied(t, m), []int{1, 2, 10}; !reflect.DeepEqual(got, want) {
+				t.Errorf("applied = %v, want %v", got, want)
+			}
+
+			var posts int
+			if err := m.db.QueryRow(`SELECT COUNT(*) FROM posts;`).Scan(&posts); err != nil || posts != 0 {
+				t.Errorf("posts = %d, %v, want the failed script rolled back", posts, err)
+			}
+		})
+	}
+}
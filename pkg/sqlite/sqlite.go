@@ -4,12 +4,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"os"
 	"real-time-forum/internal/config"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrNoFTS5 is returned when the driver is compiled without the full-text search the forum's search needs.
+var ErrNoFTS5 = errors.New("sqlite is built without FTS5, rebuild with -tags sqlite_fts5")
+
 func ConnectDatabase(cfg *config.Config) (*sql.DB, error) {
 	enableForeignKeys := "?_foreign_keys=on&cache=shared&mode=rwc"
 
@@ -22,25 +24,24 @@ func ConnectDatabase(cfg *config.Config) (*sql.DB, error) {
 		return nil, fmt.Errorf("ping database: %w", err)
 	}
 
-	if err := createTables(db, cfg.Sqlite.SchemePath); err != nil {
-		return nil, fmt.Errorf("create tables: %w", err)
+	if err := CheckFTS5(db); err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	return db, nil
 }
 
-var Errorka = errors.New("UNIQUE constraint failed: categories.category")
+// CheckFTS5 returns ErrNoFTS5 if sqlite has no FTS5 module, so it's reported before the migrations fail on it.
+func CheckFTS5(db *sql.DB) error {
+	var enabled bool
 
-func createTables(db *sql.DB, schemePath string) error {
-	data, err := os.ReadFile(schemePath)
-	if err != nil {
-		return fmt.Errorf("read file: %w", err)
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5');`).Scan(&enabled); err != nil {
+		return fmt.Errorf("check fts5: %w", err)
 	}
 
-	if _, err := db.Exec(string(data)); err != nil {
-		if errors.Is(err, Errorka) {
-			return fmt.Errorf("exec: %w", err)
-		}
+	if !enabled {
+		return ErrNoFTS5
 	}
 
 	return nil
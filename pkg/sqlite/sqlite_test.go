@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"real-time-forum/internal/config"
+)
+
+func TestConnectDatabase(t *testing.T) {
+	cfg := &config.Config{Sqlite: config.Sqlite{
+		Driver:           "sqlite3",
+		DatabaseFileName: filepath.Join(t.TempDir(), "forum.db"),
+	}}
+
+	db, err := ConnectDatabase(cfg)
+
+	// the module is compiled in by the build tag only
+	if !fts5 {
+		if !errors.Is(err, ErrNoFTS5) {
+			t.Fatalf("ConnectDatabase() without FTS5 error = %v, want %v", err, ErrNoFTS5)
+		}
+		return
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var foreignKeys bool
+	if err := db.QueryRow(`PRAGMA foreign_keys;`).Scan(&foreignKeys); err != nil {
+		t.Fatal(err)
+	}
+	if !foreignKeys {
+		t.Error("foreign keys are off")
+	}
+
+	if _, err := db.Exec(`CREATE VIRTUAL TABLE search USING fts5 (text);`); err != nil {
+		t.Errorf("create fts5 table: %v", err)
+	}
+}
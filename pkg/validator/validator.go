@@ -0,0 +1,134 @@
+package validator
+
+import (
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError tells what is wrong with one field of an input.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors are the problems found in an input, a field has one at most.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Field + ": " + err.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Validator collects the errors of the fields checked. Once a field has an error
+// the next checks of it are skipped, so the first failed rule is the one reported.
+type Validator struct {
+	errors Errors
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Check adds the message to the field when ok is false.
+func (v *Validator) Check(ok bool, field string, message string) {
+	if ok || v.has(field) {
+		return
+	}
+
+	v.errors = append(v.errors, FieldError{Field: field, Message: message})
+}
+
+// Valid tells if every check passed so far.
+func (v *Validator) Valid() bool {
+	return len(v.errors) == 0
+}
+
+// Err returns the errors found as Errors, nil when there are none.
+func (v *Validator) Err() error {
+	if v.Valid() {
+		return nil
+	}
+
+	return v.errors
+}
+
+func (v *Validator) has(field string) bool {
+	for _, err := range v.errors {
+		if err.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NotBlank tells if s has anything besides spaces.
+func NotBlank(s string) bool {
+	return strings.TrimSpace(s) != ""
+}
+
+// Length tells if s has from min to max characters.
+func Length(s string, min int, max int) bool {
+	n := utf8.RuneCountInString(s)
+	return n >= min && n <= max
+}
+
+// Between tells if n is from min to max.
+func Between(n int, min int, max int) bool {
+	return n >= min && n <= max
+}
+
+// OneOf tells if s is one of the values.
+func OneOf(s string, values ...string) bool {
+	for _, value := range values {
+		if s == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Positive tells if every id is above zero, ids of rows start from one.
+func Positive(ids ...int) bool {
+	for _, id := range ids {
+		if id <= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Email tells if s is a bare address like user@example.com, without a display name or brackets.
+func Email(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// StripControl removes the C0 control characters from s except tabs and line breaks. They aren't shown
+// in a text, so stored text can use them as markers, like the search does to highlight matches.
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Chars tells if every character of s is allowed.
+func Chars(s string, allowed func(r rune) bool) bool {
+	for _, r := range s {
+		if !allowed(r) {
+			return false
+		}
+	}
+
+	return true
+}
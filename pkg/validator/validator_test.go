@@ -0,0 +1,131 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"unicode"
+)
+
+func TestValidator(t *testing.T) {
+	v := New()
+
+	if !v.Valid() || v.Err() != nil {
+		t.Fatalf("new validator Valid() = %v, Err() = %v, want valid", v.Valid(), v.Err())
+	}
+
+	v.Check(true, "username", "username is required")
+	v.Check(false, "age", "age must be from 13 to 120")
+	v.Check(false, "age", "age must be a number")
+	v.Check(false, "email", "email is invalid")
+
+	if v.Valid() {
+		t.Fatal("Valid() = true after failed checks")
+	}
+
+	var errs Errors
+	if !errors.As(v.Err(), &errs) {
+		t.Fatalf("Err() = %T, want Errors", v.Err())
+	}
+
+	want := Errors{
+		{Field: "age", Message: "age must be from 13 to 120"},
+		{Field: "email", Message: "email is invalid"},
+	}
+
+	if len(errs) != len(want) {
+		t.Fatalf("Err() = %v, want %v", errs, want)
+	}
+	for i := range want {
+		if errs[i] != want[i] {
+			t.Errorf("Err()[%d] = %+v, want %+v", i, errs[i], want[i])
+		}
+	}
+
+	if got, want := errs.Error(), "age: age must be from 13 to 120; email: email is invalid"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	data, err := json.Marshal(errs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `[{"field":"age","message":"age must be from 13 to 120"},{"field":"email","message":"email is invalid"}]`; got != want {
+		t.Errorf("json = %s, want %s", got, want)
+	}
+}
+
+func TestRules(t *testing.T) {
+	letter := func(r rune) bool { return unicode.IsLetter(r) }
+
+	tests := []struct {
+		name string
+		ok   bool
+		want bool
+	}{
+		{"NotBlank text", NotBlank(" a "), true},
+		{"NotBlank empty", NotBlank(""), false},
+		{"NotBlank spaces", NotBlank(" \t\n"), false},
+
+		{"Length in range", Length("abc", 1, 3), true},
+		{"Length counts characters", Length("ёжик", 4, 4), true},
+		{"Length too short", Length("", 1, 3), false},
+		{"Length too long", Length("abcd", 1, 3), false},
+
+		{"Between min", Between(13, 13, 120), true},
+		{"Between max", Between(120, 13, 120), true},
+		{"Between below", Between(12, 13, 120), false},
+		{"Between above", Between(121, 13, 120), false},
+
+		{"OneOf listed", OneOf("Male", "Male", "Female"), true},
+		{"OneOf case matters", OneOf("male", "Male", "Female"), false},
+		{"OneOf nothing listed", OneOf("Male"), false},
+
+		{"Positive ids", Positive(1, 2, 3), true},
+		{"Positive none", Positive(), true},
+		{"Positive zero", Positive(1, 0), false},
+		{"Positive negative", Positive(-1), false},
+
+		{"Email plain", Email("user@example.com"), true},
+		{"Email no at", Email("user.example.com"), false},
+		{"Email display name", Email("User <user@example.com>"), false},
+		{"Email brackets", Email("<user@example.com>"), false},
+		{"Email spaces around", Email(" user@example.com "), false},
+		{"Email empty", Email(""), false},
+
+		{"Chars allowed", Chars("abc", letter), true},
+		{"Chars empty", Chars("", letter), true},
+		{"Chars not allowed", Chars("ab1", letter), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ok != tt.want {
+				t.Errorf("got %v, want %v", tt.ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripControl(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"plain", "hello", "hello"},
+		{"keeps tabs and line breaks", "a\tb\r\nc", "a\tb\r\nc"},
+		{"match markers", "\x02mark\x03", "mark"},
+		{"null and escape", "a\x00b\x1bc", "abc"},
+		{"keeps other characters", "ёжик 🎉 ", "ёжик 🎉 "},
+		{"only control", "\x01\x02\x1f", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripControl(tt.s); got != tt.want {
+				t.Errorf("StripControl(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
@@ -6,6 +6,8 @@ import Post from "./views/PostView.js";
 import NewPost from "./views/NewPostView.js";
 import Chats from "./views/ChatsView.js";
 import Profile from "./views/ProfileView.js";
+import Settings from "./views/SettingsView.js";
+import VerifyEmail from "./views/VerifyEmailView.js";
 
 import Ws from "./services/Ws.js";
 import Utils from "./services/Utils.js";
@@ -44,6 +46,8 @@ const router = async () => {
         { path: "/post/:postID", view: Post },
         { path: "/chats", view: Chats },
         { path: "/user/:userID", view: Profile },
+        { path: "/settings", view: Settings, minRole: roles.user },
+        { path: "/verify-email", view: VerifyEmail },
     ];
 
     // Test each route for potential match
@@ -97,7 +101,7 @@ document.addEventListener("DOMContentLoaded", async () => {
         }
     });
 
-    if (localStorage.getItem("token")) {
+    if (localStorage.getItem("accessToken")) {
         await Ws.connect();
     }
 
@@ -10,17 +10,28 @@ const fetcher = {
         return makeRequest(path, body, "POST");
     },
 
+    put: async (path, body) => {
+        return makeRequest(path, body, "PUT");
+    },
+
     refreshToken: async () => {
-        const accessToken = localStorage.getItem("token");
+        const refreshToken = localStorage.getItem("refreshToken");
 
-        localStorage.removeItem("token");
+        localStorage.removeItem("accessToken");
 
         const path = "/api/auth/refresh";
-        const body = { accessToken: accessToken, refreshToken: refreshToken };
+        const body = { refreshToken: refreshToken };
         const data = await fetcher.post(path, body);
+        if (!data || data.error) {
+            Utils.logOut();
+            Router.navigateTo("/sign-in");
+            return;
+        }
+
         const payload = Utils.parseJwt(data.accessToken);
 
         localStorage.setItem("accessToken", data.accessToken);
+        localStorage.setItem("refreshToken", data.refreshToken);
         localStorage.setItem("role", parseInt(payload.role));
     },
 };
@@ -28,18 +39,22 @@ const fetcher = {
 const makeRequest = async (path, body, method) => {
     const url = `http://${API_HOST_NAME}${path}`;
     console.log(url);
+    // files are sent as a multipart form, the browser sets its content type
+    const isForm = body instanceof FormData;
     const options = {
         mode: "cors",
         method: method,
-        body: JSON.stringify(body),
+        body: isForm ? body : JSON.stringify(body),
     };
 
-    const accessToken = localStorage.getItem("token");
+    const accessToken = localStorage.getItem("accessToken");
     if (accessToken != undefined) {
         options.headers = new Headers({
-            "Content-Type": "application/json",
             Authorization: `Bearer ${accessToken}`,
         });
+        if (!isForm) {
+            options.headers.set("Content-Type", "application/json");
+        }
     }
 
     const response = await fetch(url, options).catch((e) => {
@@ -55,7 +70,7 @@ const makeRequest = async (path, body, method) => {
         return;
     }
 
-    if (response.status == 400) {
+    if (response.status == 400 || response.status == 422) {
         if (respBody.error == "invalid token") {
             Utils.logOut();
             Router.navigateTo("/sign-in");
@@ -64,9 +79,9 @@ const makeRequest = async (path, body, method) => {
 
         const errorEl = document.getElementById("error-message");
         if (errorEl) {
-            errorEl.innerText = respBody.error;
+            errorEl.innerText = errorText(respBody);
         } else {
-            Utils.showError(response.status, respBody.error);
+            Utils.showError(response.status, errorText(respBody));
         }
         return;
     }
@@ -91,4 +106,12 @@ const makeRequest = async (path, body, method) => {
     return respBody;
 };
 
+// errorText lists the errors of the fields when the server tells which ones are wrong
+const errorText = (respBody) => {
+    if (!respBody.fields) {
+        return respBody.error;
+    }
+    return respBody.fields.map((field) => field.message).join("\n");
+};
+
 export default fetcher;
@@ -43,6 +43,10 @@ const getConnection = () => {
                     case "chatsResponse":
                         Chats.drawChats(obj.body)
                         break
+                    case "messageEdited":
+                    case "messageDeleted":
+                        Chats.replaceMessage(obj.body)
+                        break
                     case "readMessageResponse":
                         Chats.changeMessageStatusToRead(obj.body)
                         break
@@ -52,16 +56,22 @@ const getConnection = () => {
                     case "onlineUsersResponse":
                         Chats.drawOnlineUsers(obj.body)
                         break
+                    case "presence":
+                        Chats.drawPresence(obj.body)
+                        break
                     case "typingInResponse":
                         Chats.drawTypingIn(obj.body)
                         break
                     case "error":
-                        if (obj.body == "token has expired") {
+                        // the body is { error, fields } like the error responses of the API
+                        if (obj.body.error == "token has expired") {
                             await Fetcher.refreshToken()
                             token = localStorage.getItem("accessToken")
                             conn.send(JSON.stringify({ type: "token", body: token }))
+                        } else if (obj.body.fields) {
+                            alert(obj.body.fields.map((field) => field.message).join("\n"))
                         } else {
-                            alert(obj.body)
+                            alert(obj.body.error)
                         }
                         break
                     case "successConnection":
@@ -92,6 +102,13 @@ const Ws = {
         connection.send(e)
     },
 
+    // authenticate moves the open connection to a new session, the server closes the ones of ended sessions
+    authenticate: (token) => {
+        if (connection && connection.readyState == 1) {
+            connection.send(JSON.stringify({ type: "token", body: token }))
+        }
+    },
+
     disconnect: async () => {
         connection.close()
     }
@@ -7,6 +7,7 @@ var requestOnlineUsersInterval
 var recipientID
 
 var loadMessages
+var olderMessagesCursor
 var sendTypingInEvent
 
 const requestOnlineUsers = () => {
@@ -28,7 +29,12 @@ const newMessageElement = (message) => {
 
     const messageText = document.createElement('p')
     messageText.classList.add('message-text')
-    messageText.innerText = message.message
+    if (message.deletedAt) {
+        messageText.innerText = 'Message deleted'
+        el.classList.add('deleted-message')
+    } else {
+        messageText.innerText = message.message
+    }
 
     const messageInfo = document.createElement('div')
     messageInfo.classList.add('message-info')
@@ -37,6 +43,9 @@ const newMessageElement = (message) => {
     const messageDate = document.createElement('p')
     messageDate.classList.add('message-date')
     messageDate.innerText = new Date(Date.parse(message.date)).toLocaleString()
+    if (message.editedAt && !message.deletedAt) {
+        messageDate.innerText += ' (edited)'
+    }
 
     const readStatus = document.createElement('p')
     readStatus.classList.add('message-status')
@@ -99,7 +108,8 @@ const newChatElement = (chat) => {
     lastMessageDate.id = `chat-${chat.user.id}-lastMessageDate`
 
     if (chat.lastMessage.id) {
-        lastMessage.innerText = `${chat.lastMessage.message}`
+        lastMessage.innerText = chat.lastMessage.deletedAt ? 'Message deleted' : `${chat.lastMessage.message}`
+        lastMessage.dataset.messageId = chat.lastMessage.id
         lastMessageDate.innerText = `${new Date(chat.lastMessage.date).toLocaleString()}`
     }
 
@@ -126,8 +136,9 @@ const newChatElement = (chat) => {
         document.getElementById("chat-messages").innerHTML = ""
         document.getElementById("chat-messages").addEventListener("scroll", loadMessages)
         recipientID = chat.user.id
+        olderMessagesCursor = ""
         updateQueryParams()
-        Ws.send(JSON.stringify({ type: "messagesRequest", body: { userID: recipientID, lastMessageID: 0 } }))
+        Ws.send(JSON.stringify({ type: "messagesRequest", body: { userID: recipientID, cursor: "" } }))
     })
 
     return el
@@ -195,13 +206,12 @@ export default class extends AbstractView {
 
         loadMessages = Utils.debounce(function () {
             if (chatMessages.scrollTop < chatMessages.scrollHeight * 0.1) {
-                let offsetMsg = document.querySelector('.message')
-                if (!offsetMsg) {
+                if (!olderMessagesCursor) {
                     chatMessages.removeEventListener("scroll", loadMessages)
                     return
                 }
-                let offsetMsgID = parseInt(offsetMsg.id.split('-')[1]);
-                Ws.send(JSON.stringify({ type: "messagesRequest", body: { userID: recipientID, lastMessageID: offsetMsgID } }))
+                Ws.send(JSON.stringify({ type: "messagesRequest", body: { userID: recipientID, cursor: olderMessagesCursor } }))
+                olderMessagesCursor = ""
             }
 
             if (chatMessages.scrollTop == 0) {
@@ -257,6 +267,13 @@ export default class extends AbstractView {
         }
     }
 
+    static drawPresence(presence) {
+        const chat = document.getElementById(`chat-${presence.userID}`)
+        if (chat) {
+            chat.classList.toggle('online', presence.online)
+        }
+    }
+
     static drawChats(chats) {
         if (chats != null) {
             const chatsEl = document.getElementById("chats");
@@ -294,6 +311,7 @@ export default class extends AbstractView {
             }
 
             document.getElementById(`chat-${chatId}-lastMessage`).innerText = message.message
+            document.getElementById(`chat-${chatId}-lastMessage`).dataset.messageId = message.id
             document.getElementById(`chat-${chatId}-lastMessage`).style.display = ""
 
             document.getElementById(`chat-${chatId}-lastMessageDate`).innerText = `${new Date(message.date).toLocaleString()}`
@@ -308,12 +326,35 @@ export default class extends AbstractView {
         }
     }
 
-    static async prependMessages(messages) {
+    // replaceMessage redraws an edited or deleted message in the open chat and in the chat list
+    static async replaceMessage(message) {
+        const el = document.getElementById(`message-${message.id}`)
+        if (el) {
+            el.replaceWith(newMessageElement(message))
+        }
+
+        const user = Utils.getUser()
+        const chatId = message.senderID == user.id ? message.recipientID : message.senderID
+        const lastMessage = document.getElementById(`chat-${chatId}-lastMessage`)
+        if (lastMessage && lastMessage.dataset.messageId == message.id) {
+            lastMessage.innerText = message.deletedAt ? 'Message deleted' : message.message
+        }
+    }
+
+    static async prependMessages(page) {
+        if (page.userID != recipientID) {
+            return
+        }
+
         const chatMessages = document.getElementById("chat-messages");
         const scrollToEnd = (chatMessages.childNodes.length == 0)
+        const messages = page.messages
 
-        if (messages == null) {
+        olderMessagesCursor = page.next || ""
+        if (messages == null || !page.next) {
             chatMessages.removeEventListener("scroll", loadMessages)
+        }
+        if (messages == null) {
             return
         }
 
@@ -327,18 +368,27 @@ export default class extends AbstractView {
         })
     }
 
-    static async changeMessageStatusToRead(message) {
+    static async changeMessageStatusToRead(receipt) {
         const user = Utils.getUser()
-        if (message.recipientID == user.id) {
-            changeChatUnreadCount(document.getElementById(`chat-${message.senderID}-unread-messages-count`), -1)
+        if (receipt.recipientID == user.id) {
+            const countEl = document.getElementById(`chat-${receipt.senderID}-unread-messages-count`)
+            if (countEl) {
+                countEl.innerText = ""
+                changeChatUnreadCount(countEl, receipt.unreadMessagesCount)
+            }
+            return
         }
 
-        setTimeout(() => {
-            let el = document.getElementById(`message-${message.id}-status`)
-            if (el) {
-                el.innerText = '✓✓'
+        // every message up to the receipt is read
+        Array.from(document.getElementsByClassName("sended-message")).forEach(el => {
+            const id = parseInt(el.id.replace("message-", ""))
+            if (id <= receipt.id) {
+                const statusEl = document.getElementById(`message-${id}-status`)
+                if (statusEl) {
+                    statusEl.innerText = '✓✓'
+                }
             }
-        }, 1000)
+        })
     }
 
     static async drawTypingIn(event) {
@@ -346,20 +396,15 @@ export default class extends AbstractView {
         const lastMessage = document.getElementById(`chat-${event.senderID}-lastMessage`)
 
         if (indicator) {
-            indicator.style.display = "table"
-            lastMessage.style.display = "none"
-
-            indicator.classList.add("typing")
-            setTimeout(() => {
+            if (event.typing) {
+                indicator.style.display = "table"
+                lastMessage.style.display = "none"
+                indicator.classList.add("typing")
+            } else {
                 indicator.classList.remove("typing")
-            }, 2000)
-
-            setTimeout(() => {
-                if (!indicator.classList.contains("typing")) {
-                    indicator.style.display = "none"
-                    lastMessage.style.display = ""
-                }
-            }, 3000)
+                indicator.style.display = "none"
+                lastMessage.style.display = ""
+            }
         }
     }
 }
\ No newline at end of file
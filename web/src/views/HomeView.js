@@ -4,8 +4,11 @@ import router from "../index.js"
 import Utils from "../services/Utils.js";
 
 var currCategoryID
-var currPageNum
-var postsEnded = false
+var currCursor
+var nextCursor
+var prevCursor
+var currSort
+var currPeriod
 
 const getCategories = async () => {
     const path = "/api/categories"
@@ -23,28 +26,39 @@ const drawCategories = async (categories) => {
         el.addEventListener("click", async () => {
             const titleEl = document.getElementById("category-title")
             currCategoryID = category.id
-            currPageNum = 1
-            postsEnded = false
             titleEl.innerText = category.name
-            document.getElementById("page-number").innerText = currPageNum
 
-            updateQueryParams()
-            drawPostsByCategoryID(category.id, currPageNum)
+            drawPostsByCategoryID(category.id, "")
         })
 
         categoriesEl.append(el)
     })
 }
 
-const drawPostsByCategoryID = async (categoryID, page) => {
+const drawPostsByCategoryID = async (categoryID, cursor) => {
     const postsEl = document.getElementById("posts")
     const postsMsg = document.getElementById("posts-msg")
     postsEl.innerHTML = ""
     postsMsg.innerText = ""
 
-    const path = `/api/categories/${categoryID}/${page}`
+    currCursor = cursor || ""
+    updateQueryParams()
+
+    const feedParams = new URLSearchParams({ sort: currSort, period: currPeriod })
+    if (currCursor) {
+        feedParams.set("cursor", currCursor)
+    }
+    const path = `/api/categories/${categoryID}/posts?${feedParams.toString()}`
 
     const data = await fetcher.get(path)
+    if (!data) {
+        return
+    }
+
+    nextCursor = data.next
+    prevCursor = data.prev
+    document.getElementById("next-button").disabled = !nextCursor
+    document.getElementById("prev-button").disabled = !prevCursor
 
     if (data.posts) {
         data.posts.forEach((post) => {
@@ -53,7 +67,6 @@ const drawPostsByCategoryID = async (categoryID, page) => {
         })
     } else {
         postsMsg.innerText = "No posts"
-        postsEnded = true
     }
 }
 
@@ -85,7 +98,14 @@ const newPostElement = (post) => {
 const updateQueryParams = () => {
     const urlParams = new URLSearchParams(window.location.search)
     urlParams.set('category', currCategoryID)
-    urlParams.set('page', currPageNum)
+    urlParams.delete('page')
+    if (currCursor) {
+        urlParams.set('cursor', currCursor)
+    } else {
+        urlParams.delete('cursor')
+    }
+    urlParams.set('sort', currSort)
+    urlParams.set('period', currPeriod)
     history.replaceState(null, null, "?" + urlParams.toString())
 }
 
@@ -99,12 +119,25 @@ export default class extends AbstractView {
         return `
             <div id="categories"></div>
             <div id="category-title"></div>
+            <div class="feed-options">
+                <select id="feed-sort">
+                    <option value="new">New</option>
+                    <option value="hot">Hot</option>
+                    <option value="top">Top</option>
+                    <option value="discussed">Most discussed</option>
+                </select>
+                <select id="feed-period">
+                    <option value="all">All time</option>
+                    <option value="day">Today</option>
+                    <option value="week">This week</option>
+                    <option value="month">This month</option>
+                </select>
+            </div>
            
             <div id="posts"></div>
             <div id="posts-msg"></div>
             <div class="navigation-buttons">
                 <button id="prev-button">Newer</button>
-                <p id="page-number"></p>
                 <button id="next-button">Older</button>
             </div>
         `;
@@ -113,9 +146,26 @@ export default class extends AbstractView {
     async init() {
         const urlParams = new URLSearchParams(window.location.search)
         currCategoryID = urlParams.get('category') || 1
-        currPageNum = urlParams.get('page') || 1
+        currCursor = urlParams.get('cursor') || ""
+        currSort = urlParams.get('sort') || "new"
+        currPeriod = urlParams.get('period') || "all"
         updateQueryParams()
 
+        const sortEl = document.getElementById("feed-sort")
+        const periodEl = document.getElementById("feed-period")
+        sortEl.value = currSort
+        periodEl.value = currPeriod
+
+        const changeFeed = () => {
+            currSort = sortEl.value
+            currPeriod = periodEl.value
+
+            drawPostsByCategoryID(currCategoryID, "")
+        }
+
+        sortEl.addEventListener("change", changeFeed)
+        periodEl.addEventListener("change", changeFeed)
+
         const categories = await getCategories()
         if (!categories) {
             return
@@ -126,36 +176,25 @@ export default class extends AbstractView {
         if (!categoryEl) {
             Utils.showError(404, `Cannot find category`)
             return
-        } else {
-            categoryEl.click()
         }
+        document.getElementById("category-title").innerText = categoryEl.innerText
+        drawPostsByCategoryID(currCategoryID, currCursor)
 
         const nextButtonEl = document.getElementById(`next-button`)
         const prevButtonEl = document.getElementById(`prev-button`)
-        const pageNumber = document.getElementById(`page-number`)
-        pageNumber.innerText = currPageNum
 
         nextButtonEl.addEventListener("click", () => {
-            if (postsEnded) {
+            if (!nextCursor) {
                 return
             }
-            currPageNum++
-            pageNumber.innerText = currPageNum
-            updateQueryParams()
-
-            drawPostsByCategoryID(currCategoryID, currPageNum)
+            drawPostsByCategoryID(currCategoryID, nextCursor)
         })
 
         prevButtonEl.addEventListener("click", () => {
-            if (currPageNum == 1) {
+            if (!prevCursor) {
                 return
             }
-            postsEnded = false
-            currPageNum--
-            pageNumber.innerText = currPageNum
-            updateQueryParams()
-
-            drawPostsByCategoryID(currCategoryID, currPageNum)
+            drawPostsByCategoryID(currCategoryID, prevCursor)
         })
     }
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ import AbstractView from "./AbstractView.js";
 import Utils from "../services/Utils.js"
 import Ws from "../services/Ws.js";
 import intervals from "../services/Intervals.js";
+import fetcher from "../services/Fetcher.js";
 
 
 export default class extends AbstractView {
@@ -22,6 +23,7 @@ export default class extends AbstractView {
                 <a href="/new-post" class="nav__link" id="new-post-button" data-link>New post</a>
                 <a href="/chats" class="nav__link" id="chats-button" data-link>Chats</a>
                 <a href="/user/${this.user.id}" class="nav__link" id="chats-button" data-link>Profile</a>
+                <a href="/settings" class="nav__link" id="settings-button" data-link>Settings</a>
                 <a href="/" class="nav__link" id="sign-out-button" data-link>Logout</a>
                 `
                 :
@@ -35,7 +37,10 @@ export default class extends AbstractView {
     async init() {
         const signOutButton = document.getElementById("sign-out-button")
         if (signOutButton) {
-            signOutButton.addEventListener('click', () => {
+            signOutButton.addEventListener('click', async () => {
+                // only the session of this browser is ended, the other devices stay signed in
+                const refreshToken = localStorage.getItem("refreshToken")
+                await fetcher.post("/api/user/sign-out", { refreshToken: refreshToken })
                 Utils.logOut()
                 Ws.disconnect()
                 intervals.clearAll()
@@ -8,26 +8,40 @@ const likeTypes = {
     dislike: 2
 }
 
-var currCommentPageNum = 1
+var commentsCursors = {}
 var commentsEnded = false
+var replyParent = null
 
 const getPost = async (postID) => {
     const path = `/api/posts/${postID}`
     return await fetcher.get(path)
 }
 
-const getComments = async (postID, page) => {
-    const path = `/api/posts/${postID}/comments/${page}`
+const getComments = async (postID, cursor) => {
+    const params = new URLSearchParams()
+    if (cursor) {
+        params.set("cursor", cursor)
+    }
+    const path = `/api/posts/${postID}/comments?${params.toString()}`
     return await fetcher.get(path)
 }
 
-const addComment = async (postID, data, image) => {
+const addComment = async (postID, data, image, parentID) => {
     const path = `/api/posts/${postID}/comments`
-    const body = { data: data, image: image }
+    const body = { data: data, image: image, parentID: parentID }
 
     return await fetcher.post(path, body)
 }
 
+const getReplies = async (commentID, cursor) => {
+    const params = new URLSearchParams()
+    if (cursor) {
+        params.set("cursor", cursor)
+    }
+    const path = `/api/comments/${commentID}/replies?${params.toString()}`
+    return await fetcher.get(path)
+}
+
 
 const likePost = async (postID, likeType) => {
     const user = Utils.getUser()
@@ -163,38 +177,71 @@ const drawPost = async (post) => {
 
         const categoryEl = document.createElement("button")
         categoryEl.innerText = `${category.name}`
-        categoryEl.onclick = () => { router.navigateTo(`/?category=${category.id}&page=1`) }
+        categoryEl.onclick = () => { router.navigateTo(`/?category=${category.id}`) }
         categoriesEl.append(categoryEl)
     }
 }
 
-const drawPostCommentsPage = async (postID, page) => {
-    const data = await getComments(postID, page)
-    drawPostComments(data)
+const drawPostCommentsPage = async (postID, cursor) => {
+    const data = await getComments(postID, cursor)
+    if (!data) {
+        return
+    }
+
+    commentsCursors = { next: data.next, prev: data.prev }
+    document.getElementById("next-button").disabled = !data.next
+    document.getElementById("prev-button").disabled = !data.prev
+
+    drawPostComments(data.comments, !cursor)
 }
 
-const drawPostComments = async (comments) => {
+const drawPostComments = async (comments, firstPage) => {
     const commentsEl = document.getElementById("post-comments")
     if (!comments) {
         commentsEnded = true
         commentsEl.innerText = "No comments"
-        if (currCommentPageNum == 1) {
+        if (firstPage) {
             document.querySelector('.navigation-buttons').style.display = 'none'
         }
         return
     }
 
+    commentsEnded = false
+
     commentsEl.innerText = ""
 
     comments.forEach(comment => { drawComment(comment, false) })
 }
 
 const drawComment = (comment, isNewComment) => {
-    const user = Utils.getUser()
     const commentsEl = document.getElementById("post-comments")
+    const commentEl = newCommentElement(comment)
+
+    if (comment.parentID) {
+        const repliesEl = document.getElementById(`comment-${comment.parentID}-replies`)
+        if (repliesEl) {
+            repliesEl.append(commentEl)
+        }
+        return
+    }
+
+    if (isNewComment) {
+        if (commentsEnded) {
+            commentsEl.innerText = ""
+            commentsEnded = false
+        }
+        commentsEl.prepend(commentEl)
+    } else {
+        commentsEl.append(commentEl)
+    }
+}
+
+const newCommentElement = (comment) => {
+    const user = Utils.getUser()
 
     const commentEl = document.createElement("div")
     commentEl.classList.add("post-comment")
+    commentEl.id = `comment-${comment.id}`
 
     const commentAuthor = document.createElement("a")
     if (comment.author.id == user.id) {
@@ -250,15 +297,67 @@ const drawComment = (comment, isNewComment) => {
 
     commentEl.append(rateInfoEl)
 
-    if (isNewComment) {
-        if (commentsEnded) {
-            commentsEl.innerText = ""
-            commentsEnded = false
+    if (user.id) {
+        const replyButton = document.createElement("button")
+        replyButton.classList.add("reply-button")
+        replyButton.innerText = "Reply"
+        replyButton.addEventListener("click", () => { setReplyParent(comment) })
+        commentEl.append(replyButton)
+    }
+
+    const repliesEl = document.createElement("div")
+    repliesEl.classList.add("comment-replies")
+    repliesEl.id = `comment-${comment.id}-replies`
+    commentEl.append(repliesEl)
+
+    drawReplies(comment, repliesEl, comment.replies || [], comment.moreReplies)
+
+    return commentEl
+}
+
+// drawReplies draws a page of replies, and a button loading the rest of the branch while there's more of it.
+const drawReplies = (comment, repliesEl, replies, cursor) => {
+    replies.forEach(reply => {
+        // a reply sent from this page is already drawn
+        if (!document.getElementById(`comment-${reply.id}`)) {
+            repliesEl.append(newCommentElement(reply))
         }
-        commentsEl.prepend(commentEl)
-    } else {
-        commentsEl.append(commentEl)
+    })
+
+    const loaded = repliesEl.querySelectorAll(`:scope > .post-comment`).length
+    if (loaded >= comment.repliesCount) {
+        return
     }
+
+    const moreButton = document.createElement("button")
+    moreButton.classList.add("more-replies-button")
+    moreButton.innerText = `Load more replies (${comment.repliesCount - loaded})`
+    moreButton.addEventListener("click", async () => {
+        const data = await getReplies(comment.id, cursor)
+        if (!data) {
+            return
+        }
+        moreButton.remove()
+        drawReplies(comment, repliesEl, data.comments || [], data.next)
+    })
+    repliesEl.after(moreButton)
+}
+
+const setReplyParent = (comment) => {
+    replyParent = comment
+    const replyingEl = document.getElementById("replying-to")
+    if (!replyingEl) {
+        return
+    }
+
+    if (!comment) {
+        replyingEl.style.display = "none"
+        return
+    }
+
+    replyingEl.style.display = ""
+    document.getElementById("replying-to-name").innerText = `${comment.author.firstName} ${comment.author.lastName}`
+    document.getElementById("comment-input").focus()
 }
 
 export default class extends AbstractView {
@@ -291,7 +390,6 @@ export default class extends AbstractView {
                 <div id="post-comments"></div>
                 <div class="navigation-buttons">
                     <button id="prev-button">Newer</button>
-                    <p id="page-number">1</p>
                     <button id="next-button">Older</button>
                 </div>
             `
@@ -299,6 +397,10 @@ export default class extends AbstractView {
             (authorized ?
                 `
                 <form id="comment-form" onsubmit="return false;">
+                    <div id="replying-to" style="display: none">
+                        Replying to <span id="replying-to-name"></span>
+                        <button type="button" id="cancel-reply-button">Cancel</button>
+                    </div>
                     <textarea id="comment-input" cols="30" rows="5" minlength="2" maxlength="128" placeholder="Leave a comment" required></textarea>
                     <label for="comment-image-input" class="custom-file-input">
                         Choose image
@@ -323,32 +425,27 @@ export default class extends AbstractView {
 
 
     async init() {
+        replyParent = null
         const post = await getPost(this.postID)
         if (post) {
             drawPost(post, this.user)
-            drawPostCommentsPage(this.postID, currCommentPageNum)
+            drawPostCommentsPage(this.postID, "")
 
             const nextButtonEl = document.getElementById(`next-button`)
             const prevButtonEl = document.getElementById(`prev-button`)
-            const pageNumber = document.getElementById(`page-number`)
 
             nextButtonEl.addEventListener("click", () => {
-                if (commentsEnded) {
+                if (!commentsCursors.next) {
                     return
                 }
-                currCommentPageNum++
-                pageNumber.innerText = currCommentPageNum
-                drawPostCommentsPage(this.postID, currCommentPageNum)
+                drawPostCommentsPage(this.postID, commentsCursors.next)
             })
 
             prevButtonEl.addEventListener("click", () => {
-                if (currCommentPageNum == 1) {
+                if (!commentsCursors.prev) {
                     return
                 }
-                commentsEnded = false
-                currCommentPageNum--
-                pageNumber.innerText = currCommentPageNum
-                drawPostCommentsPage(this.postID, currCommentPageNum)
+                drawPostCommentsPage(this.postID, commentsCursors.prev)
             })
 
             if (this.user.id) {
@@ -386,10 +483,14 @@ export default class extends AbstractView {
                 })
     
     
+                document.getElementById("cancel-reply-button").addEventListener("click", () => { setReplyParent(null) })
+
                 document.getElementById("comment-form").addEventListener("submit", async () => {
-                    const comment = await addComment(this.postID, commentText.value, imageBase64)
+                    const parentID = replyParent ? replyParent.id : 0
+                    const comment = await addComment(this.postID, commentText.value, imageBase64, parentID)
                     if (comment) {
                         drawComment(comment, true)
+                        setReplyParent(null)
                         imageBase64 = ""
                         imageInput.value = ""
                         commentText.value = ""
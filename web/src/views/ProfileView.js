@@ -1,24 +1,69 @@
 import fetcher from "../services/Fetcher.js";
+import Utils from "../services/Utils.js";
 import AbstractView from "./AbstractView.js";
 
-const genders = { 1: 'Male', 2: 'Female' }
-const roles = {  1: 'Guest',  2: 'User', 3: 'Moderator',  4: 'Administator' }
+// the API sends the gender and the role as their names
+const genders = { Male: 'Male', Female: 'Female' }
+const roles = { user: 'User', admin: 'Administrator' }
 
+// getUserByID reads the public profile, the own one is read from the account to show the private fields too
 const getUserByID = async (id) => {
-    const path = `/api/users/${id}`
+    const path = id == Utils.getUser().id ? `/api/user/me` : `/api/user/${id}`
     return await fetcher.get(path);
 }
 
-const getUsersPosts = async (userID) => {
-    const path = `/api/users/${userID}/posts`
+const getUsersPosts = async (userID, cursor) => {
+    const path = `/api/user/${userID}/posts?${cursorParams(cursor)}`
     return await fetcher.get(path);
 }
 
-const getUsersRatedPosts = async (userID) => {
-    const path = `/api/users/${userID}/rated-posts`
+const getUsersRatedPosts = async (userID, cursor) => {
+    const path = `/api/user/${userID}/liked-posts?${cursorParams(cursor)}`
     return await fetcher.get(path);
 }
 
+const cursorParams = (cursor) => {
+    const params = new URLSearchParams()
+    if (cursor) {
+        params.set("cursor", cursor)
+    }
+    return params.toString()
+}
+
+// drawPostsPages draws the first page of a list and a button loading the next ones below it.
+const drawPostsPages = async (getPage, drawPosts, buttonEl) => {
+    const drawPage = async (cursor) => {
+        const data = await getPage(cursor)
+        if (!data) {
+            return
+        }
+
+        drawPosts(data.posts || [], !cursor)
+
+        buttonEl.style.display = data.next ? '' : 'none'
+        buttonEl.onclick = () => drawPage(data.next)
+    }
+
+    await drawPage("")
+}
+
+const drawPostsList = (el, posts, firstPage) => {
+    if (posts.length == 0) {
+        if (firstPage) {
+            el.innerText = 'No posts'
+        }
+        return
+    }
+
+    if (el.childElementCount == 0) {
+        el.innerText = ''
+    }
+
+    posts.forEach((post) => {
+        el.append(newPostElement(post))
+    })
+}
+
 const newPostElement = (post) => {
     const el = document.createElement("div")
     el.classList.add("post")
@@ -68,10 +113,12 @@ export default class extends AbstractView {
             </div>
             <h2>Users posts</h2>
             <div id="users-posts"></div>
+            <button id="users-posts-more">More</button>
             <h2>Users liked posts</h2>
             <div id="users-liked-posts"></div>
             <h2>Users disliked posts</h2>
             <div id="users-disliked-posts"></div>
+            <button id="users-rated-posts-more">More</button>
         `;
     }
 
@@ -82,45 +129,38 @@ export default class extends AbstractView {
         document.querySelector('.profile-info#username').innerText = `Username: ${user.username}`
         document.querySelector('.profile-info#first-name').innerText = `First name: ${user.firstName}`
         document.querySelector('.profile-info#last-name').innerText = `Last name: ${user.lastName}`
-        document.querySelector('.profile-info#age').innerText = `Age: ${user.age}`
-        document.querySelector('.profile-info#gender').innerText = `Gender: ${genders[user.gender]}`
-        document.querySelector('.profile-info#role').innerText = `Role: ${roles[user.role]}`
-        document.querySelector('.profile-info#registered').innerText = `Registered: ${new Date(Date.parse(user.registered)).toLocaleString()}`
-
-        const usersPosts = await getUsersPosts(this.userID) 
-        const usersRatedPosts = await getUsersRatedPosts(this.userID)|| []
-
-        const usersPostsEl = document.getElementById('users-posts')
-        if (usersPosts != null) {
-            usersPosts.forEach((post) => {
-                const postEl = newPostElement(post)
-                usersPostsEl.append(postEl)
-            })
-        } else {
-            usersPostsEl.innerText = 'No posts'
+        // the public profile leaves the private fields out
+        if (user.registered) {
+            document.querySelector('.profile-info#age').innerText = `Age: ${user.age}`
+            document.querySelector('.profile-info#gender').innerText = `Gender: ${genders[user.gender] || user.gender}`
+            document.querySelector('.profile-info#role').innerText = `Role: ${roles[user.role] || user.role}`
+            document.querySelector('.profile-info#registered').innerText = `Registered: ${new Date(Date.parse(user.registered)).toLocaleString()}`
         }
 
+        const usersPostsEl = document.getElementById('users-posts')
+        await drawPostsPages(
+            (cursor) => getUsersPosts(this.userID, cursor),
+            (posts, firstPage) => drawPostsList(usersPostsEl, posts, firstPage),
+            document.getElementById('users-posts-more'),
+        )
 
-        const usersLikedPosts = usersRatedPosts.filter((post) => post.userRate == 1)
         const usersLikedPostsEl = document.getElementById('users-liked-posts')
-        if (usersLikedPosts.length > 0 ) {
-            usersLikedPosts.forEach((post) => {
-                const postEl = newPostElement(post)
-                usersLikedPostsEl.append(postEl)
-            })
-        } else {
-            usersLikedPostsEl.innerText = 'No posts'
-        }
-
-        const usersDisLikedPosts = usersRatedPosts.filter((post) => post.userRate == 2)
         const usersDislikedPostsEl = document.getElementById('users-disliked-posts')
-        if (usersDisLikedPosts.length > 0) {
-            usersDisLikedPosts.forEach((post) => {
-                const postEl = newPostElement(post)
-                usersDislikedPostsEl.append(postEl)
-            })
-        } else {
-            usersDislikedPostsEl.innerText = 'No posts'
-        }
+        let likedCount = 0
+        let dislikedCount = 0
+
+        await drawPostsPages(
+            (cursor) => getUsersRatedPosts(this.userID, cursor),
+            (posts, firstPage) => {
+                const liked = posts.filter((post) => post.userRate == 1)
+                const disliked = posts.filter((post) => post.userRate == 2)
+                likedCount += liked.length
+                dislikedCount += disliked.length
+
+                drawPostsList(usersLikedPostsEl, liked, likedCount == 0)
+                drawPostsList(usersDislikedPostsEl, disliked, dislikedCount == 0)
+            },
+            document.getElementById('users-rated-posts-more'),
+        )
     }
 }
\ No newline at end of file
@@ -0,0 +1,176 @@
+import AbstractView from "./AbstractView.js";
+import fetcher from "../services/Fetcher.js";
+import Utils from "../services/Utils.js";
+import Ws from "../services/Ws.js";
+
+const getAccount = async () => {
+    return await fetcher.get(`/api/user/me`);
+};
+
+const drawMessage = (text) => {
+    document.getElementById("error-message").innerText = "";
+    document.getElementById("settings-message").innerText = text;
+};
+
+const drawError = (err) => {
+    document.getElementById("settings-message").innerText = "";
+    document.getElementById("error-message").innerText = err;
+};
+
+// send puts the settings, 400 and 422 errors are drawn by the fetcher, 409 ones come back in the body
+const send = async (path, body) => {
+    drawMessage("");
+    const data = await fetcher.put(path, body);
+    if (!data) {
+        return;
+    }
+    if (data.error) {
+        drawError(data.error);
+        return;
+    }
+    return data;
+};
+
+const drawAccount = (user) => {
+    document.getElementById("settings-avatar").innerHTML = `<img src="http://${API_HOST_NAME}/images/${user.avatar}">`;
+    document.getElementById("first-name").value = user.firstName;
+    document.getElementById("last-name").value = user.lastName;
+    document.getElementById("age").value = user.age;
+    document.querySelector(`input[name="gender"][value="${user.gender}"]`).checked = true;
+    document.getElementById("username").value = user.username;
+    document.getElementById("email").value = user.email;
+    document.getElementById("pending-email").innerText = user.pendingEmail ? `Waiting for verification: ${user.pendingEmail}` : "";
+};
+
+export default class extends AbstractView {
+    constructor(params) {
+        super(params);
+        this.setTitle("Settings");
+    }
+
+    async getHtml() {
+        return `
+            <h2>Settings</h2>
+            <div class="error" id="error-message"></div>
+            <div id="settings-message"></div>
+
+            <form id="avatar-form" class="settings-form" onsubmit="return false;">
+                <div id="settings-avatar"></div>
+                <label for="avatar-input" class="custom-file-input">Choose image</label>
+                <input type="file" id="avatar-input" accept="image/jpeg, image/png, image/gif, image/webp" required>
+                <button type="submit">Change avatar</button>
+            </form>
+
+            <form id="profile-form" class="settings-form" onsubmit="return false;">
+                First name: <br>
+                <input type="text" id="first-name" required maxlength="50"> <br>
+                Last name: <br>
+                <input type="text" id="last-name" required maxlength="50"> <br>
+                Age: <br>
+                <input type="number" id="age" required min="13" max="120"> <br>
+                Gender: <br>
+                <input type="radio" name="gender" value="Male" required> Male
+                <input type="radio" name="gender" value="Female"> Female <br>
+                <button type="submit">Save profile</button>
+            </form>
+
+            <form id="username-form" class="settings-form" onsubmit="return false;">
+                Username: <br>
+                <input type="text" id="username" required minlength="3" maxlength="50" pattern="[a-zA-Z0-9._-]+"> <br>
+                <button type="submit">Change username</button>
+            </form>
+
+            <form id="email-form" class="settings-form" onsubmit="return false;">
+                E-mail: <br>
+                <input type="email" id="email" required maxlength="50"> <br>
+                Current password: <br>
+                <input type="password" id="email-current-password" required> <br>
+                <p id="pending-email"></p>
+                <button type="submit">Change e-mail</button>
+            </form>
+
+            <form id="password-form" class="settings-form" onsubmit="return false;">
+                Current password: <br>
+                <input type="password" id="current-password" required> <br>
+                New password: <br>
+                <input type="password" id="new-password" required minlength="8" maxlength="128"> <br>
+                Confirm new password: <br>
+                <input type="password" id="new-password-confirm" required minlength="8" maxlength="128"> <br>
+                <button type="submit">Change password</button>
+            </form>
+        `;
+    }
+
+    async init() {
+        const user = await getAccount();
+        if (!user) {
+            return;
+        }
+        drawAccount(user);
+
+        document.getElementById("avatar-form").addEventListener("submit", async () => {
+            const form = new FormData();
+            form.append("image", document.getElementById("avatar-input").files[0]);
+
+            const image = await send(`/api/user/avatar`, form);
+            if (image) {
+                document.getElementById("settings-avatar").innerHTML = `<img src="http://${API_HOST_NAME}/images/${image.image}">`;
+                drawMessage("Avatar changed");
+            }
+        });
+
+        document.getElementById("profile-form").addEventListener("submit", async () => {
+            const user = await send(`/api/user/profile`, {
+                firstName: document.getElementById("first-name").value,
+                lastName: document.getElementById("last-name").value,
+                age: parseInt(document.getElementById("age").value),
+                gender: document.querySelector('input[name="gender"]:checked').value,
+            });
+            if (user) {
+                drawAccount(user);
+                drawMessage("Profile saved");
+            }
+        });
+
+        document.getElementById("username-form").addEventListener("submit", async () => {
+            const user = await send(`/api/user/username`, { username: document.getElementById("username").value });
+            if (user) {
+                drawAccount(user);
+                drawMessage("Username changed");
+            }
+        });
+
+        document.getElementById("email-form").addEventListener("submit", async () => {
+            const user = await send(`/api/user/email`, {
+                email: document.getElementById("email").value,
+                currentPassword: document.getElementById("email-current-password").value,
+            });
+            if (user) {
+                document.getElementById("email-current-password").value = "";
+                drawAccount(user);
+                drawMessage(`A verification link has been sent to ${user.pendingEmail}`);
+            }
+        });
+
+        document.getElementById("password-form").addEventListener("submit", async () => {
+            const newPassword = document.getElementById("new-password").value;
+            if (newPassword != document.getElementById("new-password-confirm").value) {
+                drawError("Passwords Doesn't Match");
+                return;
+            }
+
+            const tokens = await send(`/api/user/password`, {
+                currentPassword: document.getElementById("current-password").value,
+                newPassword: newPassword,
+            });
+            if (tokens) {
+                // the other sessions are signed out, this one goes on with the new tokens
+                localStorage.setItem("accessToken", tokens.accessToken);
+                localStorage.setItem("refreshToken", tokens.refreshToken);
+                Ws.authenticate(tokens.accessToken);
+                document.getElementById("password-form").reset();
+                drawMessage("Password changed, other devices were signed out");
+            }
+        });
+    }
+}
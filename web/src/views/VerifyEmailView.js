@@ -0,0 +1,30 @@
+import AbstractView from "./AbstractView.js";
+import fetcher from "../services/Fetcher.js";
+
+export default class extends AbstractView {
+    constructor(params) {
+        super(params);
+        this.setTitle("Verify e-mail");
+    }
+
+    async getHtml() {
+        return `
+            <h2>E-mail verification</h2>
+            <div class="error" id="error-message"></div>
+            <p id="verify-message">Verifying...</p>
+        `;
+    }
+
+    async init() {
+        const token = new URLSearchParams(window.location.search).get("token") || "";
+        const messageEl = document.getElementById("verify-message");
+
+        const data = await fetcher.post(`/api/user/email/verify`, { token: token });
+        if (!data || data.error) {
+            messageEl.innerText = data ? data.error : "";
+            return;
+        }
+
+        messageEl.innerText = `Your e-mail is now ${data.email}`;
+    }
+}